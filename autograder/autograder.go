@@ -0,0 +1,87 @@
+// Package autograder runs submitted programs against an
+// assignment's test cases.
+package autograder
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+)
+
+var (
+	// ErrUnsupportedLanguage is returned by a Runner asked to
+	// run a program in a language it cannot run.
+	ErrUnsupportedLanguage = errors.New("autograder: unsupported language")
+
+	// ErrSandboxUnavailable is returned by a Runner that cannot
+	// isolate programs on the current platform.
+	ErrSandboxUnavailable = errors.New("autograder: sandbox unavailable on this platform")
+)
+
+// Runner runs a program against a list of test cases, returning
+// one result per test case in the same order. Programs that fail
+// a test, including by crashing or timing out, are reported in
+// the results; an error means the tests could not be run at all.
+type Runner interface {
+	Run(ctx context.Context, p db.Program, tests []db.TestCase) ([]db.TestResult, error)
+}
+
+// Limits bounds the resources a program may use per test case.
+type Limits struct {
+	Timeout     time.Duration
+	MemoryBytes int64
+	OutputBytes int
+}
+
+// DefaultLimits are the limits applied by NewLocalRunner.
+var DefaultLimits = Limits{
+	Timeout:     2 * time.Second,
+	MemoryBytes: 256 << 20,
+	OutputBytes: 64 << 10,
+}
+
+// passes reports whether output matches the expected output of a
+// test case. Trailing whitespace on each line and trailing blank
+// lines are ignored, as is the line ending style.
+func passes(expected, output string) bool {
+	return normalize(expected) == normalize(output)
+}
+
+func normalize(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, l := range lines {
+		lines[i] = strings.TrimRight(l, " \t")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// limitedBuffer keeps the first max bytes written to it and
+// silently discards the rest, so that a program flooding its
+// output neither exhausts memory nor blocks on a full pipe.
+// If full is set, it is closed the first time output is discarded.
+type limitedBuffer struct {
+	buf       []byte
+	max       int
+	truncated bool
+	full      chan struct{}
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := b.max - len(b.buf); room < len(p) {
+		b.buf = append(b.buf, p[:room]...)
+		if !b.truncated && b.full != nil {
+			close(b.full)
+		}
+		b.truncated = true
+	} else {
+		b.buf = append(b.buf, p...)
+	}
+	return len(p), nil
+}
+
+func (b *limitedBuffer) String() string {
+	return string(b.buf)
+}
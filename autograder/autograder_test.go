@@ -0,0 +1,65 @@
+package autograder
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+)
+
+func TestPasses(t *testing.T) {
+	assert.True(t, passes("hello\nworld", "hello  \r\nworld\n\n"))
+	assert.False(t, passes("hello world", "hello  world"))
+	assert.False(t, passes("", "output"))
+}
+
+func TestLimitedBuffer(t *testing.T) {
+	b := &limitedBuffer{max: 4}
+	n, err := b.Write([]byte("abc"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	n, err = b.Write([]byte("def"))
+	require.NoError(t, err)
+	assert.Equal(t, 3, n)
+	assert.Equal(t, "abcd", b.String())
+	assert.True(t, b.truncated)
+}
+
+func TestFakeRunner(t *testing.T) {
+	f := &FakeRunner{Outputs: map[string]string{"a": "1\n"}}
+	res, err := f.Run(context.Background(), db.Program{UID: "test"}, []db.TestCase{
+		{ID: "a", Expected: "1"},
+		{ID: "b", Expected: "2"},
+	})
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.True(t, res[0].Passed)
+	assert.False(t, res[1].Passed)
+	assert.Equal(t, []db.Program{{UID: "test"}}, f.Runs)
+}
+
+func TestThrottle(t *testing.T) {
+	th := NewThrottle(1, time.Hour)
+	release, err := th.Acquire("alice")
+	require.NoError(t, err)
+
+	_, err = th.Acquire("bob")
+	assert.Equal(t, ErrBusy, err, "only one run at once")
+	release()
+
+	_, err = th.Acquire("alice")
+	assert.Equal(t, ErrTooSoon, err)
+	release, err = th.Acquire("bob")
+	require.NoError(t, err, "a refused run doesn't start the cooldown")
+	release()
+
+	th = NewThrottle(1, 0)
+	for i := 0; i < 2; i++ {
+		release, err := th.Acquire("alice")
+		require.NoError(t, err)
+		release()
+	}
+}
@@ -0,0 +1,92 @@
+package autograder
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+)
+
+// LocalRunner runs Python programs in a jail on the local machine.
+// Each test case is run in a fresh process with limited CPU time,
+// memory and output, and without network access. The process sees
+// only its own processes and a filesystem holding the program and
+// the read-only Paths, so none of the server's files.
+type LocalRunner struct {
+	// Python is the absolute path of the interpreter used to run
+	// programs, which must lie within Paths.
+	Python string
+
+	// Paths lists the host directories mounted read-only in the
+	// jail at the same place, for the interpreter and its
+	// libraries. Directories which don't exist are skipped.
+	Paths []string
+
+	// UID and GID are the host user and group programs run as.
+	// Only a server running as root may run them as anyone other
+	// than itself, and programs are never run as root.
+	UID, GID int
+
+	Limits Limits
+}
+
+// NewLocalRunner returns a LocalRunner using the system's python3
+// and the default limits. Programs run as nobody if the server is
+// running as root, and otherwise as the server's own user.
+func NewLocalRunner() *LocalRunner {
+	uid, gid := os.Getuid(), os.Getgid()
+	if uid == 0 {
+		uid, gid = nobody, nobody
+	}
+	return &LocalRunner{
+		Python: "/usr/bin/python3",
+		Paths:  []string{"/usr", "/lib", "/lib64", "/bin"},
+		UID:    uid,
+		GID:    gid,
+		Limits: DefaultLimits,
+	}
+}
+
+// nobody is the conventional ID of the unprivileged nobody user
+// and nogroup group.
+const nobody = 65534
+
+func (l *LocalRunner) Run(ctx context.Context, p db.Program, tests []db.TestCase) ([]db.TestResult, error) {
+	if p.Language != "python" {
+		return nil, ErrUnsupportedLanguage
+	}
+
+	// the jail's user must be able to reach the program, which is
+	// kept in src, and the empty root its filesystem is built in.
+	dir, err := ioutil.TempDir("", "tla-autograder")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create working directory")
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Chmod(dir, 0711); err != nil {
+		return nil, errors.Wrap(err, "failed to create working directory")
+	}
+	for _, sub := range []string{"src", "root"} {
+		if err := os.Mkdir(filepath.Join(dir, sub), 0755); err != nil {
+			return nil, errors.Wrap(err, "failed to create working directory")
+		}
+	}
+	if err := ioutil.WriteFile(filepath.Join(dir, "src", "main.py"), []byte(p.Code), 0644); err != nil {
+		return nil, errors.Wrap(err, "failed to write program")
+	}
+
+	results := make([]db.TestResult, len(tests))
+	for i, t := range tests {
+		res, err := l.run(ctx, dir, t.Input)
+		if err != nil {
+			return nil, err
+		}
+		res.ID, res.Name = t.ID, t.Name
+		res.Passed = res.Error == "" && passes(t.Expected, res.Output)
+		results[i] = res
+	}
+	return results, nil
+}
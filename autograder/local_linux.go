@@ -0,0 +1,291 @@
+//go:build linux
+// +build linux
+
+package autograder
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+)
+
+// jailInit is the name the server re-executes itself under to
+// build a jail from inside its new namespaces, since mounts can't
+// be set up for a child from the outside.
+const jailInit = "tla-autograder-jail"
+
+// maxProcesses caps the processes a program may run at once, so
+// that it can't fork bomb the host. The count is kept per user of
+// the jail's user namespace on current kernels; on older ones it
+// includes every process of the host user programs run as, which
+// should then be one of their own.
+const maxProcesses = 64
+
+func init() {
+	if len(os.Args) == 0 || os.Args[0] != jailInit {
+		return
+	}
+	// capabilities are per thread, so they must be dropped on the
+	// thread which goes on to exec the interpreter.
+	runtime.LockOSThread()
+
+	// setup errors are reported on fd 3, which is closed once the
+	// interpreter starts.
+	status := os.NewFile(3, "status")
+	syscall.CloseOnExec(3)
+	err := enterJail(os.Args[1:])
+	fmt.Fprint(status, err)
+	os.Exit(1)
+}
+
+// run runs the program in dir with the given input in a jail: new
+// user, mount, PID, network, IPC and UTS namespaces, with the root
+// filesystem replaced by one holding only the read-only Paths, the
+// program at /work/main.py and an empty /tmp. The interpreter runs
+// as root of the user namespace, mapped to UID on the host, with no
+// capabilities. Programs aren't run as the host's root, whose
+// processes the kernel never caps.
+func (l *LocalRunner) run(ctx context.Context, dir, input string) (db.TestResult, error) {
+	if l.UID == 0 {
+		return db.TestResult{}, errors.Wrap(ErrSandboxUnavailable, "programs can't be run as root")
+	}
+	ctx, cancel := context.WithTimeout(ctx, l.Limits.Timeout)
+	defer cancel()
+
+	cpuSeconds := int64(l.Limits.Timeout.Seconds()) + 1
+	args := append([]string{
+		jailInit,
+		dir,
+		l.Python,
+		strconv.FormatInt(l.Limits.MemoryBytes, 10),
+		strconv.FormatInt(cpuSeconds, 10),
+		strconv.Itoa(l.Limits.OutputBytes),
+		strconv.Itoa(maxProcesses),
+	}, l.Paths...)
+
+	statusR, statusW, err := os.Pipe()
+	if err != nil {
+		return db.TestResult{}, errors.Wrap(err, "failed to create status pipe")
+	}
+	defer statusR.Close()
+
+	cmd := exec.Command("/proc/self/exe")
+	cmd.Args = args
+	cmd.Env = []string{}
+	cmd.ExtraFiles = []*os.File{statusW}
+	cmd.Stdin = strings.NewReader(input)
+	stdout := &limitedBuffer{max: l.Limits.OutputBytes, full: make(chan struct{})}
+	stderr := &limitedBuffer{max: l.Limits.OutputBytes}
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	cmd.SysProcAttr = &syscall.SysProcAttr{
+		Setpgid: true,
+		Cloneflags: syscall.CLONE_NEWUSER | syscall.CLONE_NEWNS | syscall.CLONE_NEWPID |
+			syscall.CLONE_NEWNET | syscall.CLONE_NEWIPC | syscall.CLONE_NEWUTS,
+		UidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: l.UID, Size: 1},
+		},
+		GidMappings: []syscall.SysProcIDMap{
+			{ContainerID: 0, HostID: l.GID, Size: 1},
+		},
+		// become root of the namespace before exec'ing, or the server's
+		// own ids, which aren't mapped, would keep the helper from
+		// getting its capabilities there.
+		Credential: &syscall.Credential{Uid: 0, Gid: 0, NoSetGroups: true},
+	}
+
+	err = cmd.Start()
+	statusW.Close()
+	if err != nil {
+		return db.TestResult{}, errors.Wrap(ErrSandboxUnavailable, err.Error())
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- cmd.Wait() }()
+
+	// the interpreter is PID 1 of the jail, so killing its process
+	// group takes everything it started down with it.
+	kill := func() {
+		syscall.Kill(-cmd.Process.Pid, syscall.SIGKILL)
+		<-done
+	}
+
+	if msg, _ := ioutil.ReadAll(statusR); len(msg) > 0 {
+		kill()
+		return db.TestResult{}, errors.Wrap(ErrSandboxUnavailable, string(msg))
+	}
+
+	select {
+	case err = <-done:
+	case <-stdout.full:
+		kill()
+	case <-ctx.Done():
+		kill()
+		return db.TestResult{
+			Output: stdout.String(),
+			Error:  fmt.Sprintf("timed out after %s", l.Limits.Timeout),
+		}, nil
+	}
+
+	res := db.TestResult{Output: stdout.String()}
+	switch {
+	case stdout.truncated:
+		res.Error = fmt.Sprintf("output exceeded %d bytes", l.Limits.OutputBytes)
+	case err != nil:
+		res.Error = strings.TrimSpace(err.Error() + "\n" + stderr.String())
+	}
+	return res, nil
+}
+
+// enterJail builds the jail described by args, as passed by run,
+// and replaces the current process with the interpreter. It only
+// returns if the jail could not be built.
+func enterJail(args []string) error {
+	if len(args) < 6 {
+		return errors.New("missing jail arguments")
+	}
+	dir, python, paths := args[0], args[1], args[6:]
+	limits := make([]uint64, 4)
+	for i := range limits {
+		n, err := strconv.ParseUint(args[2+i], 10, 64)
+		if err != nil {
+			return errors.Wrap(err, "invalid limit")
+		}
+		limits[i] = n
+	}
+	memory, cpu, output, nproc := limits[0], limits[1], limits[2], limits[3]
+
+	// keep the jail's mounts from propagating back to the host.
+	if err := syscall.Mount("", "/", "", syscall.MS_REC|syscall.MS_PRIVATE, ""); err != nil {
+		return errors.Wrap(err, "failed to make mounts private")
+	}
+	root := filepath.Join(dir, "root")
+	if err := syscall.Mount("tmpfs", root, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, "size=64k,mode=0755"); err != nil {
+		return errors.Wrap(err, "failed to mount root")
+	}
+	for _, p := range paths {
+		if _, err := os.Stat(p); os.IsNotExist(err) {
+			continue
+		}
+		if err := bindReadOnly(p, filepath.Join(root, p)); err != nil {
+			return errors.Wrapf(err, "failed to mount %s", p)
+		}
+	}
+	if err := bindReadOnly(filepath.Join(dir, "src"), filepath.Join(root, "work")); err != nil {
+		return errors.Wrap(err, "failed to mount program")
+	}
+	tmp := filepath.Join(root, "tmp")
+	if err := os.Mkdir(tmp, 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount("tmpfs", tmp, "tmpfs", syscall.MS_NOSUID|syscall.MS_NODEV, fmt.Sprintf("size=%d,mode=1777", output+1024)); err != nil {
+		return errors.Wrap(err, "failed to mount /tmp")
+	}
+
+	// swap the root for the jail's and drop the host's entirely,
+	// which unlike chroot can't be escaped.
+	old := filepath.Join(root, ".old")
+	if err := os.Mkdir(old, 0700); err != nil {
+		return err
+	}
+	if err := syscall.PivotRoot(root, old); err != nil {
+		return errors.Wrap(err, "failed to pivot root")
+	}
+	if err := syscall.Chdir("/"); err != nil {
+		return err
+	}
+	if err := syscall.Unmount("/.old", syscall.MNT_DETACH); err != nil {
+		return errors.Wrap(err, "failed to unmount host root")
+	}
+	if err := os.Remove("/.old"); err != nil {
+		return err
+	}
+	if err := syscall.Mount("", "/", "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV, ""); err != nil {
+		return errors.Wrap(err, "failed to make root read-only")
+	}
+	if err := syscall.Chdir("/tmp"); err != nil {
+		return err
+	}
+
+	rlimits := map[int]uint64{
+		syscall.RLIMIT_AS:    memory,
+		syscall.RLIMIT_CPU:   cpu,
+		syscall.RLIMIT_FSIZE: output + 1024,
+		rlimitNProc:          nproc,
+	}
+	for resource, max := range rlimits {
+		if err := syscall.Setrlimit(resource, &syscall.Rlimit{Cur: max, Max: max}); err != nil {
+			return errors.Wrap(err, "failed to set resource limits")
+		}
+	}
+
+	if err := dropCapabilities(); err != nil {
+		return errors.Wrap(err, "failed to drop capabilities")
+	}
+	return syscall.Exec(python, []string{python, "-I", "/work/main.py"}, []string{"PATH=/usr/bin:/bin", "HOME=/tmp"})
+}
+
+// rlimitNProc and prSetNoNewPrivs are RLIMIT_NPROC and
+// PR_SET_NO_NEW_PRIVS, which the syscall package lacks.
+const (
+	rlimitNProc     = 6
+	prSetNoNewPrivs = 38
+)
+
+// bindReadOnly mounts src at dst, creating dst, and makes the new
+// mount read-only.
+func bindReadOnly(src, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	if err := syscall.Mount(src, dst, "", syscall.MS_BIND, ""); err != nil {
+		return err
+	}
+
+	// flags the host mounted src with are locked, and remounting
+	// without them is refused.
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(dst, &st); err != nil {
+		return err
+	}
+	locked := uintptr(st.Flags) & (syscall.MS_NOEXEC | syscall.MS_NOATIME | syscall.MS_NODIRATIME | syscall.MS_RELATIME)
+	return syscall.Mount("", dst, "", syscall.MS_REMOUNT|syscall.MS_BIND|syscall.MS_RDONLY|syscall.MS_NOSUID|syscall.MS_NODEV|locked, "")
+}
+
+// dropCapabilities gives up every capability of the calling thread
+// for good, so that root of the user namespace can neither undo the
+// jail nor regain capabilities by exec'ing.
+func dropCapabilities() error {
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, prSetNoNewPrivs, 1, 0); errno != 0 {
+		return errno
+	}
+	for c := 0; ; c++ {
+		_, _, errno := syscall.RawSyscall(syscall.SYS_PRCTL, syscall.PR_CAPBSET_DROP, uintptr(c), 0)
+		if errno == syscall.EINVAL {
+			break
+		}
+		if errno != 0 {
+			return errno
+		}
+	}
+
+	header := struct {
+		version uint32
+		pid     int32
+	}{version: 0x20080522} // _LINUX_CAPABILITY_VERSION_3
+	data := [2]struct{ effective, permitted, inheritable uint32 }{}
+	if _, _, errno := syscall.RawSyscall(syscall.SYS_CAPSET, uintptr(unsafe.Pointer(&header)), uintptr(unsafe.Pointer(&data[0])), 0); errno != 0 {
+		return errno
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package autograder
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+)
+
+// runPython runs code against a single test case with a
+// LocalRunner, skipping the test if no sandbox is available.
+func runPython(t *testing.T, code string, test db.TestCase) db.TestResult {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is not installed")
+	}
+	l := NewLocalRunner()
+	l.Limits.Timeout = time.Second
+	res, err := l.Run(context.Background(), db.Program{Language: "python", Code: code}, []db.TestCase{test})
+	if errors.Is(err, ErrSandboxUnavailable) {
+		t.Skip(err)
+	}
+	require.NoError(t, err)
+	require.Len(t, res, 1)
+	return res[0]
+}
+
+func TestLocalRunner(t *testing.T) {
+	t.Run("UnsupportedLanguage", func(t *testing.T) {
+		_, err := NewLocalRunner().Run(context.Background(), db.Program{Language: "html"}, nil)
+		assert.Equal(t, ErrUnsupportedLanguage, err)
+	})
+	t.Run("Pass", func(t *testing.T) {
+		res := runPython(t, "print(int(input()) * 2)", db.TestCase{ID: "double", Input: "21\n", Expected: "42"})
+		assert.True(t, res.Passed, res.Error)
+		assert.Equal(t, "double", res.ID)
+		assert.Equal(t, "42\n", res.Output)
+	})
+	t.Run("Fail", func(t *testing.T) {
+		res := runPython(t, "print(41)", db.TestCase{Expected: "42"})
+		assert.False(t, res.Passed)
+		assert.Empty(t, res.Error)
+	})
+	t.Run("Crash", func(t *testing.T) {
+		res := runPython(t, "raise ValueError('oops')", db.TestCase{})
+		assert.False(t, res.Passed)
+		assert.Contains(t, res.Error, "ValueError: oops")
+	})
+	t.Run("Timeout", func(t *testing.T) {
+		res := runPython(t, "while True:\n    pass", db.TestCase{})
+		assert.False(t, res.Passed)
+		assert.Contains(t, res.Error, "timed out")
+	})
+	t.Run("Memory", func(t *testing.T) {
+		res := runPython(t, "x = bytearray(1 << 30)\nprint('allocated')", db.TestCase{Expected: "allocated"})
+		assert.False(t, res.Passed)
+		assert.Contains(t, res.Error, "MemoryError")
+	})
+	t.Run("Output", func(t *testing.T) {
+		res := runPython(t, "while True:\n    print('spam')", db.TestCase{})
+		assert.False(t, res.Passed)
+		assert.Contains(t, res.Error, "output exceeded")
+	})
+	t.Run("Processes", func(t *testing.T) {
+		code := "import os, time\n" +
+			"n = 0\n" +
+			"try:\n" +
+			"    for _ in range(500):\n" +
+			"        if os.fork() == 0:\n" +
+			"            time.sleep(5)\n" +
+			"            os._exit(0)\n" +
+			"        n += 1\n" +
+			"except OSError:\n" +
+			"    pass\n" +
+			"print(n)"
+		res := runPython(t, code, db.TestCase{})
+		require.Empty(t, res.Error)
+		n, err := strconv.Atoi(strings.TrimSpace(res.Output))
+		require.NoError(t, err)
+		assert.Less(t, n, maxProcesses)
+	})
+	t.Run("Root", func(t *testing.T) {
+		l := NewLocalRunner()
+		l.UID = 0
+		_, err := l.Run(context.Background(), db.Program{Language: "python"}, []db.TestCase{{}})
+		assert.True(t, errors.Is(err, ErrSandboxUnavailable))
+	})
+	t.Run("Network", func(t *testing.T) {
+		code := "import socket\ntry:\n    socket.create_connection(('1.1.1.1', 53), timeout=0.5)\n    print('connected')\nexcept OSError:\n    print('blocked')"
+		res := runPython(t, code, db.TestCase{Expected: "blocked"})
+		assert.True(t, res.Passed, res.Output+res.Error)
+	})
+	t.Run("Isolation", func(t *testing.T) {
+		wd, err := os.Getwd()
+		require.NoError(t, err)
+		code := "import os\n" +
+			"print(os.getpid())\n" +
+			"for p in ['/etc/passwd', '/proc/1/environ', '" + wd + "']:\n" +
+			"    print(p, os.path.exists(p))\n" +
+			"open('/tmp/scratch', 'w').write('ok')\n" +
+			"try:\n    open('/work/main.py', 'a')\nexcept OSError:\n    print('read-only')"
+		res := runPython(t, code, db.TestCase{})
+		assert.Empty(t, res.Error)
+		assert.Equal(t, "1\n/etc/passwd False\n/proc/1/environ False\n"+wd+" False\nread-only\n", res.Output)
+	})
+}
@@ -0,0 +1,73 @@
+package autograder
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrBusy is returned by a Throttle already running as many
+	// programs as it allows.
+	ErrBusy = errors.New("autograder: too many programs running")
+
+	// ErrTooSoon is returned by a Throttle asked to run programs
+	// for a key again before its cooldown is over.
+	ErrTooSoon = errors.New("autograder: run again too soon")
+)
+
+// DefaultConcurrency and DefaultCooldown are the limits applied by
+// the autograding handler.
+const (
+	DefaultConcurrency = 4
+	DefaultCooldown    = 10 * time.Second
+)
+
+// Throttle limits how many runs may go on at once, and how often a
+// run may be started for each key, such as the user asking for it.
+type Throttle struct {
+	slots    chan struct{}
+	cooldown time.Duration
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// NewThrottle returns a Throttle allowing concurrent runs at once
+// and one run per key every cooldown.
+func NewThrottle(concurrent int, cooldown time.Duration) *Throttle {
+	return &Throttle{
+		slots:    make(chan struct{}, concurrent),
+		cooldown: cooldown,
+		last:     make(map[string]time.Time),
+	}
+}
+
+// Acquire starts a run for key, returning the function which ends
+// it. Runs are refused with ErrTooSoon if one was started for key
+// within the cooldown, and with ErrBusy if too many are going on.
+// Only runs which are started count towards the cooldown.
+func (t *Throttle) Acquire(key string) (release func(), err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	now := time.Now()
+	if last, ok := t.last[key]; ok && now.Sub(last) < t.cooldown {
+		return nil, ErrTooSoon
+	}
+	select {
+	case t.slots <- struct{}{}:
+	default:
+		return nil, ErrBusy
+	}
+
+	// forget keys whose cooldown is over, so the map stays small.
+	for k, last := range t.last {
+		if now.Sub(last) >= t.cooldown {
+			delete(t.last, k)
+		}
+	}
+	t.last[key] = now
+	return func() { <-t.slots }, nil
+}
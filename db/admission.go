@@ -0,0 +1,138 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// JoinRequest is a user waiting for an instructor to approve their
+// joining a class.
+type JoinRequest struct {
+	UID         string    `firestore:"uid" json:"uid"`
+	RequestedAt time.Time `firestore:"requestedAt" json:"requestedAt"`
+}
+
+// IsFull reports whether the class has no seats left for members.
+func (c *Class) IsFull() bool {
+	return c.Capacity > 0 && len(c.Members) >= c.Capacity
+}
+
+// checkEnroll reports why uid may not become a member of the
+// class: they are banned, or the class is archived or full.
+func (c *Class) checkEnroll(uid string) error {
+	if c.IsBanned(uid) {
+		return status.Errorf(codes.PermissionDenied, "user %s is banned from class %s", uid, c.CID)
+	}
+	if err := c.CheckActive(); err != nil {
+		return err
+	}
+	if c.IsFull() {
+		return status.Errorf(codes.FailedPrecondition, "class %s is full", c.CID)
+	}
+	return nil
+}
+
+// IsPending reports whether uid is waiting to be approved to join
+// the class.
+func (c *Class) IsPending(uid string) bool {
+	return c.pendingIndex(uid) >= 0
+}
+
+func (c *Class) pendingIndex(uid string) int {
+	for i, r := range c.Pending {
+		if r.UID == uid {
+			return i
+		}
+	}
+	return -1
+}
+
+// removePending drops the join request of uid, returning false if
+// there was none.
+func (c *Class) removePending(uid string) bool {
+	i := c.pendingIndex(uid)
+	if i < 0 {
+		return false
+	}
+	c.Pending = append(c.Pending[:i], c.Pending[i+1:]...)
+	return true
+}
+
+// setAdmission sets the capacity of c and whether joining it needs
+// an instructor's approval, on behalf of actor, who must be an
+// instructor. A capacity of zero means there is no limit.
+func setAdmission(c *Class, actor string, capacity int, requireApproval bool) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if capacity < 0 {
+		return status.Error(codes.InvalidArgument, "capacity can't be negative")
+	}
+	c.Capacity = capacity
+	c.RequireApproval = requireApproval
+	return nil
+}
+
+// approveJoinRequest enrolls the pending user u as a member of c
+// on behalf of actor, who must be an instructor.
+func approveJoinRequest(c *Class, actor string, u *User, at time.Time) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if !c.IsPending(u.UID) {
+		return status.Errorf(codes.NotFound, "user %s has not asked to join class %s", u.UID, c.CID)
+	}
+	if err := c.CheckActive(); err != nil {
+		return err
+	}
+	if c.IsFull() {
+		return status.Errorf(codes.FailedPrecondition, "class %s is full", c.CID)
+	}
+
+	c.removePending(u.UID)
+	c.AddMember(u.UID, at)
+	if !containsString(u.Classes, c.CID) {
+		u.Classes = append(u.Classes, c.CID)
+	}
+	return nil
+}
+
+// rejectJoinRequest drops the join request of uid from c on behalf
+// of actor, who must be an instructor.
+func rejectJoinRequest(c *Class, actor, uid string) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if !c.removePending(uid) {
+		return status.Errorf(codes.NotFound, "user %s has not asked to join class %s", uid, c.CID)
+	}
+	return nil
+}
+
+// SetClassAdmission sets the capacity of the class cid and whether
+// joining it needs approval, on behalf of actor, who must be an
+// instructor.
+func (d *DB) SetClassAdmission(ctx context.Context, cid, actor string, capacity int, requireApproval bool) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return setAdmission(c, actor, capacity, requireApproval)
+	})
+}
+
+// ApproveJoinRequest enrolls the pending user uid in the class cid
+// on behalf of actor, who must be an instructor.
+func (d *DB) ApproveJoinRequest(ctx context.Context, cid, actor, uid string) error {
+	return d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) error {
+		return approveJoinRequest(c, actor, u[0], time.Now().UTC())
+	})
+}
+
+// RejectJoinRequest drops the join request of uid from the class
+// cid on behalf of actor, who must be an instructor.
+func (d *DB) RejectJoinRequest(ctx context.Context, cid, actor, uid string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return rejectJoinRequest(c, actor, uid)
+	})
+}
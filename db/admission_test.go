@@ -0,0 +1,39 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAdmission(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := Class{CID: "test", Instructors: []string{"teacher"}}
+	require.NoError(t, setAdmission(&c, "teacher", 1, true))
+
+	alice := User{UID: "alice"}
+	require.NoError(t, joinClass(&c, &alice, c.WID, now))
+	assert.Empty(t, c.Members)
+	assert.Empty(t, alice.Classes)
+	assert.True(t, c.IsPending("alice"))
+	assert.Empty(t, c.ForMember().Pending)
+
+	// asking again doesn't queue twice.
+	require.NoError(t, joinClass(&c, &alice, c.WID, now))
+	assert.Len(t, c.Pending, 1)
+	assert.Equal(t, 1, c.JoinCode.Uses)
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(approveJoinRequest(&c, "alice", &alice, now)))
+	assert.Equal(t, codes.NotFound, status.Code(approveJoinRequest(&c, "teacher", &User{UID: "bob"}, now)))
+	require.NoError(t, approveJoinRequest(&c, "teacher", &alice, now))
+	assert.Equal(t, []string{"alice"}, c.Members)
+	assert.Equal(t, []string{"test"}, alice.Classes)
+	assert.False(t, c.IsPending("alice"))
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(joinClass(&c, &User{UID: "bob"}, c.WID, now)))
+	assert.Equal(t, codes.NotFound, status.Code(rejectJoinRequest(&c, "teacher", "bob")))
+}
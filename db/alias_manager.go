@@ -203,6 +203,13 @@ func (d *DB) MakeAlias(ctx context.Context, uid string, path string) (string, er
 	return strings.Join(widList, ","), err
 }
 
+// DeleteAlias removes the mapping of the WID wid, so that it no
+// longer resolves.
+func (d *DB) DeleteAlias(ctx context.Context, wid string, path string) error {
+	_, err := d.Collection(path).Doc(wid).Delete(ctx)
+	return err
+}
+
 // GetUIDFromWID returns the UID given a WID
 func (d *DB) GetUIDFromWID(ctx context.Context, wid string, path string) (string, error) {
 
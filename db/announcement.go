@@ -0,0 +1,181 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Announcement is a message posted by an instructor to everyone in
+// a class.
+type Announcement struct {
+	ID        string    `firestore:"ID" json:"id"`
+	CID       string    `firestore:"CID" json:"cid"`
+	Author    string    `firestore:"author" json:"author"`
+	Body      string    `firestore:"body" json:"body"`
+	Pinned    bool      `firestore:"pinned" json:"pinned"`
+	CreatedAt time.Time `firestore:"createdAt" json:"createdAt"`
+	EditedAt  time.Time `firestore:"editedAt" json:"editedAt"`
+
+	// ReadBy lists the UIDs of users who have read the
+	// announcement, and Read whether the requesting user has.
+	ReadBy []string `firestore:"readBy" json:"readBy"`
+	Read   bool     `firestore:"-" json:"read"`
+}
+
+// ForUser returns the announcement as seen by the user uid. Only
+// instructors may see who else has read it.
+func (a Announcement) ForUser(uid string, isInstructor bool) Announcement {
+	a.Read = containsString(a.ReadBy, uid)
+	if !isInstructor {
+		a.ReadBy = nil
+	}
+	return a
+}
+
+// announcementBefore reports whether a comes before b in a class's
+// feed: pinned announcements first, then the newest.
+func announcementBefore(a, b Announcement) bool {
+	if a.Pinned != b.Pinned {
+		return a.Pinned
+	}
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
+	}
+	return a.ID > b.ID
+}
+
+// LoadAnnouncement returns the announcement with the given ID.
+func (d *DB) LoadAnnouncement(ctx context.Context, id string) (Announcement, error) {
+	doc, err := d.Collection(announcementsPath).Doc(id).Get(ctx)
+	if err != nil {
+		return Announcement{}, err
+	}
+
+	a := Announcement{}
+	if err := doc.DataTo(&a); err != nil {
+		return Announcement{}, err
+	}
+	return a, nil
+}
+
+// StoreAnnouncement stores a, replacing its previous version.
+func (d *DB) StoreAnnouncement(ctx context.Context, a Announcement) error {
+	if _, err := d.Collection(announcementsPath).Doc(a.ID).Set(ctx, &a); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateAnnouncement stores only the given fields of a, named by
+// their Firestore keys, leaving the rest of the stored
+// announcement, such as who has read it, as it is. Nothing is
+// written if no fields are given.
+func (d *DB) UpdateAnnouncement(ctx context.Context, a Announcement, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	up, err := fieldUpdates(a, fields)
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection(announcementsPath).Doc(a.ID).Update(ctx, up)
+	return err
+}
+
+// CreateAnnouncement stores a as a new announcement, giving it an
+// ID.
+func (d *DB) CreateAnnouncement(ctx context.Context, a Announcement) (Announcement, error) {
+	ref := d.Collection(announcementsPath).NewDoc()
+	a.ID = ref.ID
+	if _, err := ref.Create(ctx, a); err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// DeleteAnnouncement deletes the announcement with the given ID.
+func (d *DB) DeleteAnnouncement(ctx context.Context, id string) error {
+	if _, err := d.Collection(announcementsPath).Doc(id).Delete(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
+// ListAnnouncements returns up to limit announcements of the class
+// cid in feed order, starting after the announcement with the ID
+// after, or from the top of the feed if after is empty.
+//
+// The query needs a composite index on CID, pinned and createdAt.
+func (d *DB) ListAnnouncements(ctx context.Context, cid, after string, limit int) ([]Announcement, error) {
+	q := d.Collection(announcementsPath).
+		Where("CID", "==", cid).
+		OrderBy("pinned", firestore.Desc).
+		OrderBy("createdAt", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc).
+		Limit(limit)
+	if after != "" {
+		snap, err := d.Collection(announcementsPath).Doc(after).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		q = q.StartAfter(snap)
+	}
+
+	docs := q.Documents(ctx)
+	defer docs.Stop()
+
+	announcements := []Announcement{}
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return announcements, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		a := Announcement{}
+		if err := doc.DataTo(&a); err != nil {
+			return nil, err
+		}
+		announcements = append(announcements, a)
+	}
+}
+
+// MarkAnnouncementsRead records that the user uid has read the
+// announcements with the given IDs, which must belong to the class
+// cid.
+func (d *DB) MarkAnnouncementsRead(ctx context.Context, cid, uid string, ids []string) error {
+	return d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		refs := make([]*firestore.DocumentRef, len(ids))
+		for i, id := range ids {
+			refs[i] = d.Collection(announcementsPath).Doc(id)
+			snap, err := tx.Get(refs[i])
+			if err != nil {
+				return err
+			}
+			a := Announcement{}
+			if err := snap.DataTo(&a); err != nil {
+				return err
+			}
+			if a.CID != cid {
+				return status.Errorf(codes.NotFound, "announcement %s is not in class %s", id, cid)
+			}
+		}
+
+		for _, ref := range refs {
+			if err := tx.Update(ref, []firestore.Update{
+				{Path: "readBy", Value: firestore.ArrayUnion(uid)},
+			}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CheckActive returns a FailedPrecondition error if the class has
+// been archived, and so can't be changed.
+func (c *Class) CheckActive() error {
+	if c.Archived {
+		return status.Errorf(codes.FailedPrecondition, "class %s is archived", c.CID)
+	}
+	return nil
+}
+
+// archiveClass archives or unarchives c on behalf of actor, who
+// must be an instructor.
+func archiveClass(c *Class, actor string, archived bool, at time.Time) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if c.Archived == archived {
+		return nil
+	}
+
+	c.Archived = archived
+	c.ArchivedAt = time.Time{}
+	if archived {
+		c.ArchivedAt = at
+	}
+	return nil
+}
+
+// ArchiveClass archives or unarchives the class cid on behalf of
+// actor, who must be an instructor.
+func (d *DB) ArchiveClass(ctx context.Context, cid, actor string, archived bool) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return archiveClass(c, actor, archived, time.Now().UTC())
+	})
+}
@@ -0,0 +1,32 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestArchive(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := Class{CID: "test", Instructors: []string{"teacher"}, Pending: []JoinRequest{{UID: "bob"}}}
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(archiveClass(&c, "alice", true, now)))
+	require.NoError(t, archiveClass(&c, "teacher", true, now))
+	assert.Equal(t, now, c.ArchivedAt)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(c.CheckActive()))
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(joinClass(&c, &User{UID: "alice"}, c.WID, now)))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(approveJoinRequest(&c, "teacher", &User{UID: "bob"}, now)))
+
+	// archiving twice keeps the original date.
+	require.NoError(t, archiveClass(&c, "teacher", true, now.Add(time.Hour)))
+	assert.Equal(t, now, c.ArchivedAt)
+
+	require.NoError(t, archiveClass(&c, "teacher", false, now))
+	assert.NoError(t, c.CheckActive())
+	assert.True(t, c.ArchivedAt.IsZero())
+}
@@ -0,0 +1,121 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+// Assignment is a struct representation of an assignment
+// document. Each member of the assignment's class works on
+// their own copy of the starter program, created the first
+// time they open the assignment.
+type Assignment struct {
+	AID            string    `firestore:"AID" json:"aid"`
+	CID            string    `firestore:"CID" json:"cid"`
+	Title          string    `firestore:"title" json:"title"`
+	Instructions   string    `firestore:"instructions" json:"instructions"`
+	StarterProgram string    `firestore:"starterProgram" json:"starterProgram"`
+	DueDate        time.Time `firestore:"dueDate" json:"dueDate"`
+	LockDate       time.Time `firestore:"lockDate" json:"lockDate"`
+	Creator        string    `firestore:"creator" json:"creator"`
+	DateCreated    time.Time `firestore:"dateCreated" json:"dateCreated"`
+
+	// Copies maps the UID of each member who has opened the
+	// assignment to the PID of their copy.
+	Copies map[string]string `firestore:"copies" json:"copies"`
+
+	// Extensions maps the UIDs of members granted an extension
+	// to their personal due date.
+	Extensions map[string]time.Time `firestore:"extensions" json:"extensions"`
+
+	Rubric []Criterion `firestore:"rubric" json:"rubric"`
+	Tests  []TestCase  `firestore:"tests" json:"tests"`
+
+	// GradesReleased is set once the instructor publishes grades,
+	// making them visible to members.
+	GradesReleased bool `firestore:"gradesReleased" json:"gradesReleased"`
+
+	// Sections lists the IDs of the class sections the assignment
+	// is listed for. It is listed for every member if empty.
+	Sections []string `firestore:"sections" json:"sections"`
+
+	// PeerReviewers is how many members were assigned to review
+	// each submission, or zero if reviewers haven't been assigned.
+	PeerReviewers int `firestore:"peerReviewers" json:"peerReviewers"`
+}
+
+// IsFor reports whether the assignment is listed for members of
+// any of the given sections.
+func (a Assignment) IsFor(sections []string) bool {
+	if len(a.Sections) == 0 {
+		return true
+	}
+	for _, s := range sections {
+		if containsString(a.Sections, s) {
+			return true
+		}
+	}
+	return false
+}
+
+// CopyFor returns a new copy of the starter program p for
+// this assignment, to be owned by the user uid.
+func (a Assignment) CopyFor(p Program, uid, wid string) Program {
+	return Program{
+		Code:        p.Code,
+		DateCreated: time.Now().UTC().String(),
+		Language:    p.Language,
+		Name:        a.Title,
+		Thumbnail:   p.Thumbnail,
+		WID:         wid,
+		Owner:       uid,
+		Assignment:  a.AID,
+	}
+}
+
+// ForMember returns the assignment as seen by the class member
+// uid, who may only see their own copy and extension, and none of
+// the tests their submission will be graded against.
+func (a Assignment) ForMember(uid string) Assignment {
+	a.Tests = nil
+
+	copies := make(map[string]string)
+	if pid, ok := a.Copies[uid]; ok {
+		copies[uid] = pid
+	}
+	a.Copies = copies
+
+	extensions := make(map[string]time.Time)
+	if ext, ok := a.Extensions[uid]; ok {
+		extensions[uid] = ext
+	}
+	a.Extensions = extensions
+	return a
+}
+
+// addAssignment lists the assignment aid on c, which must not be
+// archived.
+func addAssignment(c *Class, aid string) error {
+	if err := c.CheckActive(); err != nil {
+		return err
+	}
+	if !containsString(c.Assignments, aid) {
+		c.Assignments = append(c.Assignments, aid)
+	}
+	return nil
+}
+
+// AddClassAssignment lists the assignment aid on the class cid.
+func (d *DB) AddClassAssignment(ctx context.Context, cid, aid string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return addAssignment(c, aid)
+	})
+}
+
+// RemoveClassAssignment takes the assignment aid off the class cid.
+func (d *DB) RemoveClassAssignment(ctx context.Context, cid, aid string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		c.Assignments = removeString(c.Assignments, aid)
+		return nil
+	})
+}
@@ -0,0 +1,35 @@
+package db
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"github.com/google/uuid"
+)
+
+// CopyID returns the PID of the member uid's copy of the
+// assignment. It is derived rather than random, so that members
+// opening an assignment twice at once still get a single copy.
+func (a Assignment) CopyID(uid string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(a.AID+"\x00"+uid)).String()
+}
+
+// AddUserProgram adds the program pid to the programs of the user
+// uid, unless they already have it. Members' copies are only listed
+// for their owner, not in the class's programs, so that they stay
+// hidden from the rest of the class.
+func (d *DB) AddUserProgram(ctx context.Context, uid, pid string) error {
+	_, err := d.Collection(usersPath).Doc(uid).Update(ctx, []firestore.Update{
+		{Path: "programs", Value: firestore.ArrayUnion(pid)},
+	})
+	return err
+}
+
+// LinkAssignmentCopy records pid as the member uid's copy of the
+// assignment aid, leaving the rest of the assignment untouched.
+func (d *DB) LinkAssignmentCopy(ctx context.Context, aid, uid, pid string) error {
+	_, err := d.Collection(assignmentsPath).Doc(aid).Update(ctx, []firestore.Update{
+		{FieldPath: firestore.FieldPath{"copies", uid}, Value: pid},
+	})
+	return err
+}
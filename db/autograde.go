@@ -0,0 +1,23 @@
+package db
+
+// TestCase is an instructor-defined check run against submissions
+// by an autograder. The program passes if, given Input on stdin, it
+// prints Expected to stdout, ignoring trailing whitespace.
+type TestCase struct {
+	ID       string `firestore:"id" json:"id"`
+	Name     string `firestore:"name" json:"name"`
+	Input    string `firestore:"input" json:"input"`
+	Expected string `firestore:"expected" json:"expected"`
+}
+
+// TestResult is the outcome of running a single TestCase.
+type TestResult struct {
+	ID     string `firestore:"id" json:"id"`
+	Name   string `firestore:"name" json:"name"`
+	Passed bool   `firestore:"passed" json:"passed"`
+	Output string `firestore:"output" json:"output"`
+
+	// Error describes why the program failed to run to
+	// completion, such as a timeout or a crash.
+	Error string `firestore:"error" json:"error"`
+}
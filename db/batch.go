@@ -0,0 +1,96 @@
+package db
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+)
+
+// getAll fetches the documents with the given IDs from the
+// collection at path in a single round trip, skipping those that
+// don't exist.
+func (d *DB) getAll(ctx context.Context, path string, ids []string) ([]*firestore.DocumentSnapshot, error) {
+	if len(ids) == 0 {
+		return nil, nil
+	}
+	refs := make([]*firestore.DocumentRef, len(ids))
+	for i, id := range ids {
+		refs[i] = d.Collection(path).Doc(id)
+	}
+
+	snaps, err := d.GetAll(ctx, refs)
+	if err != nil {
+		return nil, err
+	}
+	found := snaps[:0]
+	for _, snap := range snaps {
+		if snap.Exists() {
+			found = append(found, snap)
+		}
+	}
+	return found, nil
+}
+
+// LoadUsers returns the users with the given UIDs, keyed by UID.
+// Users that don't exist are left out.
+func (d *DB) LoadUsers(ctx context.Context, uids []string) (map[string]User, error) {
+	snaps, err := d.getAll(ctx, usersPath, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	users := make(map[string]User, len(snaps))
+	for _, snap := range snaps {
+		u := User{}
+		if err := snap.DataTo(&u); err != nil {
+			return nil, err
+		}
+		u.UID = snap.Ref.ID
+		users[u.UID] = u
+	}
+	return users, nil
+}
+
+// LoadPrograms returns the programs with the given PIDs, keyed by
+// PID. Programs that don't exist are left out.
+func (d *DB) LoadPrograms(ctx context.Context, pids []string) (map[string]Program, error) {
+	snaps, err := d.getAll(ctx, programsPath, pids)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make(map[string]Program, len(snaps))
+	for _, snap := range snaps {
+		p := Program{}
+		if err := snap.DataTo(&p); err != nil {
+			return nil, err
+		}
+		p.UID = snap.Ref.ID
+		programs[p.UID] = p
+	}
+	return programs, nil
+}
+
+// LoadSubmissions returns the submissions of the given users for
+// the assignment aid, keyed by UID. Users who haven't submitted are
+// left out.
+func (d *DB) LoadSubmissions(ctx context.Context, aid string, uids []string) (map[string]Submission, error) {
+	ids := make([]string, len(uids))
+	for i, uid := range uids {
+		ids[i] = submissionID(aid, uid)
+	}
+	snaps, err := d.getAll(ctx, submissionsPath, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	submissions := make(map[string]Submission, len(snaps))
+	for _, snap := range snaps {
+		s := Submission{}
+		if err := snap.DataTo(&s); err != nil {
+			return nil, err
+		}
+		submissions[s.UID] = s
+	}
+	return submissions, nil
+}
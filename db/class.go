@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/labstack/echo/v4"
@@ -25,7 +26,40 @@ type Class struct {
 	CID         string   `firestore:"CID" json:"cid"`
 	WID         string   `firestore:"WID" json:"wid"`
 	Description string   `firestore:"description" json:"description"`
-
+	Assignments []string `firestore:"assignments" json:"assignments"`
+
+	// JoinDates maps the UID of each member and instructor to
+	// when they joined the class.
+	JoinDates map[string]time.Time `firestore:"joinDates" json:"joinDates"`
+
+	// Invitations lists the UIDs and emails of users invited to
+	// the class who don't have an account yet. They are enrolled
+	// when their account is created.
+	Invitations []string `firestore:"invitations" json:"invitations"`
+
+	// Banned lists the UIDs of users who may not rejoin the class,
+	// and Moderation records every removal and ban.
+	Banned     []string           `firestore:"banned" json:"banned"`
+	Moderation []ModerationAction `firestore:"moderation" json:"moderation"`
+
+	// JoinCode limits who may join the class with its WID.
+	JoinCode JoinCode `firestore:"joinCode" json:"joinCode"`
+
+	// Capacity is the most members the class may have, or zero
+	// if there is no limit. If RequireApproval is set, users who
+	// join wait in Pending until an instructor approves them.
+	Capacity        int           `firestore:"capacity" json:"capacity"`
+	RequireApproval bool          `firestore:"requireApproval" json:"requireApproval"`
+	Pending         []JoinRequest `firestore:"pending" json:"pending"`
+
+	// Archived classes are read-only and hidden from class lists
+	// by default.
+	Archived   bool      `firestore:"archived" json:"archived"`
+	ArchivedAt time.Time `firestore:"archivedAt" json:"archivedAt"`
+
+	// Sections splits the members of the class into groups, each
+	// led by some of its instructors as TAs.
+	Sections []Section `firestore:"sections" json:"sections"`
 }
 
 // AddClassToUser takes a uid and a pid,
@@ -132,6 +166,7 @@ func (d *DB) CreateClass(c echo.Context) error {
 		Instructors: []string{req.UID},
 		Members:     []string{},
 		Programs:    []string{},
+		JoinDates:   map[string]time.Time{req.UID: time.Now().UTC()},
 	}
 
 	// create a new doc for this class
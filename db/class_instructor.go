@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsInstructor reports whether uid is an instructor of the class.
+func (c *Class) IsInstructor(uid string) bool {
+	return containsString(c.Instructors, uid)
+}
+
+// addInstructor makes u an instructor of c on behalf of actor,
+// who must already be one and not a TA, since a TA could otherwise
+// make anyone an instructor free of sections. Members are moved
+// out of the member list, since each user holds a single role in a
+// class.
+func addInstructor(c *Class, actor string, u *User) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if c.isTA(actor) {
+		return status.Errorf(codes.PermissionDenied, "TAs of class %s can't add instructors", c.CID)
+	}
+	if c.IsInstructor(u.UID) {
+		return status.Errorf(codes.AlreadyExists, "user %s is already an instructor of class %s", u.UID, c.CID)
+	}
+
+	if !containsString(c.Members, u.UID) {
+		if c.JoinDates == nil {
+			c.JoinDates = make(map[string]time.Time)
+		}
+		c.JoinDates[u.UID] = time.Now().UTC()
+	}
+	c.Members = removeString(c.Members, u.UID)
+	c.dropFromSections(u.UID)
+	c.Instructors = append(c.Instructors, u.UID)
+	if !containsString(u.Classes, c.CID) {
+		u.Classes = append(u.Classes, c.CID)
+	}
+	return nil
+}
+
+// promoteMember makes the member u an instructor of c on behalf
+// of actor.
+func promoteMember(c *Class, actor string, u *User) error {
+	if !containsString(c.Members, u.UID) {
+		return status.Errorf(codes.FailedPrecondition, "user %s is not a member of class %s", u.UID, c.CID)
+	}
+	return addInstructor(c, actor, u)
+}
+
+// removeInstructor removes the instructor u from c on behalf of
+// actor. Only the creator may remove instructors, and the creator
+// can't be removed, so a class always keeps an instructor.
+func removeInstructor(c *Class, actor string, u *User) error {
+	if actor != c.Creator {
+		return status.Errorf(codes.PermissionDenied, "only the creator of class %s may remove instructors", c.CID)
+	}
+	if !c.IsInstructor(u.UID) {
+		return status.Errorf(codes.NotFound, "user %s is not an instructor of class %s", u.UID, c.CID)
+	}
+	if u.UID == c.Creator {
+		return status.Errorf(codes.FailedPrecondition, "the creator of class %s must transfer it before leaving", c.CID)
+	}
+	if len(c.Instructors) == 1 {
+		return status.Errorf(codes.FailedPrecondition, "class %s must keep at least one instructor", c.CID)
+	}
+
+	c.Instructors = removeString(c.Instructors, u.UID)
+	c.dropFromSections(u.UID)
+	delete(c.JoinDates, u.UID)
+	u.Classes = removeString(u.Classes, c.CID)
+	return nil
+}
+
+// transferClass makes to the creator of c in place of from. The
+// new creator must already belong to the class, and is promoted
+// to instructor if they are a member.
+func transferClass(c *Class, from, to string) error {
+	if c.Creator != from {
+		return status.Errorf(codes.PermissionDenied, "user %s is not the creator of class %s", from, c.CID)
+	}
+	if from == to {
+		return nil
+	}
+	if !c.IsInstructor(to) {
+		if !containsString(c.Members, to) {
+			return status.Errorf(codes.FailedPrecondition, "user %s is not in class %s", to, c.CID)
+		}
+		c.Members = removeString(c.Members, to)
+		c.dropFromSections(to)
+		c.Instructors = append(c.Instructors, to)
+	}
+
+	c.Creator = to
+	return nil
+}
+
+// classTransaction runs f on the class cid and the users uids
+// within a transaction, storing all of them if f succeeds.
+func (d *DB) classTransaction(ctx context.Context, cid string, uids []string, f func(*Class, []*User) error) error {
+	return d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		cref := d.Collection(classesPath).Doc(cid)
+		csnap, err := tx.Get(cref)
+		if err != nil {
+			return err
+		}
+		c := Class{}
+		if err := csnap.DataTo(&c); err != nil {
+			return err
+		}
+
+		users := make([]*User, len(uids))
+		for i, uid := range uids {
+			usnap, err := tx.Get(d.Collection(usersPath).Doc(uid))
+			if err != nil {
+				return err
+			}
+			users[i] = &User{}
+			if err := usnap.DataTo(users[i]); err != nil {
+				return err
+			}
+			users[i].UID = uid
+		}
+
+		if err := f(&c, users); err != nil {
+			return err
+		}
+
+		if err := tx.Set(cref, &c); err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := tx.Set(d.Collection(usersPath).Doc(u.UID), u); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// AddClassInstructor makes the user uid an instructor of the class
+// cid on behalf of actor, who must be an instructor.
+func (d *DB) AddClassInstructor(ctx context.Context, cid, actor, uid string) error {
+	return d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) error {
+		return addInstructor(c, actor, u[0])
+	})
+}
+
+// PromoteClassMember makes the member uid an instructor of the class
+// cid on behalf of actor, who must be an instructor.
+func (d *DB) PromoteClassMember(ctx context.Context, cid, actor, uid string) error {
+	return d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) error {
+		return promoteMember(c, actor, u[0])
+	})
+}
+
+// RemoveClassInstructor removes the instructor uid from the class
+// cid on behalf of actor, who must be the class's creator.
+func (d *DB) RemoveClassInstructor(ctx context.Context, cid, actor, uid string) error {
+	return d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) error {
+		return removeInstructor(c, actor, u[0])
+	})
+}
+
+// TransferClass makes the user to the creator of the class cid in
+// place of the user from.
+func (d *DB) TransferClass(ctx context.Context, cid, from, to string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return transferClass(c, from, to)
+	})
+}
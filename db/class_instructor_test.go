@@ -0,0 +1,66 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestClassInstructors(t *testing.T) {
+	newClass := func() Class {
+		return Class{
+			CID:         "test",
+			Creator:     "creator",
+			Instructors: []string{"creator", "co"},
+			Members:     []string{"member"},
+		}
+	}
+
+	t.Run("Add", func(t *testing.T) {
+		c, u := newClass(), User{UID: "new"}
+		assert.Equal(t, codes.PermissionDenied, status.Code(addInstructor(&c, "member", &u)))
+		assert.Equal(t, codes.AlreadyExists, status.Code(addInstructor(&c, "co", &User{UID: "creator"})))
+		ta := newClass()
+		ta.Sections = []Section{{ID: "a", TAs: []string{"co"}}}
+		assert.Equal(t, codes.PermissionDenied, status.Code(addInstructor(&ta, "co", &u)))
+		assert.Equal(t, codes.PermissionDenied, status.Code(promoteMember(&ta, "co", &User{UID: "member"})))
+		require.NoError(t, addInstructor(&c, "co", &u))
+		assert.Equal(t, []string{"creator", "co", "new"}, c.Instructors)
+		assert.Contains(t, c.JoinDates, "new")
+		assert.Equal(t, []string{"test"}, u.Classes)
+	})
+	t.Run("Promote", func(t *testing.T) {
+		c := newClass()
+		assert.Equal(t, codes.FailedPrecondition, status.Code(promoteMember(&c, "creator", &User{UID: "new"})))
+		require.NoError(t, promoteMember(&c, "creator", &User{UID: "member"}))
+		assert.Empty(t, c.Members)
+		assert.True(t, c.IsInstructor("member"))
+	})
+	t.Run("Remove", func(t *testing.T) {
+		c := newClass()
+		u := User{UID: "co", Classes: []string{"test"}}
+		assert.Equal(t, codes.PermissionDenied, status.Code(removeInstructor(&c, "co", &u)))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(removeInstructor(&c, "creator", &User{UID: "creator"})))
+		assert.Equal(t, codes.NotFound, status.Code(removeInstructor(&c, "creator", &User{UID: "member"})))
+		require.NoError(t, removeInstructor(&c, "creator", &u))
+		assert.Equal(t, []string{"creator"}, c.Instructors)
+		assert.Empty(t, u.Classes)
+	})
+	t.Run("LastInstructor", func(t *testing.T) {
+		// legacy classes may not list their creator as an instructor.
+		c := Class{Creator: "creator", Instructors: []string{"only"}}
+		assert.Equal(t, codes.FailedPrecondition, status.Code(removeInstructor(&c, "creator", &User{UID: "only"})))
+	})
+	t.Run("Transfer", func(t *testing.T) {
+		c := newClass()
+		assert.Equal(t, codes.PermissionDenied, status.Code(transferClass(&c, "co", "member")))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(transferClass(&c, "creator", "outsider")))
+		require.NoError(t, transferClass(&c, "creator", "member"))
+		assert.Equal(t, "member", c.Creator)
+		assert.True(t, c.IsInstructor("member"))
+		assert.True(t, c.IsInstructor("creator"))
+	})
+}
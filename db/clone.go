@@ -0,0 +1,122 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+)
+
+// CloneFor returns a new class for the next term of c, created by
+// the instructor uid. The instructors, description, thumbnail and
+// admission settings are kept, but not the members, programs,
+// assignments or join code, and the new class has no CID or WID
+// yet. Templates and assignments are copied separately.
+func (c Class) CloneFor(uid, name string, at time.Time) Class {
+	if name == "" {
+		name = c.Name
+	}
+	instructors := append([]string{}, c.Instructors...)
+	if !containsString(instructors, uid) {
+		instructors = append(instructors, uid)
+	}
+	joinDates := make(map[string]time.Time, len(instructors))
+	for _, i := range instructors {
+		joinDates[i] = at
+	}
+
+	return Class{
+		Thumbnail:       c.Thumbnail,
+		Name:            name,
+		Creator:         uid,
+		Instructors:     instructors,
+		Members:         []string{},
+		Programs:        []string{},
+		Description:     c.Description,
+		Assignments:     []string{},
+		JoinDates:       joinDates,
+		Capacity:        c.Capacity,
+		RequireApproval: c.RequireApproval,
+	}
+}
+
+// CloneFor returns a copy of the assignment for the class cid,
+// created by uid, that starts from the program starter. Due and
+// lock dates are moved by offset. Member copies, extensions and
+// released grades are left behind.
+func (a Assignment) CloneFor(cid, starter, uid string, offset time.Duration, at time.Time) Assignment {
+	shift := func(t time.Time) time.Time {
+		if t.IsZero() {
+			return t
+		}
+		return t.Add(offset)
+	}
+
+	return Assignment{
+		CID:            cid,
+		Title:          a.Title,
+		Instructions:   a.Instructions,
+		StarterProgram: starter,
+		DueDate:        shift(a.DueDate),
+		LockDate:       shift(a.LockDate),
+		Creator:        uid,
+		DateCreated:    at,
+		Copies:         make(map[string]string),
+		Rubric:         append([]Criterion(nil), a.Rubric...),
+		Tests:          append([]TestCase(nil), a.Tests...),
+	}
+}
+
+// IsTemplateOf reports whether p is one of the class's templates,
+// a program an instructor added to the class rather than a
+// member's program or copy of an assignment.
+func (p Program) IsTemplateOf(c Class) bool {
+	return p.Assignment == "" && c.IsInstructor(p.Owner)
+}
+
+// CloneFor returns a copy of the program p for the class with the
+// WID wid, to be owned by the user uid.
+func (p Program) CloneFor(uid, wid string) Program {
+	return Program{
+		Code:        p.Code,
+		DateCreated: time.Now().UTC().String(),
+		Language:    p.Language,
+		Name:        p.Name,
+		Thumbnail:   p.Thumbnail,
+		WID:         wid,
+		Owner:       uid,
+	}
+}
+
+// stringValues returns ss as values for firestore.ArrayUnion and
+// firestore.ArrayRemove.
+func stringValues(ss []string) []interface{} {
+	values := make([]interface{}, len(ss))
+	for i, s := range ss {
+		values[i] = s
+	}
+	return values
+}
+
+// AddUserClass adds the class cid, along with the programs pids,
+// to the lists of the user uid in a single write, leaving the rest
+// of the user as it is.
+func (d *DB) AddUserClass(ctx context.Context, uid, cid string, pids []string) error {
+	up := []firestore.Update{{Path: "classes", Value: firestore.ArrayUnion(cid)}}
+	if len(pids) != 0 {
+		up = append(up, firestore.Update{Path: "programs", Value: firestore.ArrayUnion(stringValues(pids)...)})
+	}
+	_, err := d.Collection(usersPath).Doc(uid).Update(ctx, up)
+	return err
+}
+
+// RemoveUserClass undoes AddUserClass, taking the class cid and the
+// programs pids off the lists of the user uid.
+func (d *DB) RemoveUserClass(ctx context.Context, uid, cid string, pids []string) error {
+	up := []firestore.Update{{Path: "classes", Value: firestore.ArrayRemove(cid)}}
+	if len(pids) != 0 {
+		up = append(up, firestore.Update{Path: "programs", Value: firestore.ArrayRemove(stringValues(pids)...)})
+	}
+	_, err := d.Collection(usersPath).Doc(uid).Update(ctx, up)
+	return err
+}
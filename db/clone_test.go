@@ -0,0 +1,48 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloneFor(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := Class{
+		CID:         "test",
+		WID:         "a,b,c",
+		Name:        "Spring",
+		Creator:     "teacher",
+		Instructors: []string{"teacher"},
+		Members:     []string{"alice"},
+		Banned:      []string{"bob"},
+		Capacity:    10,
+	}
+
+	clone := c.CloneFor("ta", "", now)
+	assert.Equal(t, "Spring", clone.Name)
+	assert.Equal(t, "ta", clone.Creator)
+	assert.Equal(t, []string{"teacher", "ta"}, clone.Instructors)
+	assert.Equal(t, []string{"teacher"}, c.Instructors)
+	assert.Empty(t, clone.Members)
+	assert.Empty(t, clone.Banned)
+	assert.Empty(t, clone.WID)
+	assert.Equal(t, 10, clone.Capacity)
+
+	a := Assignment{
+		AID:        "a",
+		Title:      "Spirals",
+		DueDate:    now,
+		Copies:     map[string]string{"alice": "p"},
+		Rubric:     []Criterion{{ID: "style", Points: 2}},
+		Extensions: map[string]time.Time{"alice": now},
+	}
+	ac := a.CloneFor("new", "starter", "ta", 24*time.Hour, now)
+	assert.Equal(t, now.Add(24*time.Hour), ac.DueDate)
+	assert.True(t, ac.LockDate.IsZero())
+	assert.Empty(t, ac.Copies)
+	assert.Empty(t, ac.Extensions)
+	assert.Equal(t, a.Rubric, ac.Rubric)
+	assert.Equal(t, "starter", ac.StarterProgram)
+}
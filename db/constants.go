@@ -20,6 +20,10 @@ const (
 	// the number of program thumbnails available to choose from.
 	ThumbnailCount = 58
 
+	// MaxRecentPrograms is the number of programs remembered
+	// in a user's list of recent programs.
+	MaxRecentPrograms = 10
+
 	// programsPath describes the path to the program
 	// management endpoint.
 	programsPath = "programs"
@@ -32,6 +36,33 @@ const (
 	// management endpoint.
 	classesPath = "classes"
 
+	// assignmentsPath describes the path to the assignments
+	// management endpoint.
+	assignmentsPath = "assignments"
+
+	// submissionsPath describes the path to the collection
+	// of assignment submissions.
+	submissionsPath = "submissions"
+
+	// announcementsPath describes the path to the collection
+	// of class announcements.
+	announcementsPath = "announcements"
+
+	// snapshotsPath describes the path to the collection of
+	// class snapshots, each holding the captured programs in a
+	// subcollection at snapshotProgramsPath.
+	snapshotsPath        = "snapshots"
+	snapshotProgramsPath = "programs"
+
+	// peerReviewsPath describes the path to the collection of
+	// members' reviews of each other's submissions.
+	peerReviewsPath = "peerReviews"
+
+	// threadsPath and repliesPath describe the paths to the
+	// collections of class discussion threads and their replies.
+	threadsPath = "threads"
+	repliesPath = "replies"
+
 	// classesAliasPath describes the path to the collection with 3 word id => hash mapping for classes
 	ClassesAliasPath = "classes_alias"
 
@@ -43,6 +74,14 @@ const (
 	slotPerShard = aliasSize / numShards             // how many IDs we have per shard
 	shardCap     = slotPerShard
 
+	// likesPath, likeShardsPath and viewShardsPath describe the
+	// subcollections of a program document holding per-user likes
+	// and the shards of its like and view counters.
+	likesPath      = "likes"
+	likeShardsPath = "likeShards"
+	viewShardsPath = "viewShards"
+	statShards     = 8 // number of shards per program counter
+
 	msgTypeRead  = "READ"
 	msgTypeError = "ERROR"
 )
@@ -0,0 +1,163 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	// ProgressNotStarted, ProgressStarted, ProgressSubmitted and
+	// ProgressGraded are how far a member has got with an
+	// assignment: not yet opened it, opened their copy, submitted
+	// it, and had their submission graded.
+	ProgressNotStarted = "notStarted"
+	ProgressStarted    = "started"
+	ProgressSubmitted  = "submitted"
+	ProgressGraded     = "graded"
+)
+
+// Dashboard summarizes the progress of every member of a class on
+// its assignments.
+type Dashboard struct {
+	Assignments []DashboardAssignment `json:"assignments"`
+	Members     []MemberProgress      `json:"members"`
+}
+
+// DashboardAssignment is an assignment as listed on a Dashboard.
+type DashboardAssignment struct {
+	AID      string    `json:"aid"`
+	Title    string    `json:"title"`
+	DueDate  time.Time `json:"dueDate"`
+	Sections []string  `json:"sections"`
+}
+
+// MemberProgress is the progress of one member of a class.
+type MemberProgress struct {
+	UID          string `json:"uid"`
+	DisplayName  string `json:"displayName"`
+	ProgramCount int    `json:"programCount"`
+
+	// LastEdited is when the member last saved a copy of one of
+	// the class's assignments, and LastActive when they last
+	// opened or saved any program or submitted an assignment.
+	LastEdited time.Time `json:"lastEdited"`
+	LastActive time.Time `json:"lastActive"`
+
+	// Assignments is keyed by AID, and leaves out assignments that
+	// aren't listed for the member's section.
+	Assignments map[string]AssignmentProgress `json:"assignments"`
+}
+
+// AssignmentProgress is the progress of one member on one
+// assignment.
+type AssignmentProgress struct {
+	Status      string    `json:"status"`
+	PID         string    `json:"pid"`
+	LastEdited  time.Time `json:"lastEdited"`
+	SubmittedAt time.Time `json:"submittedAt"`
+	Late        bool      `json:"late"`
+	Attempts    int       `json:"attempts"`
+
+	// Score is nil until the submission has been graded.
+	Score *float64 `json:"score"`
+}
+
+// latest returns the later of a and b.
+func latest(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
+
+// LoadDashboard builds the dashboard of class for its members.
+// Users, copies and submissions are read in batches rather than
+// one at a time, so the number of reads grows with the number of
+// assignments and not with the number of members.
+func LoadDashboard(ctx context.Context, d TLADB, class Class) (Dashboard, error) {
+	dash := Dashboard{
+		Assignments: make([]DashboardAssignment, 0, len(class.Assignments)),
+		Members:     make([]MemberProgress, 0, len(class.Members)),
+	}
+
+	assignments := make([]Assignment, 0, len(class.Assignments))
+	copies := []string{}
+	for _, aid := range class.Assignments {
+		a, err := d.LoadAssignment(ctx, aid)
+		if err != nil {
+			return Dashboard{}, err
+		}
+		assignments = append(assignments, a)
+		dash.Assignments = append(dash.Assignments, DashboardAssignment{
+			AID:      a.AID,
+			Title:    a.Title,
+			DueDate:  a.DueDate,
+			Sections: a.Sections,
+		})
+		for _, uid := range class.Members {
+			if pid, ok := a.Copies[uid]; ok {
+				copies = append(copies, pid)
+			}
+		}
+	}
+
+	users, err := d.LoadUsers(ctx, class.Members)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	programs, err := d.LoadPrograms(ctx, copies)
+	if err != nil {
+		return Dashboard{}, err
+	}
+	submissions := make([]map[string]Submission, len(assignments))
+	for i, a := range assignments {
+		if submissions[i], err = d.LoadSubmissions(ctx, a.AID, class.Members); err != nil {
+			return Dashboard{}, err
+		}
+	}
+
+	for _, uid := range class.Members {
+		u := users[uid]
+		m := MemberProgress{
+			UID:          uid,
+			DisplayName:  u.DisplayName,
+			ProgramCount: len(u.Programs),
+			Assignments:  make(map[string]AssignmentProgress),
+		}
+		if len(u.RecentPrograms) > 0 {
+			m.LastActive = u.RecentPrograms[0].LastOpened
+		}
+
+		sections := class.SectionsOf(uid)
+		for i, a := range assignments {
+			if !a.IsFor(sections) {
+				continue
+			}
+
+			p := AssignmentProgress{Status: ProgressNotStarted}
+			if pid, ok := a.Copies[uid]; ok {
+				p.Status = ProgressStarted
+				p.PID = pid
+				p.LastEdited = programs[pid].DateModified
+				m.LastEdited = latest(m.LastEdited, p.LastEdited)
+			}
+			if s, ok := submissions[i][uid]; ok {
+				p.Status = ProgressSubmitted
+				p.SubmittedAt = s.SubmittedAt
+				p.Late = s.Late
+				p.Attempts = s.Attempts
+				if s.Grade != nil {
+					p.Status = ProgressGraded
+					score := s.Grade.Total
+					p.Score = &score
+				}
+				m.LastActive = latest(m.LastActive, s.SubmittedAt)
+			}
+			m.Assignments[a.AID] = p
+		}
+		m.LastActive = latest(m.LastActive, m.LastEdited)
+
+		dash.Members = append(dash.Members, m)
+	}
+	return dash, nil
+}
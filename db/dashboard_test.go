@@ -0,0 +1,60 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDashboard(t *testing.T) {
+	ctx := context.Background()
+	opened := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	edited := opened.Add(time.Hour)
+	submitted := edited.Add(time.Hour)
+
+	d := OpenMock()
+	class := Class{
+		CID:         "test",
+		Instructors: []string{"teacher"},
+		Members:     []string{"alice", "bob", "carol"},
+		Assignments: []string{"spirals", "squares"},
+		Sections:    []Section{{ID: "a", Members: []string{"carol"}}},
+	}
+	require.NoError(t, d.StoreAssignment(ctx, Assignment{AID: "spirals", Copies: map[string]string{"alice": "alice-spirals", "bob": "bob-spirals"}}))
+	require.NoError(t, d.StoreAssignment(ctx, Assignment{AID: "squares", Copies: map[string]string{}, Sections: []string{"b"}}))
+	require.NoError(t, d.StoreUser(ctx, User{UID: "alice", DisplayName: "Alice", Programs: []string{"alice-spirals"}, RecentPrograms: []RecentProgram{{PID: "alice-spirals", LastOpened: opened}}}))
+	require.NoError(t, d.StoreUser(ctx, User{UID: "bob", Programs: []string{"bob-spirals", "other"}}))
+	require.NoError(t, d.StoreProgram(ctx, Program{UID: "alice-spirals", DateModified: edited}))
+	require.NoError(t, d.StoreProgram(ctx, Program{UID: "bob-spirals"}))
+	require.NoError(t, d.StoreSubmission(ctx, Submission{AID: "spirals", UID: "alice", SubmittedAt: submitted, Attempts: 2, Grade: &Grade{Total: 4}}))
+
+	dash, err := LoadDashboard(ctx, d, class)
+	require.NoError(t, err)
+	require.Len(t, dash.Assignments, 2)
+	require.Len(t, dash.Members, 3)
+
+	alice := dash.Members[0]
+	assert.Equal(t, "Alice", alice.DisplayName)
+	assert.Equal(t, 1, alice.ProgramCount)
+	assert.Equal(t, edited, alice.LastEdited)
+	assert.Equal(t, submitted, alice.LastActive)
+	require.Contains(t, alice.Assignments, "spirals")
+	assert.Equal(t, ProgressGraded, alice.Assignments["spirals"].Status)
+	assert.Equal(t, 2, alice.Assignments["spirals"].Attempts)
+	if assert.NotNil(t, alice.Assignments["spirals"].Score) {
+		assert.Equal(t, 4.0, *alice.Assignments["spirals"].Score)
+	}
+
+	bob := dash.Members[1]
+	assert.Equal(t, 2, bob.ProgramCount)
+	assert.Equal(t, ProgressStarted, bob.Assignments["spirals"].Status)
+	assert.True(t, bob.LastActive.IsZero())
+	assert.NotContains(t, bob.Assignments, "squares", "squares is only listed for section b")
+
+	carol := dash.Members[2]
+	assert.Equal(t, ProgressNotStarted, carol.Assignments["spirals"].Status)
+	assert.NotContains(t, carol.Assignments, "squares")
+}
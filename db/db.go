@@ -15,6 +15,9 @@ import (
 type DB struct {
 	// Primary database connection.
 	*firestore.Client
+
+	// In-process search index over program names and code.
+	index *programIndex
 }
 
 func (d *DB) LoadProgram(ctx context.Context, pid string) (Program, error) {
@@ -34,9 +37,27 @@ func (d *DB) StoreProgram(ctx context.Context, p Program) error {
 	if _, err := d.Collection(programsPath).Doc(p.UID).Set(ctx, &p); err != nil {
 		return err
 	}
+	d.index.add(p)
 	return nil
 }
 
+// UpdateProgramFields stores only the given fields of p, named by
+// their Firestore keys, leaving the rest of the stored program,
+// such as its code, as it is. Nothing is written if no fields are
+// given. The fields must not be searchable ones, which are only
+// re-indexed by StoreProgram.
+func (d *DB) UpdateProgramFields(ctx context.Context, p Program, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	up, err := fieldUpdates(p, fields)
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection(programsPath).Doc(p.UID).Update(ctx, up)
+	return err
+}
+
 func (d *DB) CreateUser(ctx context.Context, u User) (User, error) {
 	// create a new doc for the user if necessary
 	ref := d.Collection(usersPath).NewDoc()
@@ -64,6 +85,7 @@ func (d *DB) CreateProgram(ctx context.Context, p Program) (Program, error) {
 	if _, err := newProg.Create(ctx, p); err != nil {
 		return p, err
 	}
+	d.index.add(p)
 
 	return p, nil
 }
@@ -72,6 +94,7 @@ func (d *DB) RemoveProgram(ctx context.Context, pid string) error {
 	if _, err := d.Collection(programsPath).Doc(pid).Delete(ctx); err != nil {
 		return err
 	}
+	d.index.remove(pid)
 	return nil
 }
 
@@ -103,6 +126,70 @@ func (d *DB) DeleteClass(ctx context.Context, cid string) error {
 	return nil
 }
 
+func (d *DB) LoadAssignment(ctx context.Context, aid string) (Assignment, error) {
+	doc, err := d.Collection(assignmentsPath).Doc(aid).Get(ctx)
+	if err != nil {
+		return Assignment{}, err
+	}
+
+	a := Assignment{}
+	if err := doc.DataTo(&a); err != nil {
+		return Assignment{}, err
+	}
+	return a, nil
+}
+
+func (d *DB) StoreAssignment(ctx context.Context, a Assignment) error {
+	if _, err := d.Collection(assignmentsPath).Doc(a.AID).Set(ctx, &a); err != nil {
+		return err
+	}
+	return nil
+}
+
+// UpdateAssignment stores only the given fields of a, named by
+// their Firestore keys, leaving the rest of the stored assignment,
+// such as members' copies, as it is. Nothing is written if no
+// fields are given.
+func (d *DB) UpdateAssignment(ctx context.Context, a Assignment, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	up, err := fieldUpdates(a, fields)
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection(assignmentsPath).Doc(a.AID).Update(ctx, up)
+	return err
+}
+
+func (d *DB) CreateAssignment(ctx context.Context, a Assignment) (Assignment, error) {
+	ref := d.Collection(assignmentsPath).NewDoc()
+	a.AID = ref.ID
+	if _, err := ref.Create(ctx, a); err != nil {
+		return a, err
+	}
+
+	return a, nil
+}
+
+// AddClass stores c as a new class document, giving it a CID.
+func (d *DB) AddClass(ctx context.Context, c Class) (Class, error) {
+	ref := d.Collection(classesPath).NewDoc()
+	c.CID = ref.ID
+	if _, err := ref.Create(ctx, c); err != nil {
+		return c, err
+	}
+
+	return c, nil
+}
+
+func (d *DB) DeleteAssignment(ctx context.Context, aid string) error {
+	if _, err := d.Collection(assignmentsPath).Doc(aid).Delete(ctx); err != nil {
+		return err
+	}
+	return nil
+}
+
 func (d *DB) LoadUser(ctx context.Context, uid string) (User, error) {
 	doc, err := d.Collection(usersPath).Doc(uid).Get(ctx)
 	if err != nil {
@@ -148,7 +235,7 @@ func Open(ctx context.Context, cfg string) (*DB, error) {
 
 	// acquire the firestore client, fail if we cannot.
 	client, err := app.Firestore(ctx)
-	return &DB{Client: client}, err
+	return &DB{Client: client, index: newProgramIndex()}, err
 }
 
 // OpenFromJSON returns a pointer to a new database client based
@@ -162,5 +249,5 @@ func OpenFromJSON(ctx context.Context, path string) (*DB, error) {
 	}
 
 	client, err := app.Firestore(ctx)
-	return &DB{Client: client}, err
+	return &DB{Client: client, index: newProgramIndex()}, err
 }
@@ -0,0 +1,55 @@
+package db
+
+import (
+	"reflect"
+	"strings"
+
+	"cloud.google.com/go/firestore"
+	"github.com/pkg/errors"
+)
+
+// field returns the field of the struct v stored under the given
+// Firestore key.
+func field(v reflect.Value, key string) (reflect.Value, error) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if strings.Split(t.Field(i).Tag.Get("firestore"), ",")[0] == key {
+			return v.Field(i), nil
+		}
+	}
+	return reflect.Value{}, errors.Errorf("%s has no field %s", t.Name(), key)
+}
+
+// fieldUpdates returns the updates setting each of the fields of
+// the struct v named by their Firestore keys, leaving the rest of
+// the document as it is.
+func fieldUpdates(v interface{}, fields []string) ([]firestore.Update, error) {
+	up := make([]firestore.Update, len(fields))
+	for i, key := range fields {
+		f, err := field(reflect.ValueOf(v), key)
+		if err != nil {
+			return nil, err
+		}
+		up[i] = firestore.Update{Path: key, Value: f.Interface()}
+	}
+	return up, nil
+}
+
+// copyFields sets each of the fields of the struct dst points to,
+// named by their Firestore keys, to their values in src, which must
+// be of the same type. It mirrors applying fieldUpdates(src, fields)
+// to the stored dst.
+func copyFields(dst, src interface{}, fields []string) error {
+	for _, key := range fields {
+		to, err := field(reflect.ValueOf(dst).Elem(), key)
+		if err != nil {
+			return err
+		}
+		from, err := field(reflect.ValueOf(src), key)
+		if err != nil {
+			return err
+		}
+		to.Set(from)
+	}
+	return nil
+}
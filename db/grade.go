@@ -0,0 +1,78 @@
+package db
+
+import (
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Criterion is a single line of an assignment's rubric.
+type Criterion struct {
+	ID          string  `firestore:"id" json:"id"`
+	Description string  `firestore:"description" json:"description"`
+	Points      float64 `firestore:"points" json:"points"`
+}
+
+// Grade is an instructor's assessment of a submission against
+// its assignment's rubric.
+type Grade struct {
+	// Scores and Comments are keyed by criterion ID.
+	Scores   map[string]float64 `firestore:"scores" json:"scores"`
+	Comments map[string]string  `firestore:"comments" json:"comments"`
+	Feedback string             `firestore:"feedback" json:"feedback"`
+	Total    float64            `firestore:"total" json:"total"`
+	Grader   string             `firestore:"grader" json:"grader"`
+	GradedAt time.Time          `firestore:"gradedAt" json:"gradedAt"`
+}
+
+// MaxPoints returns the total number of points available
+// on the assignment's rubric.
+func (a Assignment) MaxPoints() (total float64) {
+	for _, c := range a.Rubric {
+		total += c.Points
+	}
+	return
+}
+
+// criterion returns the rubric criterion with the given ID.
+func (a Assignment) criterion(id string) (Criterion, bool) {
+	for _, c := range a.Rubric {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return Criterion{}, false
+}
+
+// Grade returns a grade for the assignment from the given scores
+// and comments, keyed by criterion ID. Criteria left unscored
+// count for no points. Fails if a score or comment refers to a
+// criterion not on the rubric, or a score is out of range.
+func (a Assignment) Grade(scores map[string]float64, comments map[string]string, feedback, grader string, at time.Time) (Grade, error) {
+	g := Grade{
+		Scores:   make(map[string]float64, len(scores)),
+		Comments: make(map[string]string, len(comments)),
+		Feedback: feedback,
+		Grader:   grader,
+		GradedAt: at,
+	}
+	for id, score := range scores {
+		c, ok := a.criterion(id)
+		if !ok {
+			return Grade{}, status.Errorf(codes.InvalidArgument, "criterion %s is not on the rubric", id)
+		}
+		if score < 0 || score > c.Points {
+			return Grade{}, status.Errorf(codes.InvalidArgument, "score for criterion %s must be between 0 and %g", id, c.Points)
+		}
+		g.Scores[id] = score
+		g.Total += score
+	}
+	for id, comment := range comments {
+		if _, ok := a.criterion(id); !ok {
+			return Grade{}, status.Errorf(codes.InvalidArgument, "criterion %s is not on the rubric", id)
+		}
+		g.Comments[id] = comment
+	}
+	return g, nil
+}
@@ -0,0 +1,39 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGrade(t *testing.T) {
+	a := Assignment{Rubric: []Criterion{
+		{ID: "style", Description: "Style", Points: 2},
+		{ID: "works", Description: "Correctness", Points: 8},
+	}}
+	assert.Equal(t, 10.0, a.MaxPoints())
+
+	t.Run("Valid", func(t *testing.T) {
+		g, err := a.Grade(map[string]float64{"style": 1.5, "works": 8}, map[string]string{"style": "indent"}, "nice", "teacher", time.Now())
+		require.NoError(t, err)
+		assert.Equal(t, 9.5, g.Total)
+		assert.Equal(t, "indent", g.Comments["style"])
+		assert.Equal(t, "teacher", g.Grader)
+	})
+	t.Run("UnknownCriterion", func(t *testing.T) {
+		_, err := a.Grade(map[string]float64{"nope": 1}, nil, "", "teacher", time.Now())
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = a.Grade(nil, map[string]string{"nope": "?"}, "", "teacher", time.Now())
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+	t.Run("OutOfRange", func(t *testing.T) {
+		_, err := a.Grade(map[string]float64{"style": 3}, nil, "", "teacher", time.Now())
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+		_, err = a.Grade(map[string]float64{"style": -1}, nil, "", "teacher", time.Now())
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
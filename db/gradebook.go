@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// WriteGradebook writes the gradebook of class to w as CSV, with
+// one row per member giving their display name, their score on and
+// whether they submitted late for each assignment, and their total.
+// Rows are written as each member is loaded, so the gradebook is
+// never held in memory all at once, and cells which spreadsheets
+// would evaluate as formulas are quoted.
+//
+// Scores are left empty for ungraded or missing submissions, and
+// grades are included whether or not they have been released. A
+// member whose user can't be loaded is still listed, without a
+// display name, rather than cutting the gradebook short.
+func WriteGradebook(ctx context.Context, d TLADB, class Class, w io.Writer) error {
+	assignments := make([]Assignment, 0, len(class.Assignments))
+	for _, aid := range class.Assignments {
+		a, err := d.LoadAssignment(ctx, aid)
+		if err != nil {
+			return err
+		}
+		assignments = append(assignments, a)
+	}
+
+	out := csv.NewWriter(w)
+	header := []string{"uid", "displayName"}
+	for _, a := range assignments {
+		header = append(header,
+			csvCell(a.Title+" ("+formatPoints(a.MaxPoints())+")"),
+			csvCell(a.Title+" late"),
+		)
+	}
+	header = append(header, "total")
+	if err := out.Write(header); err != nil {
+		return err
+	}
+
+	for _, uid := range class.Members {
+		u, _ := d.LoadUser(ctx, uid)
+		row := []string{csvCell(uid), csvCell(u.DisplayName)}
+		total := 0.0
+		for _, a := range assignments {
+			s, err := d.LoadSubmission(ctx, a.AID, uid)
+			if status.Code(err) == codes.NotFound {
+				row = append(row, "", "")
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			score := ""
+			if s.Grade != nil {
+				score = formatPoints(s.Grade.Total)
+				total += s.Grade.Total
+			}
+			row = append(row, score, strconv.FormatBool(s.Late))
+		}
+		row = append(row, formatPoints(total))
+
+		if err := out.Write(row); err != nil {
+			return err
+		}
+		out.Flush()
+		if err := out.Error(); err != nil {
+			return err
+		}
+	}
+
+	out.Flush()
+	return out.Error()
+}
+
+// csvCell returns s as a CSV cell which spreadsheets won't evaluate
+// as a formula, quoting it with a leading ' if it starts with one
+// of the characters which begin a formula.
+func csvCell(s string) string {
+	if s != "" && strings.ContainsAny(s[:1], "=+-@\t\r") {
+		return "'" + s
+	}
+	return s
+}
+
+// formatPoints formats a number of points without trailing zeros.
+func formatPoints(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
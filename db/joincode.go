@@ -0,0 +1,153 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// JoinCode limits who may join a class with its WID.
+type JoinCode struct {
+	// Expires is when the WID stops working. The zero time
+	// means it never expires.
+	Expires time.Time `firestore:"expires" json:"expires"`
+
+	// MaxUses is how many users may join with the WID, and Uses
+	// how many have. A MaxUses of zero means there is no limit.
+	MaxUses int `firestore:"maxUses" json:"maxUses"`
+	Uses    int `firestore:"uses" json:"uses"`
+
+	// Paused stops anyone from joining the class.
+	Paused bool `firestore:"paused" json:"paused"`
+}
+
+// check returns why the WID of class cid can't be used at the
+// given time, if it can't.
+func (j JoinCode) check(cid string, at time.Time) error {
+	switch {
+	case j.Paused:
+		return status.Errorf(codes.FailedPrecondition, "joining class %s is paused", cid)
+	case !j.Expires.IsZero() && !at.Before(j.Expires):
+		return status.Errorf(codes.FailedPrecondition, "the join code of class %s has expired", cid)
+	case j.MaxUses > 0 && j.Uses >= j.MaxUses:
+		return status.Errorf(codes.FailedPrecondition, "the join code of class %s has been used up", cid)
+	}
+	return nil
+}
+
+// joinClass enrolls u as a member of c through wid, if it is still
+// the class's WID, the join code allows it, and the class isn't full
+// or archived. If joining c needs an instructor's approval, u is
+// queued in c.Pending instead. Joining a class u already belongs to
+// or asked to join doesn't count as a use of the WID, and only
+// makes sure a member has it in their class list.
+func joinClass(c *Class, u *User, wid string, at time.Time) error {
+	if wid != c.WID {
+		return status.Errorf(codes.NotFound, "join code %s has been retired", wid)
+	}
+	if c.IsBanned(u.UID) {
+		return status.Errorf(codes.PermissionDenied, "user %s is banned from class %s", u.UID, c.CID)
+	}
+	if c.IsPending(u.UID) {
+		return nil
+	}
+	if !c.IsInstructor(u.UID) && !containsString(c.Members, u.UID) {
+		if err := c.checkEnroll(u.UID); err != nil {
+			return err
+		}
+		if err := c.JoinCode.check(c.CID, at); err != nil {
+			return err
+		}
+
+		c.JoinCode.Uses++
+		if c.RequireApproval {
+			c.Pending = append(c.Pending, JoinRequest{UID: u.UID, RequestedAt: at})
+			return nil
+		}
+		c.AddMember(u.UID, at)
+	}
+
+	if !containsString(u.Classes, c.CID) {
+		u.Classes = append(u.Classes, c.CID)
+	}
+	return nil
+}
+
+// rotateWID replaces the WID of c with wid on behalf of actor,
+// who must be an instructor. The new WID starts with no uses.
+func rotateWID(c *Class, actor, wid string) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	c.WID = wid
+	c.JoinCode.Uses = 0
+	return nil
+}
+
+// setJoinCode replaces the join code limits of c on behalf of
+// actor, who must be an instructor. The uses of the current WID
+// are kept.
+func setJoinCode(c *Class, actor string, j JoinCode) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if j.MaxUses < 0 {
+		return status.Error(codes.InvalidArgument, "maxUses can't be negative")
+	}
+	j.Uses = c.JoinCode.Uses
+	c.JoinCode = j
+	return nil
+}
+
+// JoinClass enrolls the user uid as a member of the class cid
+// through the WID wid, or queues them for approval, returning the
+// updated class.
+func (d *DB) JoinClass(ctx context.Context, cid, wid, uid string) (Class, error) {
+	class := Class{}
+	err := d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) error {
+		if err := joinClass(c, u[0], wid, time.Now().UTC()); err != nil {
+			return err
+		}
+		class = *c
+		return nil
+	})
+	return class, err
+}
+
+// RotateClassWID gives the class cid a new WID on behalf of actor,
+// who must be an instructor, retiring the old one so it can no
+// longer be used to join or to add programs. The old WID still
+// resolves to the class, since programs created through it keep
+// it. Returns the new WID.
+func (d *DB) RotateClassWID(ctx context.Context, cid, actor string) (string, error) {
+	class, err := d.LoadClass(ctx, cid)
+	if err != nil {
+		return "", err
+	}
+	if !class.IsInstructor(actor) {
+		return "", status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, cid)
+	}
+
+	wid, err := d.MakeAlias(ctx, cid, ClassesAliasPath)
+	if err != nil {
+		return "", err
+	}
+	if err := d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return rotateWID(c, actor, wid)
+	}); err != nil {
+		// don't leave the unused alias pointing at the class.
+		_ = d.DeleteAlias(ctx, wid, ClassesAliasPath)
+		return "", err
+	}
+	return wid, nil
+}
+
+// SetClassJoinCode replaces the join code limits of the class cid
+// on behalf of actor, who must be an instructor.
+func (d *DB) SetClassJoinCode(ctx context.Context, cid, actor string, j JoinCode) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return setJoinCode(c, actor, j)
+	})
+}
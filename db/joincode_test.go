@@ -0,0 +1,41 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestJoinCode(t *testing.T) {
+	now := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := Class{CID: "test", WID: "old", Instructors: []string{"teacher"}}
+
+	alice := User{UID: "alice"}
+	require.NoError(t, joinClass(&c, &alice, c.WID, now))
+	assert.Equal(t, []string{"alice"}, c.Members)
+	assert.Equal(t, []string{"test"}, alice.Classes)
+	assert.Equal(t, 1, c.JoinCode.Uses)
+
+	// rejoining isn't counted.
+	require.NoError(t, joinClass(&c, &alice, c.WID, now))
+	assert.Equal(t, 1, c.JoinCode.Uses)
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(setJoinCode(&c, "alice", JoinCode{})))
+	require.NoError(t, setJoinCode(&c, "teacher", JoinCode{MaxUses: 2, Expires: now.Add(time.Hour)}))
+	assert.Equal(t, 1, c.JoinCode.Uses)
+
+	require.NoError(t, joinClass(&c, &User{UID: "bob"}, c.WID, now))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(joinClass(&c, &User{UID: "carol"}, c.WID, now)))
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(rotateWID(&c, "alice", "new")))
+	require.NoError(t, rotateWID(&c, "teacher", "new"))
+	assert.Equal(t, "new", c.WID)
+	assert.Equal(t, codes.NotFound, status.Code(joinClass(&c, &User{UID: "carol"}, "old", now)))
+	require.NoError(t, joinClass(&c, &User{UID: "carol"}, c.WID, now))
+
+	assert.Equal(t, codes.FailedPrecondition, status.Code(joinClass(&c, &User{UID: "dave"}, c.WID, now.Add(time.Hour))))
+}
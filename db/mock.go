@@ -2,32 +2,52 @@ package db
 
 import (
 	"context"
+	"sort"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/pkg/errors"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	// "errors"
 )
 
 type MockDB struct {
 	// "Users, Programs, Class" collection
 	db map[string]map[string]interface{}
+
+	index *programIndex
 }
 
 func (d *MockDB) LoadProgram(_ context.Context, pid string) (Program, error) {
 	p, ok := d.db[programsPath][pid].(Program)
 	if !ok {
-		return Program{}, errors.New("program has not been created")
+		return Program{}, status.Error(codes.NotFound, "program has not been created")
 	}
 	return p, nil
 }
 
+func (d *MockDB) UpdateProgramFields(_ context.Context, p Program, fields ...string) error {
+	stored, ok := d.db[programsPath][p.UID].(Program)
+	if !ok {
+		return status.Error(codes.NotFound, "program has not been created")
+	}
+	if err := copyFields(&stored, p, fields); err != nil {
+		return err
+	}
+	d.db[programsPath][p.UID] = stored
+	return nil
+}
+
 func (d *MockDB) StoreProgram(_ context.Context, p Program) error {
 	d.db[programsPath][p.UID] = p
+	d.index.add(p)
 	return nil
 }
 
 func (d *MockDB) RemoveProgram(_ context.Context, pid string) error {
 	delete(d.db[programsPath], pid)
+	d.index.remove(pid)
 	return nil
 }
 
@@ -49,6 +69,381 @@ func (d *MockDB) DeleteClass(_ context.Context, cid string) error {
 	return nil
 }
 
+func (d *MockDB) LoadAssignment(_ context.Context, aid string) (a Assignment, err error) {
+	a, ok := d.db[assignmentsPath][aid].(Assignment)
+	if !ok {
+		err = status.Error(codes.NotFound, "invalid assignment ID")
+	}
+	return
+}
+
+func (d *MockDB) StoreAssignment(_ context.Context, a Assignment) error {
+	d.db[assignmentsPath][a.AID] = a
+	return nil
+}
+
+func (d *MockDB) UpdateAssignment(_ context.Context, a Assignment, fields ...string) error {
+	stored, ok := d.db[assignmentsPath][a.AID].(Assignment)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid assignment ID")
+	}
+	if err := copyFields(&stored, a, fields); err != nil {
+		return err
+	}
+	d.db[assignmentsPath][a.AID] = stored
+	return nil
+}
+
+func (d *MockDB) CreateAssignment(_ context.Context, a Assignment) (Assignment, error) {
+	a.AID = uuid.New().String()
+	d.db[assignmentsPath][a.AID] = a
+	return a, nil
+}
+
+func (d *MockDB) AddClass(_ context.Context, c Class) (Class, error) {
+	c.CID = uuid.New().String()
+	d.db[classesPath][c.CID] = c
+	return c, nil
+}
+
+func (d *MockDB) LinkAssignmentCopy(_ context.Context, aid, uid, pid string) error {
+	a, ok := d.db[assignmentsPath][aid].(Assignment)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid assignment ID")
+	}
+	if a.Copies == nil {
+		a.Copies = make(map[string]string)
+	}
+	a.Copies[uid] = pid
+	d.db[assignmentsPath][aid] = a
+	return nil
+}
+
+func (d *MockDB) DeleteAssignment(_ context.Context, aid string) error {
+	delete(d.db[assignmentsPath], aid)
+	return nil
+}
+
+func (d *MockDB) LoadSubmission(_ context.Context, aid, uid string) (s Submission, err error) {
+	s, ok := d.db[submissionsPath][submissionID(aid, uid)].(Submission)
+	if !ok {
+		err = status.Error(codes.NotFound, "submission does not exist")
+	}
+	return
+}
+
+func (d *MockDB) StoreSubmission(_ context.Context, s Submission) error {
+	d.db[submissionsPath][submissionID(s.AID, s.UID)] = s
+	return nil
+}
+
+func (d *MockDB) UpdateSubmission(_ context.Context, s Submission, fields ...string) (Submission, error) {
+	stored, ok := d.db[submissionsPath][submissionID(s.AID, s.UID)].(Submission)
+	if !ok {
+		return Submission{}, status.Error(codes.NotFound, "submission does not exist")
+	}
+	if !stored.SubmittedAt.Equal(s.SubmittedAt) {
+		return Submission{}, status.Error(codes.FailedPrecondition, "the submission has changed since it was loaded")
+	}
+	if err := copyFields(&stored, s, fields); err != nil {
+		return Submission{}, err
+	}
+	d.db[submissionsPath][submissionID(s.AID, s.UID)] = stored
+	return stored, nil
+}
+
+func (d *MockDB) GrantExtension(_ context.Context, aid, uid string, due time.Time) error {
+	a, ok := d.db[assignmentsPath][aid].(Assignment)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid assignment ID")
+	}
+	if a.Extensions == nil {
+		a.Extensions = make(map[string]time.Time)
+	}
+	a.Extensions[uid] = due
+	d.db[assignmentsPath][aid] = a
+	return nil
+}
+
+func (d *MockDB) LoadPeerReview(_ context.Context, id string) (r PeerReview, err error) {
+	r, ok := d.db[peerReviewsPath][id].(PeerReview)
+	if !ok {
+		err = status.Error(codes.NotFound, "invalid peer review ID")
+	}
+	return
+}
+
+func (d *MockDB) UpdatePeerReview(_ context.Context, r PeerReview, fields ...string) error {
+	stored, ok := d.db[peerReviewsPath][r.ID].(PeerReview)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid peer review ID")
+	}
+	if err := copyFields(&stored, r, fields); err != nil {
+		return err
+	}
+	d.db[peerReviewsPath][r.ID] = stored
+	return nil
+}
+
+func (d *MockDB) CreatePeerReviews(_ context.Context, aid string, n int, reviews []PeerReview) ([]PeerReview, error) {
+	a, ok := d.db[assignmentsPath][aid].(Assignment)
+	if !ok {
+		return nil, status.Error(codes.NotFound, "invalid assignment ID")
+	}
+	if a.PeerReviewers > 0 {
+		return nil, status.Errorf(codes.FailedPrecondition, "peer reviewers have already been assigned for assignment %s", aid)
+	}
+
+	created := make([]PeerReview, len(reviews))
+	for i, r := range reviews {
+		r.ID = uuid.New().String()
+		d.db[peerReviewsPath][r.ID] = r
+		created[i] = r
+	}
+	a.PeerReviewers = n
+	d.db[assignmentsPath][aid] = a
+	return created, nil
+}
+
+func (d *MockDB) ListPeerReviews(_ context.Context, aid string) ([]PeerReview, error) {
+	reviews := []PeerReview{}
+	for _, r := range d.db[peerReviewsPath] {
+		if r := r.(PeerReview); r.AID == aid {
+			reviews = append(reviews, r)
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool {
+		return reviews[i].ID < reviews[j].ID
+	})
+	return reviews, nil
+}
+
+func (d *MockDB) LoadAnnouncement(_ context.Context, id string) (a Announcement, err error) {
+	a, ok := d.db[announcementsPath][id].(Announcement)
+	if !ok {
+		err = status.Error(codes.NotFound, "invalid announcement ID")
+	}
+	return
+}
+
+func (d *MockDB) StoreAnnouncement(_ context.Context, a Announcement) error {
+	d.db[announcementsPath][a.ID] = a
+	return nil
+}
+
+func (d *MockDB) UpdateAnnouncement(_ context.Context, a Announcement, fields ...string) error {
+	stored, ok := d.db[announcementsPath][a.ID].(Announcement)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid announcement ID")
+	}
+	if err := copyFields(&stored, a, fields); err != nil {
+		return err
+	}
+	d.db[announcementsPath][a.ID] = stored
+	return nil
+}
+
+func (d *MockDB) CreateAnnouncement(_ context.Context, a Announcement) (Announcement, error) {
+	a.ID = uuid.New().String()
+	d.db[announcementsPath][a.ID] = a
+	return a, nil
+}
+
+func (d *MockDB) DeleteAnnouncement(_ context.Context, id string) error {
+	delete(d.db[announcementsPath], id)
+	return nil
+}
+
+func (d *MockDB) ListAnnouncements(_ context.Context, cid, after string, limit int) ([]Announcement, error) {
+	feed := []Announcement{}
+	for _, a := range d.db[announcementsPath] {
+		if a := a.(Announcement); a.CID == cid {
+			feed = append(feed, a)
+		}
+	}
+	sort.Slice(feed, func(i, j int) bool {
+		return announcementBefore(feed[i], feed[j])
+	})
+
+	if after != "" {
+		a, ok := d.db[announcementsPath][after].(Announcement)
+		if !ok {
+			return nil, status.Error(codes.NotFound, "invalid announcement ID")
+		}
+		i := sort.Search(len(feed), func(i int) bool {
+			return !announcementBefore(feed[i], a)
+		})
+		feed = feed[i:]
+		if len(feed) > 0 && feed[0].ID == a.ID {
+			feed = feed[1:]
+		}
+	}
+	if len(feed) > limit {
+		feed = feed[:limit]
+	}
+	return feed, nil
+}
+
+func (d *MockDB) MarkAnnouncementsRead(_ context.Context, cid, uid string, ids []string) error {
+	for _, id := range ids {
+		if a, ok := d.db[announcementsPath][id].(Announcement); !ok || a.CID != cid {
+			return status.Errorf(codes.NotFound, "announcement %s is not in class %s", id, cid)
+		}
+	}
+	for _, id := range ids {
+		a := d.db[announcementsPath][id].(Announcement)
+		if !containsString(a.ReadBy, uid) {
+			a.ReadBy = append(a.ReadBy, uid)
+		}
+		d.db[announcementsPath][id] = a
+	}
+	return nil
+}
+
+func (d *MockDB) LoadThread(_ context.Context, id string) (t Thread, err error) {
+	t, ok := d.db[threadsPath][id].(Thread)
+	if !ok {
+		err = status.Error(codes.NotFound, "invalid thread ID")
+	}
+	return
+}
+
+func (d *MockDB) CreateThread(_ context.Context, t Thread) (Thread, error) {
+	t.ID = uuid.New().String()
+	d.db[threadsPath][t.ID] = t
+	return t, nil
+}
+
+func (d *MockDB) ListThreads(_ context.Context, cid, after string, limit int) ([]Thread, error) {
+	threads := []Thread{}
+	for _, t := range d.db[threadsPath] {
+		if t := t.(Thread); t.CID == cid {
+			threads = append(threads, t)
+		}
+	}
+	newer := func(a, b Thread) bool {
+		if !a.CreatedAt.Equal(b.CreatedAt) {
+			return a.CreatedAt.After(b.CreatedAt)
+		}
+		return a.ID > b.ID
+	}
+	sort.Slice(threads, func(i, j int) bool {
+		return newer(threads[i], threads[j])
+	})
+
+	if after != "" {
+		t, ok := d.db[threadsPath][after].(Thread)
+		if !ok {
+			return nil, status.Error(codes.NotFound, "invalid thread ID")
+		}
+		i := sort.Search(len(threads), func(i int) bool {
+			return !newer(threads[i], t)
+		})
+		threads = threads[i:]
+		if len(threads) > 0 && threads[0].ID == t.ID {
+			threads = threads[1:]
+		}
+	}
+	if len(threads) > limit {
+		threads = threads[:limit]
+	}
+	return threads, nil
+}
+
+func (d *MockDB) LoadReply(_ context.Context, id string) (r Reply, err error) {
+	r, ok := d.db[repliesPath][id].(Reply)
+	if !ok {
+		err = status.Error(codes.NotFound, "invalid reply ID")
+	}
+	return
+}
+
+func (d *MockDB) EndorseReply(_ context.Context, id, uid string) (Reply, error) {
+	r, ok := d.db[repliesPath][id].(Reply)
+	if !ok {
+		return Reply{}, status.Error(codes.NotFound, "invalid reply ID")
+	}
+	t, ok := d.db[threadsPath][r.ThreadID].(Thread)
+	if !ok {
+		return Reply{}, status.Error(codes.NotFound, "invalid thread ID")
+	}
+
+	r.EndorsedBy = uid
+	d.db[repliesPath][id] = r
+	t.Answered = false
+	for _, other := range d.db[repliesPath] {
+		if other := other.(Reply); other.ThreadID == t.ID && other.EndorsedBy != "" {
+			t.Answered = true
+		}
+	}
+	d.db[threadsPath][t.ID] = t
+	return r, nil
+}
+
+func (d *MockDB) CreateReply(_ context.Context, r Reply) (Reply, error) {
+	r.ID = uuid.New().String()
+	d.db[repliesPath][r.ID] = r
+	return r, nil
+}
+
+func (d *MockDB) ListReplies(_ context.Context, threadID string) ([]Reply, error) {
+	replies := []Reply{}
+	for _, r := range d.db[repliesPath] {
+		if r := r.(Reply); r.ThreadID == threadID {
+			replies = append(replies, r)
+		}
+	}
+	sort.Slice(replies, func(i, j int) bool {
+		if !replies[i].CreatedAt.Equal(replies[j].CreatedAt) {
+			return replies[i].CreatedAt.Before(replies[j].CreatedAt)
+		}
+		return replies[i].ID < replies[j].ID
+	})
+	return replies, nil
+}
+
+func (d *MockDB) CreateSnapshot(_ context.Context, s ClassSnapshot, programs map[string]Program) (ClassSnapshot, error) {
+	s.ID = uuid.New().String()
+	path := snapshotsPath + "/" + s.ID + "/" + snapshotProgramsPath
+	d.db[path] = make(map[string]interface{})
+	for uid, p := range programs {
+		d.db[path][uid] = p
+	}
+	d.db[snapshotsPath][s.ID] = s
+	return s, nil
+}
+
+func (d *MockDB) LoadSnapshot(_ context.Context, id string) (s ClassSnapshot, err error) {
+	s, ok := d.db[snapshotsPath][id].(ClassSnapshot)
+	if !ok {
+		err = status.Error(codes.NotFound, "invalid snapshot ID")
+	}
+	return
+}
+
+func (d *MockDB) LoadSnapshotPrograms(_ context.Context, id string, uids []string) (map[string]Program, error) {
+	programs := make(map[string]Program)
+	for _, uid := range uids {
+		if p, ok := d.db[snapshotsPath+"/"+id+"/"+snapshotProgramsPath][uid].(Program); ok {
+			programs[uid] = p
+		}
+	}
+	return programs, nil
+}
+
+func (d *MockDB) ListSnapshots(_ context.Context, cid string) ([]ClassSnapshot, error) {
+	snapshots := []ClassSnapshot{}
+	for _, s := range d.db[snapshotsPath] {
+		if s := s.(ClassSnapshot); s.CID == cid {
+			snapshots = append(snapshots, s)
+		}
+	}
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].TakenAt.After(snapshots[j].TakenAt)
+	})
+	return snapshots, nil
+}
+
 func (d *MockDB) LoadUser(_ context.Context, uid string) (u User, err error) {
 	u, ok := d.db[usersPath][uid].(User)
 	if !ok {
@@ -62,6 +457,68 @@ func (d *MockDB) StoreUser(_ context.Context, u User) error {
 	return nil
 }
 
+func (d *MockDB) RecordProgramOpen(_ context.Context, uid, pid string) error {
+	u, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return errors.New("invalid user ID")
+	}
+	if containsString(u.Programs, pid) {
+		u.OpenedProgram(pid, time.Now().UTC())
+		d.db[usersPath][uid] = u
+	}
+	return nil
+}
+
+func (d *MockDB) UpdateFolders(_ context.Context, uid string, f func(map[string]Folder) error) error {
+	u, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid user ID")
+	}
+
+	// f works on a copy, as a failed transaction leaves nothing
+	// behind.
+	folders := make(map[string]Folder, len(u.Folders))
+	for id, folder := range u.Folders {
+		folders[id] = folder
+	}
+	if err := f(folders); err != nil {
+		return err
+	}
+	u.Folders = folders
+	d.db[usersPath][uid] = u
+	return nil
+}
+
+func (d *MockDB) LoadUsers(_ context.Context, uids []string) (map[string]User, error) {
+	users := make(map[string]User)
+	for _, uid := range uids {
+		if u, ok := d.db[usersPath][uid].(User); ok {
+			users[uid] = u
+		}
+	}
+	return users, nil
+}
+
+func (d *MockDB) LoadPrograms(_ context.Context, pids []string) (map[string]Program, error) {
+	programs := make(map[string]Program)
+	for _, pid := range pids {
+		if p, ok := d.db[programsPath][pid].(Program); ok {
+			programs[pid] = p
+		}
+	}
+	return programs, nil
+}
+
+func (d *MockDB) LoadSubmissions(_ context.Context, aid string, uids []string) (map[string]Submission, error) {
+	submissions := make(map[string]Submission)
+	for _, uid := range uids {
+		if s, ok := d.db[submissionsPath][submissionID(aid, uid)].(Submission); ok {
+			submissions[uid] = s
+		}
+	}
+	return submissions, nil
+}
+
 func (d *MockDB) DeleteUser(_ context.Context, uid string) error {
 	delete(d.db[usersPath], uid)
 	return nil
@@ -86,24 +543,406 @@ func (d *MockDB) CreateProgram(_ context.Context, p Program) (Program, error) {
 	// Give the program a UID
 	p.UID = uuid.New().String()
 	d.db[programsPath][p.UID] = p
+	d.index.add(p)
+
+	return p, nil
+}
+
+// programTransaction mirrors DB.programTransaction, storing the
+// program and users only if f succeeds.
+func (d *MockDB) programTransaction(pid string, uids []string, f func(*Program, []*User) error) error {
+	p, ok := d.db[programsPath][pid].(Program)
+	if !ok {
+		return status.Error(codes.NotFound, "program has not been created")
+	}
+	users := make([]*User, len(uids))
+	for i, uid := range uids {
+		u, ok := d.db[usersPath][uid].(User)
+		if !ok {
+			return status.Error(codes.NotFound, "invalid user ID")
+		}
+		users[i] = &u
+	}
+
+	if err := f(&p, users); err != nil {
+		return err
+	}
+	d.db[programsPath][pid] = p
+	for _, u := range users {
+		d.db[usersPath][u.UID] = *u
+	}
+	return nil
+}
+
+func (d *MockDB) TransferProgram(_ context.Context, pid, from, to string) error {
+	return d.programTransaction(pid, []string{from, to}, func(p *Program, u []*User) error {
+		return transferProgram(p, u[0], u[1])
+	})
+}
+
+func (d *MockDB) AddProgramCollaborator(_ context.Context, pid, owner, uid string) error {
+	return d.programTransaction(pid, []string{owner, uid}, func(p *Program, u []*User) error {
+		return addCollaborator(p, u[0], u[1])
+	})
+}
+
+func (d *MockDB) RemoveProgramCollaborator(_ context.Context, pid, actor, uid string) error {
+	return d.programTransaction(pid, []string{uid}, func(p *Program, u []*User) error {
+		return removeCollaborator(p, actor, u[0])
+	})
+}
 
+func (d *MockDB) DeleteOwnedProgram(_ context.Context, pid, uid string) (Program, error) {
+	p, ok := d.db[programsPath][pid].(Program)
+	if !ok {
+		return Program{}, status.Error(codes.NotFound, "program has not been created")
+	}
+	p.UID = pid
+	owner, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return Program{}, status.Error(codes.NotFound, "invalid user ID")
+	}
+	owner.UID = uid
+	collaborators := []*User{}
+	for _, id := range p.Collaborators {
+		if u, ok := d.db[usersPath][id].(User); ok {
+			u.UID = id
+			collaborators = append(collaborators, &u)
+		}
+	}
+
+	if err := deleteProgram(&p, &owner, collaborators); err != nil {
+		return Program{}, err
+	}
+	for _, u := range append([]*User{&owner}, collaborators...) {
+		d.db[usersPath][u.UID] = *u
+	}
+	delete(d.db[programsPath], pid)
+	d.index.remove(pid)
 	return p, nil
 }
 
-// Temporary stand-ins to allow other refactors to function
+// classTransaction mirrors DB.classTransaction, storing the
+// class and users only if f succeeds.
+func (d *MockDB) classTransaction(cid string, uids []string, f func(*Class, []*User) error) error {
+	c, ok := d.db[classesPath][cid].(Class)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid class ID")
+	}
+	users := make([]*User, len(uids))
+	for i, uid := range uids {
+		u, ok := d.db[usersPath][uid].(User)
+		if !ok {
+			return status.Error(codes.NotFound, "invalid user ID")
+		}
+		users[i] = &u
+	}
+
+	if err := f(&c, users); err != nil {
+		return err
+	}
+	d.db[classesPath][cid] = c
+	for _, u := range users {
+		d.db[usersPath][u.UID] = *u
+	}
+	return nil
+}
+
+func (d *MockDB) AddUserClass(_ context.Context, uid, cid string, pids []string) error {
+	u, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid user ID")
+	}
+	if !containsString(u.Classes, cid) {
+		u.Classes = append(u.Classes, cid)
+	}
+	for _, pid := range pids {
+		if !containsString(u.Programs, pid) {
+			u.Programs = append(u.Programs, pid)
+		}
+	}
+	d.db[usersPath][uid] = u
+	return nil
+}
+
+func (d *MockDB) RemoveUserClass(_ context.Context, uid, cid string, pids []string) error {
+	u, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid user ID")
+	}
+	u.Classes = removeString(u.Classes, cid)
+	for _, pid := range pids {
+		u.Programs = removeString(u.Programs, pid)
+	}
+	d.db[usersPath][uid] = u
+	return nil
+}
+
+func (d *MockDB) AddUserProgram(_ context.Context, uid, pid string) error {
+	u, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return status.Error(codes.NotFound, "invalid user ID")
+	}
+	if !containsString(u.Programs, pid) {
+		u.Programs = append(u.Programs, pid)
+	}
+	d.db[usersPath][uid] = u
+	return nil
+}
+
+func (d *MockDB) EnrollClassMember(_ context.Context, cid, uid string) (bool, error) {
+	enrolled := false
+	err := d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) (err error) {
+		enrolled, err = enroll(c, u[0], time.Now().UTC())
+		return err
+	})
+	return enrolled, err
+}
+
+func (d *MockDB) InviteClassUsers(_ context.Context, cid string, entries []string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		for _, entry := range entries {
+			c.Invite(entry)
+		}
+		return nil
+	})
+}
+
+func (d *MockDB) AddClassAssignment(_ context.Context, cid, aid string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return addAssignment(c, aid)
+	})
+}
+
+func (d *MockDB) RemoveClassAssignment(_ context.Context, cid, aid string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		c.Assignments = removeString(c.Assignments, aid)
+		return nil
+	})
+}
+
+func (d *MockDB) AddClassInstructor(_ context.Context, cid, actor, uid string) error {
+	return d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) error {
+		return addInstructor(c, actor, u[0])
+	})
+}
+
+func (d *MockDB) PromoteClassMember(_ context.Context, cid, actor, uid string) error {
+	return d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) error {
+		return promoteMember(c, actor, u[0])
+	})
+}
+
+func (d *MockDB) RemoveClassInstructor(_ context.Context, cid, actor, uid string) error {
+	return d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) error {
+		return removeInstructor(c, actor, u[0])
+	})
+}
+
+func (d *MockDB) TransferClass(_ context.Context, cid, from, to string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return transferClass(c, from, to)
+	})
+}
+
+func (d *MockDB) RemoveClassMember(_ context.Context, cid, actor, uid, reason string, ban bool) error {
+	return d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) error {
+		return removeMember(c, actor, u[0], reason, ban)
+	})
+}
+
+func (d *MockDB) JoinClass(_ context.Context, cid, wid, uid string) (Class, error) {
+	class := Class{}
+	err := d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) error {
+		if err := joinClass(c, u[0], wid, time.Now().UTC()); err != nil {
+			return err
+		}
+		class = *c
+		return nil
+	})
+	return class, err
+}
+
+func (d *MockDB) RotateClassWID(ctx context.Context, cid, actor string) (string, error) {
+	c, ok := d.db[classesPath][cid].(Class)
+	if !ok {
+		return "", status.Error(codes.NotFound, "invalid class ID")
+	}
+	if !c.IsInstructor(actor) {
+		return "", status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, cid)
+	}
+
+	wid, _ := d.MakeAlias(ctx, cid, ClassesAliasPath)
+	return wid, d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return rotateWID(c, actor, wid)
+	})
+}
+
+func (d *MockDB) SetClassJoinCode(_ context.Context, cid, actor string, j JoinCode) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return setJoinCode(c, actor, j)
+	})
+}
+
+func (d *MockDB) SetClassAdmission(_ context.Context, cid, actor string, capacity int, requireApproval bool) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return setAdmission(c, actor, capacity, requireApproval)
+	})
+}
+
+func (d *MockDB) ApproveJoinRequest(_ context.Context, cid, actor, uid string) error {
+	return d.classTransaction(cid, []string{uid}, func(c *Class, u []*User) error {
+		return approveJoinRequest(c, actor, u[0], time.Now().UTC())
+	})
+}
+
+func (d *MockDB) RejectJoinRequest(_ context.Context, cid, actor, uid string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return rejectJoinRequest(c, actor, uid)
+	})
+}
+
+func (d *MockDB) ArchiveClass(_ context.Context, cid, actor string, archived bool) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return archiveClass(c, actor, archived, time.Now().UTC())
+	})
+}
+
+func (d *MockDB) SetClassSection(_ context.Context, cid, actor string, s Section) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return setSection(c, actor, s)
+	})
+}
+
+func (d *MockDB) DeleteClassSection(_ context.Context, cid, actor, id string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return deleteSection(c, actor, id)
+	})
+}
+
+func (d *MockDB) UnbanClassUser(_ context.Context, cid, actor, uid, reason string) error {
+	return d.classTransaction(cid, nil, func(c *Class, _ []*User) error {
+		return unbanUser(c, actor, uid, reason)
+	})
+}
+
+func (d *MockDB) ToggleProgramLike(_ context.Context, pid, uid string) (bool, error) {
+	if _, ok := d.db[programsPath][pid]; !ok {
+		return false, errors.New("program has not been created")
+	}
+	u, ok := d.db[usersPath][uid].(User)
+	if !ok {
+		return false, errors.New("invalid user ID")
+	}
+
+	key := pid + "/" + uid
+	if _, liked := d.db[likesPath][key]; liked {
+		delete(d.db[likesPath], key)
+		for i, p := range u.LikedPrograms {
+			if p == pid {
+				u.LikedPrograms = append(u.LikedPrograms[:i], u.LikedPrograms[i+1:]...)
+				break
+			}
+		}
+		d.db[usersPath][uid] = u
+		return false, nil
+	}
+
+	d.db[likesPath][key] = pid
+	u.LikedPrograms = append(u.LikedPrograms, pid)
+	d.db[usersPath][uid] = u
+	return true, nil
+}
+
+func (d *MockDB) IncrementProgramViews(_ context.Context, pid string) error {
+	if _, ok := d.db[programsPath][pid]; !ok {
+		return errors.New("program has not been created")
+	}
+	views, _ := d.db[viewShardsPath][pid].(int64)
+	d.db[viewShardsPath][pid] = views + 1
+	return nil
+}
+
+func (d *MockDB) LoadProgramStats(_ context.Context, pid string) (ProgramStats, error) {
+	if _, ok := d.db[programsPath][pid]; !ok {
+		return ProgramStats{}, errors.New("program has not been created")
+	}
+
+	stats := ProgramStats{PID: pid}
+	stats.Views, _ = d.db[viewShardsPath][pid].(int64)
+	for _, likedPID := range d.db[likesPath] {
+		if likedPID == pid {
+			stats.Likes++
+		}
+	}
+	return stats, nil
+}
+
+func (d *MockDB) SearchPrograms(_ context.Context, query string, pids []string, public bool) ([]SearchResult, error) {
+	return d.index.search(query, pids, public), nil
+}
+
+// MakeAlias records a random WID for uid. WIDs that were never
+// made resolve to themselves, so tests can use a CID as a WID.
 func (d *MockDB) MakeAlias(ctx context.Context, uid string, path string) (string, error) {
-	return "", nil
+	if d.db[path] == nil {
+		d.db[path] = make(map[string]interface{})
+	}
+	wid := uuid.New().String()
+	d.db[path][wid] = uid
+	return wid, nil
+}
+
+func (d *MockDB) DeleteAlias(ctx context.Context, wid string, path string) error {
+	delete(d.db[path], wid)
+	return nil
 }
 
 func (d *MockDB) GetUIDFromWID(ctx context.Context, wid string, path string) (string, error) {
+	if target, ok := d.db[path][wid]; ok {
+		return target.(string), nil
+	}
 	return wid, nil
 }
 
+func (d *MockDB) LoadUserByEmail(_ context.Context, email string) (User, error) {
+	email = NormalizeEmail(email)
+	for _, u := range d.db[usersPath] {
+		if u := u.(User); u.Email == email {
+			return u, nil
+		}
+	}
+	return User{}, status.Errorf(codes.NotFound, "no user with email %s", email)
+}
+
+func (d *MockDB) LoadInvitedClasses(_ context.Context, entry string) ([]Class, error) {
+	classes := []Class{}
+	for _, c := range d.db[classesPath] {
+		if c := c.(Class); containsString(c.Invitations, entry) {
+			classes = append(classes, c)
+		}
+	}
+	return classes, nil
+}
+
 // Creates a new MockDB.
 func OpenMock() *MockDB {
-	m := MockDB{db: make(map[string]map[string]interface{})}
+	m := MockDB{
+		db:    make(map[string]map[string]interface{}),
+		index: newProgramIndex(),
+	}
 	m.db[usersPath] = make(map[string]interface{})
 	m.db[programsPath] = make(map[string]interface{})
 	m.db[classesPath] = make(map[string]interface{})
+	m.db[assignmentsPath] = make(map[string]interface{})
+	m.db[submissionsPath] = make(map[string]interface{})
+	m.db[announcementsPath] = make(map[string]interface{})
+	m.db[snapshotsPath] = make(map[string]interface{})
+	m.db[peerReviewsPath] = make(map[string]interface{})
+	m.db[threadsPath] = make(map[string]interface{})
+	m.db[repliesPath] = make(map[string]interface{})
+	m.db[ClassesAliasPath] = make(map[string]interface{})
+	m.db[likesPath] = make(map[string]interface{})
+	m.db[viewShardsPath] = make(map[string]interface{})
 	return &m
 }
@@ -2,7 +2,9 @@ package db_test
 
 import (
 	"context"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -96,3 +98,154 @@ func TestMockClass(t *testing.T) {
 	})
 	// Add tests if there is a DeleteClass
 }
+
+func TestMockAssignment(t *testing.T) {
+	t.Run("store", func(t *testing.T) {
+		d := db.OpenMock()
+		assert.NoError(t, d.StoreAssignment(context.Background(), db.Assignment{}))
+	})
+	t.Run("create", func(t *testing.T) {
+		d := db.OpenMock()
+		a, err := d.CreateAssignment(context.Background(), db.Assignment{Title: "test"})
+		require.NoError(t, err)
+		assert.NotEmpty(t, a.AID)
+		loaded, err := d.LoadAssignment(context.Background(), a.AID)
+		require.NoError(t, err)
+		assert.Equal(t, a, loaded)
+	})
+	t.Run("invalidLoad", func(t *testing.T) {
+		d := db.OpenMock()
+		_, err := d.LoadAssignment(context.Background(), "invalid")
+		assert.Error(t, err)
+	})
+	t.Run("delete", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreAssignment(context.Background(), db.Assignment{AID: "test"}))
+		require.NoError(t, d.DeleteAssignment(context.Background(), "test"))
+		_, err := d.LoadAssignment(context.Background(), "test")
+		assert.Error(t, err)
+	})
+}
+
+func TestMockSubmission(t *testing.T) {
+	first := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	t.Run("update", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: "a", UID: "u", SubmittedAt: first, Attempts: 1}))
+		s, err := d.UpdateSubmission(context.Background(), db.Submission{AID: "a", UID: "u", SubmittedAt: first, Grade: &db.Grade{Total: 3}}, "grade")
+		require.NoError(t, err)
+		assert.Equal(t, 1, s.Attempts, "fields not given are kept")
+		assert.Equal(t, 3.0, s.Grade.Total)
+	})
+	t.Run("resubmitted", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: "a", UID: "u", SubmittedAt: first.Add(time.Hour)}))
+		_, err := d.UpdateSubmission(context.Background(), db.Submission{AID: "a", UID: "u", SubmittedAt: first, Grade: &db.Grade{}}, "grade")
+		assert.Error(t, err)
+		s, err := d.LoadSubmission(context.Background(), "a", "u")
+		require.NoError(t, err)
+		assert.Nil(t, s.Grade)
+	})
+}
+
+func TestMockAnnouncements(t *testing.T) {
+	d := db.OpenMock()
+	ctx := context.Background()
+	start := time.Date(2021, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, pinned := range []bool{false, true, false, false} {
+		require.NoError(t, d.StoreAnnouncement(ctx, db.Announcement{
+			ID:        string(rune('a' + i)),
+			CID:       "test",
+			Pinned:    pinned,
+			CreatedAt: start.Add(time.Duration(i) * time.Hour),
+		}))
+	}
+	require.NoError(t, d.StoreAnnouncement(ctx, db.Announcement{ID: "other", CID: "other"}))
+
+	ids := func(feed []db.Announcement) (ids []string) {
+		for _, a := range feed {
+			ids = append(ids, a.ID)
+		}
+		return
+	}
+	feed, err := d.ListAnnouncements(ctx, "test", "", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b", "d", "c"}, ids(feed))
+	feed, err = d.ListAnnouncements(ctx, "test", "c", 3)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, ids(feed))
+
+	assert.Error(t, d.MarkAnnouncementsRead(ctx, "test", "alice", []string{"a", "other"}))
+	require.NoError(t, d.MarkAnnouncementsRead(ctx, "test", "alice", []string{"a", "a"}))
+	a, err := d.LoadAnnouncement(ctx, "a")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice"}, a.ReadBy)
+	assert.True(t, a.ForUser("alice", false).Read)
+	assert.Empty(t, a.ForUser("bob", false).ReadBy)
+}
+
+func TestWriteGradebook(t *testing.T) {
+	d := db.OpenMock()
+	ctx := context.Background()
+	require.NoError(t, d.StoreUser(ctx, db.User{UID: "alice", DisplayName: "Alice"}))
+	require.NoError(t, d.StoreUser(ctx, db.User{UID: "bob", DisplayName: "Bob, Jr."}))
+	require.NoError(t, d.StoreAssignment(ctx, db.Assignment{
+		AID:    "a1",
+		Title:  "Spirals",
+		Rubric: []db.Criterion{{ID: "works", Points: 10}},
+	}))
+	require.NoError(t, d.StoreAssignment(ctx, db.Assignment{AID: "a2", Title: "Squares"}))
+	require.NoError(t, d.StoreSubmission(ctx, db.Submission{AID: "a1", UID: "alice", Grade: &db.Grade{Total: 7.5}}))
+	require.NoError(t, d.StoreSubmission(ctx, db.Submission{AID: "a2", UID: "alice", Late: true}))
+	require.NoError(t, d.StoreSubmission(ctx, db.Submission{AID: "a1", UID: "bob", Late: true, Grade: &db.Grade{Total: 10}}))
+
+	out := strings.Builder{}
+	require.NoError(t, db.WriteGradebook(ctx, d, db.Class{
+		Members:     []string{"alice", "deleted", "bob"},
+		Assignments: []string{"a1", "a2"},
+	}, &out))
+	assert.Equal(t, "uid,displayName,Spirals (10),Spirals late,Squares (0),Squares late,total\n"+
+		"alice,Alice,7.5,false,,true,7.5\n"+
+		"deleted,,,,,,0\n"+
+		"bob,\"Bob, Jr.\",10,true,,,10\n", out.String())
+
+	t.Run("Formulas", func(t *testing.T) {
+		require.NoError(t, d.StoreUser(ctx, db.User{UID: "mallory", DisplayName: "=HYPERLINK(\"http://evil\")"}))
+		require.NoError(t, d.StoreAssignment(ctx, db.Assignment{AID: "a3", Title: "@SUM(A1)"}))
+
+		out := strings.Builder{}
+		require.NoError(t, db.WriteGradebook(ctx, d, db.Class{
+			Members:     []string{"mallory"},
+			Assignments: []string{"a3"},
+		}, &out))
+		assert.Equal(t, "uid,displayName,'@SUM(A1) (0),'@SUM(A1) late,total\n"+
+			"mallory,\"'=HYPERLINK(\"\"http://evil\"\")\",,,0\n", out.String())
+	})
+}
+
+func TestWriteRoster(t *testing.T) {
+	d := db.OpenMock()
+	ctx := context.Background()
+	require.NoError(t, d.StoreUser(ctx, db.User{UID: "teacher", DisplayName: "Teacher"}))
+	require.NoError(t, d.StoreUser(ctx, db.User{UID: "alice", DisplayName: "Alice", Email: "alice@ucla.edu"}))
+
+	out := strings.Builder{}
+	require.NoError(t, db.WriteRoster(ctx, d, db.Class{
+		Instructors: []string{"teacher"},
+		Members:     []string{"alice", "deleted"},
+		JoinDates:   map[string]time.Time{"alice": time.Date(2020, 9, 1, 12, 0, 0, 0, time.UTC)},
+	}, &out))
+	assert.Equal(t, "uid,displayName,email,role,joined\n"+
+		"teacher,Teacher,,instructor,\n"+
+		"alice,Alice,alice@ucla.edu,member,2020-09-01T12:00:00Z\n"+
+		"deleted,,,member,\n", out.String())
+
+	t.Run("Formulas", func(t *testing.T) {
+		require.NoError(t, d.StoreUser(ctx, db.User{UID: "mallory", DisplayName: "+1+cmd|' /C calc'!A0", Email: "-@evil.com"}))
+
+		out := strings.Builder{}
+		require.NoError(t, db.WriteRoster(ctx, d, db.Class{Members: []string{"mallory"}}, &out))
+		assert.Equal(t, "uid,displayName,email,role,joined\n"+
+			"mallory,'+1+cmd|' /C calc'!A0,'-@evil.com,member,\n", out.String())
+	})
+}
@@ -0,0 +1,115 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// ModerationRemove, ModerationBan and ModerationUnban are the
+	// kinds of ModerationAction an instructor may take.
+	ModerationRemove = "remove"
+	ModerationBan    = "ban"
+	ModerationUnban  = "unban"
+)
+
+// ModerationAction records an instructor removing, banning or
+// unbanning a user from a class.
+type ModerationAction struct {
+	UID    string    `firestore:"uid" json:"uid"`
+	Actor  string    `firestore:"actor" json:"actor"`
+	Action string    `firestore:"action" json:"action"`
+	Reason string    `firestore:"reason" json:"reason"`
+	At     time.Time `firestore:"at" json:"at"`
+}
+
+// IsBanned reports whether uid is banned from rejoining the class.
+func (c *Class) IsBanned(uid string) bool {
+	return containsString(c.Banned, uid)
+}
+
+// removeMember takes the member u out of c on behalf of actor, who
+// must be an instructor, banning them from rejoining if ban is set.
+func removeMember(c *Class, actor string, u *User, reason string, ban bool) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if !containsString(c.Members, u.UID) {
+		return status.Errorf(codes.NotFound, "user %s is not a member of class %s", u.UID, c.CID)
+	}
+	if !c.CanSee(actor, u.UID) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not in a section led by %s", u.UID, actor)
+	}
+
+	c.Members = removeString(c.Members, u.UID)
+	c.dropFromSections(u.UID)
+	delete(c.JoinDates, u.UID)
+	u.Classes = removeString(u.Classes, c.CID)
+
+	action := ModerationRemove
+	if ban {
+		action = ModerationBan
+		if !c.IsBanned(u.UID) {
+			c.Banned = append(c.Banned, u.UID)
+		}
+	}
+	c.Moderation = append(c.Moderation, ModerationAction{
+		UID:    u.UID,
+		Actor:  actor,
+		Action: action,
+		Reason: reason,
+		At:     time.Now().UTC(),
+	})
+	return nil
+}
+
+// unbanUser lets the banned user uid rejoin c on behalf of actor,
+// who must be an instructor.
+func unbanUser(c *Class, actor, uid, reason string) error {
+	if !c.IsInstructor(actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s is not an instructor of class %s", actor, c.CID)
+	}
+	if !c.IsBanned(uid) {
+		return status.Errorf(codes.NotFound, "user %s is not banned from class %s", uid, c.CID)
+	}
+
+	c.Banned = removeString(c.Banned, uid)
+	c.Moderation = append(c.Moderation, ModerationAction{
+		UID:    uid,
+		Actor:  actor,
+		Action: ModerationUnban,
+		Reason: reason,
+		At:     time.Now().UTC(),
+	})
+	return nil
+}
+
+// RemoveClassMember takes the member uid out of the class cid and
+// the class out of their class list, on behalf of actor, who must
+// be an instructor. If ban is set, uid may not rejoin the class.
+func (d *DB) RemoveClassMember(ctx context.Context, cid, actor, uid, reason string, ban bool) error {
+	return d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) error {
+		return removeMember(c, actor, u[0], reason, ban)
+	})
+}
+
+// UnbanClassUser lets the user uid rejoin the class cid, on behalf
+// of actor, who must be an instructor.
+func (d *DB) UnbanClassUser(ctx context.Context, cid, actor, uid, reason string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return unbanUser(c, actor, uid, reason)
+	})
+}
+
+// ForMember returns the class as seen by its members, without
+// the instructor-only roster and moderation details.
+func (c Class) ForMember() Class {
+	c.Invitations = nil
+	c.Pending = nil
+	c.Banned = nil
+	c.Moderation = nil
+	return c
+}
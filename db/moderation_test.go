@@ -0,0 +1,36 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestModeration(t *testing.T) {
+	c := Class{CID: "test", Instructors: []string{"teacher"}, Members: []string{"alice", "bob"}}
+	alice := User{UID: "alice", Classes: []string{"test"}}
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(removeMember(&c, "bob", &alice, "", false)))
+	assert.Equal(t, codes.NotFound, status.Code(removeMember(&c, "teacher", &User{UID: "carol"}, "", false)))
+	ta := c
+	ta.Instructors = []string{"teacher", "ta"}
+	ta.Sections = []Section{{ID: "a", TAs: []string{"ta"}, Members: []string{"bob"}}}
+	assert.Equal(t, codes.PermissionDenied, status.Code(removeMember(&ta, "ta", &alice, "", false)))
+
+	require.NoError(t, removeMember(&c, "teacher", &alice, "spam", true))
+	assert.Equal(t, []string{"bob"}, c.Members)
+	assert.Empty(t, alice.Classes)
+	assert.True(t, c.IsBanned("alice"))
+	require.Len(t, c.Moderation, 1)
+	assert.Equal(t, ModerationAction{UID: "alice", Actor: "teacher", Action: ModerationBan, Reason: "spam", At: c.Moderation[0].At}, c.Moderation[0])
+
+	assert.Equal(t, codes.NotFound, status.Code(unbanUser(&c, "teacher", "bob", "")))
+	require.NoError(t, unbanUser(&c, "teacher", "alice", "apologized"))
+	assert.False(t, c.IsBanned("alice"))
+	assert.Len(t, c.Moderation, 2)
+
+	assert.Empty(t, c.ForMember().Moderation)
+}
@@ -0,0 +1,201 @@
+package db
+
+import (
+	"context"
+	"math/rand"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PeerReview is a member's review of another member's submission
+// for an assignment, against the assignment's rubric.
+type PeerReview struct {
+	ID       string `firestore:"ID" json:"id"`
+	AID      string `firestore:"AID" json:"aid"`
+	Reviewer string `firestore:"reviewer" json:"reviewer"`
+	Author   string `firestore:"author" json:"author"`
+
+	// Review is nil until the reviewer submits it.
+	Review *Grade `firestore:"review" json:"review"`
+
+	// Hidden is set by an instructor to withdraw a review from its
+	// author, such as one which is unkind or unhelpful.
+	Hidden         bool   `firestore:"hidden" json:"hidden"`
+	ModeratedBy    string `firestore:"moderatedBy" json:"moderatedBy"`
+	ModerationNote string `firestore:"moderationNote" json:"moderationNote"`
+}
+
+// ForReviewer returns the review as seen by its reviewer, who
+// isn't told whose submission it is.
+func (r PeerReview) ForReviewer() PeerReview {
+	r.Author = ""
+	r.ModeratedBy, r.ModerationNote = "", ""
+	return r
+}
+
+// ForAuthor returns the review as seen by the author of the
+// submission reviewed, who isn't told who reviewed it.
+func (r PeerReview) ForAuthor() PeerReview {
+	r.Reviewer = ""
+	r.ModeratedBy, r.ModerationNote = "", ""
+	if r.Review != nil {
+		review := *r.Review
+		review.Grader = ""
+		r.Review = &review
+	}
+	return r
+}
+
+// AssignPeerReviewers assigns n reviewers to the submission of each
+// of authors for the assignment aid, drawn from the authors
+// themselves in an order shuffled with rng. Nobody reviews their
+// own submission, and everyone reviews exactly n others. The
+// reviews are given IDs when they are created.
+func AssignPeerReviewers(aid string, authors []string, n int, rng *rand.Rand) ([]PeerReview, error) {
+	if n < 1 {
+		return nil, status.Error(codes.InvalidArgument, "at least one reviewer is needed per submission")
+	}
+	if n >= len(authors) {
+		return nil, status.Errorf(codes.FailedPrecondition, "%d reviewers per submission needs more than %d submissions", n, n)
+	}
+
+	order := append([]string(nil), authors...)
+	rng.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	// each member reviews the n members after them in the shuffled
+	// order, wrapping around, so every submission gets n reviewers.
+	reviews := make([]PeerReview, 0, n*len(order))
+	for i, reviewer := range order {
+		for k := 1; k <= n; k++ {
+			author := order[(i+k)%len(order)]
+			reviews = append(reviews, PeerReview{
+				AID:      aid,
+				Reviewer: reviewer,
+				Author:   author,
+			})
+		}
+	}
+	return reviews, nil
+}
+
+// LoadPeerReview returns the peer review with the given ID.
+func (d *DB) LoadPeerReview(ctx context.Context, id string) (PeerReview, error) {
+	doc, err := d.Collection(peerReviewsPath).Doc(id).Get(ctx)
+	if err != nil {
+		return PeerReview{}, err
+	}
+
+	r := PeerReview{}
+	if err := doc.DataTo(&r); err != nil {
+		return PeerReview{}, err
+	}
+	return r, nil
+}
+
+// UpdatePeerReview stores only the given fields of r, named by
+// their Firestore keys, so that a reviewer submitting a review and
+// an instructor moderating it don't overwrite each other. Nothing
+// is written if no fields are given.
+func (d *DB) UpdatePeerReview(ctx context.Context, r PeerReview, fields ...string) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	up, err := fieldUpdates(r, fields)
+	if err != nil {
+		return err
+	}
+	_, err = d.Collection(peerReviewsPath).Doc(r.ID).Update(ctx, up)
+	return err
+}
+
+// CreatePeerReviews stores reviews, as assigned for the assignment
+// aid with n reviewers per submission, under new random IDs, and
+// records n on the assignment. Fails if reviewers have already been
+// assigned. Reviews beyond what fits in one commit alongside n are
+// created afterwards, so if that fails part way the assignment
+// keeps only some of its reviews.
+func (d *DB) CreatePeerReviews(ctx context.Context, aid string, n int, reviews []PeerReview) ([]PeerReview, error) {
+	created := make([]PeerReview, len(reviews))
+	refs := make([]*firestore.DocumentRef, len(reviews))
+	for i, r := range reviews {
+		refs[i] = d.Collection(peerReviewsPath).NewDoc()
+		r.ID = refs[i].ID
+		created[i] = r
+	}
+
+	// the first commit records n with as many reviews as fit, so
+	// that only one assignment of reviewers can ever go ahead.
+	first := len(created)
+	if first > maxBatchWrites-1 {
+		first = maxBatchWrites - 1
+	}
+	err := d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		aref := d.Collection(assignmentsPath).Doc(aid)
+		asnap, err := tx.Get(aref)
+		if err != nil {
+			return err
+		}
+		a := Assignment{}
+		if err := asnap.DataTo(&a); err != nil {
+			return err
+		}
+		if a.PeerReviewers > 0 {
+			return status.Errorf(codes.FailedPrecondition, "peer reviewers have already been assigned for assignment %s", aid)
+		}
+
+		for i := 0; i < first; i++ {
+			if err := tx.Create(refs[i], &created[i]); err != nil {
+				return err
+			}
+		}
+		return tx.Update(aref, []firestore.Update{{Path: "peerReviewers", Value: n}})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	batch, writes := d.Batch(), 0
+	for i := first; i < len(created); i++ {
+		batch.Create(refs[i], &created[i])
+		if writes++; writes == maxBatchWrites {
+			if _, err := batch.Commit(ctx); err != nil {
+				return nil, err
+			}
+			batch, writes = d.Batch(), 0
+		}
+	}
+	if writes > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return nil, err
+		}
+	}
+	return created, nil
+}
+
+// ListPeerReviews returns every peer review of the assignment aid.
+func (d *DB) ListPeerReviews(ctx context.Context, aid string) ([]PeerReview, error) {
+	docs := d.Collection(peerReviewsPath).Where("AID", "==", aid).Documents(ctx)
+	defer docs.Stop()
+
+	reviews := []PeerReview{}
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return reviews, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r := PeerReview{}
+		if err := doc.DataTo(&r); err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, r)
+	}
+}
@@ -0,0 +1,66 @@
+package db
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestAssignPeerReviewers(t *testing.T) {
+	authors := []string{"alice", "bob", "carol", "dave", "erin"}
+
+	t.Run("Balanced", func(t *testing.T) {
+		reviews, err := AssignPeerReviewers("test", authors, 2, rand.New(rand.NewSource(1)))
+		require.NoError(t, err)
+		require.Len(t, reviews, 10)
+
+		given, received := map[string]int{}, map[string]int{}
+		pairs := map[[2]string]bool{}
+		for _, r := range reviews {
+			assert.NotEqual(t, r.Reviewer, r.Author, "nobody reviews themselves")
+			assert.Equal(t, "test", r.AID)
+			given[r.Reviewer]++
+			received[r.Author]++
+			pairs[[2]string{r.Reviewer, r.Author}] = true
+		}
+		assert.Len(t, pairs, 10, "nobody reviews the same submission twice")
+		for _, uid := range authors {
+			assert.Equal(t, 2, given[uid])
+			assert.Equal(t, 2, received[uid])
+		}
+	})
+	t.Run("TooFewSubmissions", func(t *testing.T) {
+		_, err := AssignPeerReviewers("test", authors[:2], 2, rand.New(rand.NewSource(1)))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+	t.Run("NoReviewers", func(t *testing.T) {
+		_, err := AssignPeerReviewers("test", authors, 0, rand.New(rand.NewSource(1)))
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+}
+
+func TestPeerReviewViews(t *testing.T) {
+	r := PeerReview{
+		ID:             "r",
+		Reviewer:       "bob",
+		Author:         "alice",
+		Review:         &Grade{Total: 3, Grader: "bob"},
+		ModeratedBy:    "teacher",
+		ModerationNote: "fine",
+	}
+
+	forReviewer := r.ForReviewer()
+	assert.Empty(t, forReviewer.Author)
+	assert.Empty(t, forReviewer.ModerationNote)
+	assert.Equal(t, "bob", forReviewer.Review.Grader)
+
+	forAuthor := r.ForAuthor()
+	assert.Empty(t, forAuthor.Reviewer)
+	assert.Empty(t, forAuthor.Review.Grader)
+	assert.Equal(t, 3.0, forAuthor.Review.Total)
+	assert.Equal(t, "bob", r.Review.Grader, "the original review is unchanged")
+}
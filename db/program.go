@@ -3,6 +3,8 @@ package db
 import (
 	"context"
 	"net/http"
+	"sort"
+	"time"
 
 	"cloud.google.com/go/firestore"
 	"github.com/labstack/echo/v4"
@@ -14,13 +16,23 @@ import (
 
 // Program is a representation of a program document.
 type Program struct {
-	Code        string `firestore:"code" json:"code"`
-	DateCreated string `firestore:"dateCreated" json:"dateCreated"`
-	Language    string `firestore:"language" json:"language"`
-	Name        string `firestore:"name" json:"name"`
-	Thumbnail   int64  `firestore:"thumbnail" json:"thumbnail"`
-	UID         string `json:"uid"`
-	WID         string `json:"wid"` // Optional WID of class associated with program
+	Code          string   `firestore:"code" json:"code"`
+	DateCreated   string   `firestore:"dateCreated" json:"dateCreated"`
+	Language      string   `firestore:"language" json:"language"`
+	Name          string   `firestore:"name" json:"name"`
+	Thumbnail     int64    `firestore:"thumbnail" json:"thumbnail"`
+	UID           string   `json:"uid"`
+	WID           string   `json:"wid"` // Optional WID of class associated with program
+	Public        bool     `firestore:"public" json:"public"`
+	Folder        string   `firestore:"folder" json:"folder"` // Optional ID of the owner's folder containing the program
+	Tags          []string `firestore:"tags" json:"tags"`
+	Owner         string   `firestore:"owner" json:"owner"`
+	Collaborators []string `firestore:"collaborators" json:"collaborators"`
+	Assignment    string   `firestore:"assignment" json:"assignment"` // Optional AID of the assignment this program is a copy for
+
+	// DateModified is when the program was last saved, or zero if
+	// it never has been.
+	DateModified time.Time `firestore:"dateModified" json:"dateModified"`
 }
 
 // ToFirestoreUpdate returns the []firestore.Update representation
@@ -76,27 +88,53 @@ func (d *DB) UpdateProgram(c echo.Context) error {
 		if err := usnap.DataTo(&owner); err != nil {
 			return err
 		}
+		owner.UID = body.UID
 
-		for id, p := range body.Programs {
-			// confirm that the program specified is owned by UID.
-			belongsTo := false
-			for _, userProg := range owner.Programs {
-				if id == userProg {
-					belongsTo = true
-					break
-				}
+		// confirm that every program specified is owned by or
+		// shared with UID before writing any of them.
+		for id := range body.Programs {
+			psnap, err := tx.Get(d.Collection(programsPath).Doc(id))
+			if err != nil {
+				return err
+			}
+			current := Program{}
+			if err := psnap.DataTo(&current); err != nil {
+				return err
 			}
-			if !belongsTo {
+			current.UID = id
+			if !current.CanEdit(owner) {
 				return errors.Errorf("specified program is out of bounds for user %s", body.UID)
 			}
+		}
 
+		// programs are recorded as opened in order of PID, so that
+		// saving several at once always leaves the same one on top.
+		ids := make([]string, 0, len(body.Programs))
+		for id := range body.Programs {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+
+		now := time.Now().UTC()
+		for _, id := range ids {
 			// update the program
+			p := body.Programs[id]
 			pref := d.Collection(programsPath).Doc(id)
-			if err := tx.Update(pref, p.ToFirestoreUpdate()); err != nil {
+			up := append(p.ToFirestoreUpdate(), firestore.Update{Path: "dateModified", Value: now})
+			if err := tx.Update(pref, up); err != nil {
 				return err
 			}
+			owner.OpenedProgram(id, now)
 		}
-		return nil
+		if len(body.Programs) == 0 {
+			return nil
+		}
+
+		// saving a program counts as opening it.
+		return tx.Update(usnap.Ref, []firestore.Update{
+			{Path: "mostRecentProgram", Value: owner.MostRecentProgram},
+			{Path: "recentPrograms", Value: owner.RecentPrograms},
+		})
 	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
@@ -105,10 +143,19 @@ func (d *DB) UpdateProgram(c echo.Context) error {
 		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to write update(s) to database").Error())
 	}
 
+	// keep the search index in line with the new names and code.
+	for id := range body.Programs {
+		if p, err := d.LoadProgram(c.Request().Context(), id); err == nil {
+			p.UID = id
+			d.index.add(p)
+		}
+	}
+
 	return c.String(http.StatusOK, "")
 }
 
-// ForkProgram forks a program `pid` to the user `uid`.
+// ForkProgram forks a program `pid` to the user `uid`, who owns the
+// fork.
 //
 // Request Body:
 // {
@@ -138,28 +185,25 @@ func (d *DB) ForkProgram(c echo.Context) error {
 		if err != nil {
 			return err
 		}
-
-		// copy program
-		newProgram := d.Collection(programsPath).NewDoc()
-		if err := tx.Create(newProgram, pSnap); err != nil {
-			return err
-		}
-
-		// TODO: strong potential for code lifting here.
-		userSnap, err := tx.Get(uref)
-		u := User{}
-		if err != nil {
+		source := Program{}
+		if err := pSnap.DataTo(&source); err != nil {
 			return err
 		}
-		if err := userSnap.DataTo(&u); err != nil {
+		if _, err := tx.Get(uref); err != nil {
 			return err
 		}
 
-		u.Programs = append(u.Programs, newProgram.ID)
-		if err := tx.Set(uref, u.ToFirestoreUpdate()); err != nil {
+		// the fork belongs to the forker alone, outside the source's
+		// folders, tags, class and assignment.
+		ref := d.Collection(programsPath).NewDoc()
+		forkedProgram = source.CloneFor(body.UID, "")
+		forkedProgram.UID = ref.ID
+		if err := tx.Create(ref, &forkedProgram); err != nil {
 			return err
 		}
-		return pSnap.DataTo(&forkedProgram)
+		return tx.Update(uref, []firestore.Update{
+			{Path: "programs", Value: firestore.ArrayUnion(ref.ID)},
+		})
 	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
@@ -167,6 +211,7 @@ func (d *DB) ForkProgram(c echo.Context) error {
 		}
 		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to fork program").Error())
 	}
+	d.index.add(forkedProgram)
 
 	return c.JSON(http.StatusCreated, forkedProgram)
 }
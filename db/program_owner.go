@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsOwnedBy reports whether u owns the program. Programs created
+// before owners were recorded are owned by whoever lists them.
+func (p *Program) IsOwnedBy(u User) bool {
+	if p.Owner != "" {
+		return p.Owner == u.UID
+	}
+	return containsString(u.Programs, p.UID)
+}
+
+// CanEdit reports whether u may modify the program, either as
+// its owner or as one of its collaborators.
+func (p *Program) CanEdit(u User) bool {
+	return p.IsOwnedBy(u) || containsString(p.Collaborators, u.UID)
+}
+
+// containsString reports whether s is in list.
+func containsString(list []string, s string) bool {
+	for _, e := range list {
+		if e == s {
+			return true
+		}
+	}
+	return false
+}
+
+// removeString returns list without any occurrences of s.
+func removeString(list []string, s string) []string {
+	out := list[:0:0]
+	for _, e := range list {
+		if e != s {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// transferProgram makes to the owner of p in place of from,
+// moving p between their program lists.
+func transferProgram(p *Program, from, to *User) error {
+	if !p.IsOwnedBy(*from) {
+		return status.Errorf(codes.PermissionDenied, "user %s does not own program %s", from.UID, p.UID)
+	}
+	if from.UID == to.UID {
+		return nil
+	}
+
+	p.Owner = to.UID
+	p.Collaborators = removeString(p.Collaborators, to.UID)
+	from.Programs = removeString(from.Programs, p.UID)
+	from.ForgetProgram(p.UID)
+	if !containsString(to.Programs, p.UID) {
+		to.Programs = append(to.Programs, p.UID)
+	}
+	return nil
+}
+
+// addCollaborator shares p with collaborator on behalf of owner.
+func addCollaborator(p *Program, owner, collaborator *User) error {
+	if !p.IsOwnedBy(*owner) {
+		return status.Errorf(codes.PermissionDenied, "user %s does not own program %s", owner.UID, p.UID)
+	}
+	if owner.UID == collaborator.UID {
+		return status.Error(codes.InvalidArgument, "the owner of a program cannot be its collaborator")
+	}
+
+	// record the owner of legacy programs so that the
+	// collaborator isn't mistaken for one.
+	p.Owner = owner.UID
+	if !containsString(p.Collaborators, collaborator.UID) {
+		p.Collaborators = append(p.Collaborators, collaborator.UID)
+	}
+	if !containsString(collaborator.Programs, p.UID) {
+		collaborator.Programs = append(collaborator.Programs, p.UID)
+	}
+	return nil
+}
+
+// removeCollaborator revokes collaborator's access to p. Either
+// the owner or the collaborator themselves may do so.
+func removeCollaborator(p *Program, actor string, collaborator *User) error {
+	if actor != collaborator.UID && (p.Owner == "" || p.Owner != actor) {
+		return status.Errorf(codes.PermissionDenied, "user %s may not remove collaborators from program %s", actor, p.UID)
+	}
+	if !containsString(p.Collaborators, collaborator.UID) {
+		return status.Errorf(codes.NotFound, "user %s is not a collaborator on program %s", collaborator.UID, p.UID)
+	}
+
+	p.Collaborators = removeString(p.Collaborators, collaborator.UID)
+	collaborator.Programs = removeString(collaborator.Programs, p.UID)
+	collaborator.ForgetProgram(p.UID)
+	return nil
+}
+
+// deleteProgram takes p off the program lists and recent programs
+// of its owner and its collaborators, on behalf of owner, so that
+// it can be deleted.
+func deleteProgram(p *Program, owner *User, collaborators []*User) error {
+	if !p.IsOwnedBy(*owner) {
+		return status.Errorf(codes.PermissionDenied, "user %s does not own program %s", owner.UID, p.UID)
+	}
+	for _, u := range append([]*User{owner}, collaborators...) {
+		u.Programs = removeString(u.Programs, p.UID)
+		u.ForgetProgram(p.UID)
+	}
+	return nil
+}
+
+// programTransaction runs f on the program pid and the users uids
+// within a transaction, storing all of them if f succeeds.
+func (d *DB) programTransaction(ctx context.Context, pid string, uids []string, f func(*Program, []*User) error) error {
+	return d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		pref := d.Collection(programsPath).Doc(pid)
+		psnap, err := tx.Get(pref)
+		if err != nil {
+			return err
+		}
+		p := Program{}
+		if err := psnap.DataTo(&p); err != nil {
+			return err
+		}
+		p.UID = pid
+
+		users := make([]*User, len(uids))
+		for i, uid := range uids {
+			if users[i], err = d.txUser(tx, uid); err != nil {
+				return err
+			}
+		}
+
+		if err := f(&p, users); err != nil {
+			return err
+		}
+
+		if err := tx.Set(pref, &p); err != nil {
+			return err
+		}
+		for _, u := range users {
+			if err := tx.Set(d.Collection(usersPath).Doc(u.UID), u); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// txUser returns the user uid as read within tx.
+func (d *DB) txUser(tx *firestore.Transaction, uid string) (*User, error) {
+	usnap, err := tx.Get(d.Collection(usersPath).Doc(uid))
+	if err != nil {
+		return nil, err
+	}
+	u := User{}
+	if err := usnap.DataTo(&u); err != nil {
+		return nil, err
+	}
+	u.UID = uid
+	return &u, nil
+}
+
+// TransferProgram transfers ownership of the program pid from the
+// user from to the user to, keeping both users' program lists in
+// sync.
+func (d *DB) TransferProgram(ctx context.Context, pid, from, to string) error {
+	return d.programTransaction(ctx, pid, []string{from, to}, func(p *Program, u []*User) error {
+		return transferProgram(p, u[0], u[1])
+	})
+}
+
+// AddProgramCollaborator shares the program pid owned by owner with
+// the user uid, adding it to their program list.
+func (d *DB) AddProgramCollaborator(ctx context.Context, pid, owner, uid string) error {
+	return d.programTransaction(ctx, pid, []string{owner, uid}, func(p *Program, u []*User) error {
+		return addCollaborator(p, u[0], u[1])
+	})
+}
+
+// RemoveProgramCollaborator revokes the user uid's access to the
+// program pid on behalf of actor, who must be the program's owner
+// or uid.
+func (d *DB) RemoveProgramCollaborator(ctx context.Context, pid, actor, uid string) error {
+	return d.programTransaction(ctx, pid, []string{uid}, func(p *Program, u []*User) error {
+		return removeCollaborator(p, actor, u[0])
+	})
+}
+
+// DeleteOwnedProgram deletes the program pid on behalf of its owner
+// uid, taking it off the program lists of the owner and of every
+// collaborator in the same transaction. It returns the program as
+// it was before it was deleted.
+func (d *DB) DeleteOwnedProgram(ctx context.Context, pid, uid string) (Program, error) {
+	p := Program{}
+	err := d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		pref := d.Collection(programsPath).Doc(pid)
+		psnap, err := tx.Get(pref)
+		if err != nil {
+			return err
+		}
+		p = Program{}
+		if err := psnap.DataTo(&p); err != nil {
+			return err
+		}
+		p.UID = pid
+
+		owner, err := d.txUser(tx, uid)
+		if err != nil {
+			return err
+		}
+		collaborators := []*User{}
+		for _, id := range p.Collaborators {
+			u, err := d.txUser(tx, id)
+			if status.Code(err) == codes.NotFound {
+				// the collaborator has since deleted their account.
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			collaborators = append(collaborators, u)
+		}
+
+		if err := deleteProgram(&p, owner, collaborators); err != nil {
+			return err
+		}
+		for _, u := range append([]*User{owner}, collaborators...) {
+			if err := tx.Set(d.Collection(usersPath).Doc(u.UID), u); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(pref)
+	})
+	if err != nil {
+		return Program{}, err
+	}
+	d.index.remove(pid)
+	return p, nil
+}
@@ -0,0 +1,136 @@
+package db
+
+import (
+	"context"
+	"math/rand"
+	"strconv"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ProgramStats describes the engagement counters
+// associated with a program.
+type ProgramStats struct {
+	PID   string `json:"pid"`
+	Likes int64  `json:"likes"`
+	Views int64  `json:"views"`
+}
+
+// counterShard returns a reference to a random shard of the
+// distributed counter stored under the given subcollection of
+// a program. Spreading writes over statShards documents keeps
+// popular programs under Firestore's per-document write limit,
+// in the same manner as the alias manager's shards.
+func (d *DB) counterShard(pid, counter string) *firestore.DocumentRef {
+	shard := strconv.Itoa(rand.Intn(statShards))
+	return d.Collection(programsPath).Doc(pid).Collection(counter).Doc(shard)
+}
+
+// readCounter sums every shard of the given counter.
+func (d *DB) readCounter(ctx context.Context, pid, counter string) (int64, error) {
+	shards := d.Collection(programsPath).Doc(pid).Collection(counter).Documents(ctx)
+	defer shards.Stop()
+
+	total := int64(0)
+	for {
+		doc, err := shards.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		s := Shard{}
+		if err := doc.DataTo(&s); err != nil {
+			return 0, err
+		}
+		total += s.Count
+	}
+	return total, nil
+}
+
+// ToggleProgramLike likes the program pid on behalf of user
+// uid, or removes the like if one already exists. Returns
+// whether the program is liked by the user after the toggle.
+func (d *DB) ToggleProgramLike(ctx context.Context, pid, uid string) (bool, error) {
+	pref := d.Collection(programsPath).Doc(pid)
+	uref := d.Collection(usersPath).Doc(uid)
+	lref := pref.Collection(likesPath).Doc(uid)
+
+	liked := false
+	err := d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		// confirm both the program and the user exist.
+		if _, err := tx.Get(pref); err != nil {
+			return err
+		}
+		if _, err := tx.Get(uref); err != nil {
+			return err
+		}
+
+		// a missing like document means the user has not
+		// liked this program yet.
+		lsnap, err := tx.Get(lref)
+		if err != nil && status.Code(err) != codes.NotFound {
+			return err
+		}
+		liked = !lsnap.Exists()
+
+		var (
+			delta int64       = 1
+			likes interface{} = firestore.ArrayUnion(pid)
+		)
+		if liked {
+			err = tx.Create(lref, map[string]interface{}{"uid": uid})
+		} else {
+			delta, likes = -1, firestore.ArrayRemove(pid)
+			err = tx.Delete(lref)
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Set(d.counterShard(pid, likeShardsPath), map[string]interface{}{
+			"Count": firestore.Increment(delta),
+		}, firestore.MergeAll); err != nil {
+			return err
+		}
+		return tx.Update(uref, []firestore.Update{
+			{Path: "likedPrograms", Value: likes},
+		})
+	})
+	return liked, err
+}
+
+// IncrementProgramViews records a single view of the program pid.
+func (d *DB) IncrementProgramViews(ctx context.Context, pid string) error {
+	if _, err := d.Collection(programsPath).Doc(pid).Get(ctx); err != nil {
+		return err
+	}
+
+	_, err := d.counterShard(pid, viewShardsPath).Set(ctx, map[string]interface{}{
+		"Count": firestore.Increment(1),
+	}, firestore.MergeAll)
+	return err
+}
+
+// LoadProgramStats returns the like and view counts of the program pid.
+func (d *DB) LoadProgramStats(ctx context.Context, pid string) (ProgramStats, error) {
+	if _, err := d.Collection(programsPath).Doc(pid).Get(ctx); err != nil {
+		return ProgramStats{}, err
+	}
+
+	likes, err := d.readCounter(ctx, pid, likeShardsPath)
+	if err != nil {
+		return ProgramStats{}, err
+	}
+	views, err := d.readCounter(ctx, pid, viewShardsPath)
+	if err != nil {
+		return ProgramStats{}, err
+	}
+
+	return ProgramStats{PID: pid, Likes: likes, Views: views}, nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// pushedProgramID returns the PID of the copy of the program src
+// pushed to the member uid of the class cid, where key identifies
+// the push. The same push always gives each member the same PID,
+// so redoing it doesn't make a second copy.
+func pushedProgramID(cid, src, key, uid string) string {
+	return uuid.NewSHA1(uuid.NameSpaceOID, []byte(cid+"\x00"+src+"\x00"+key+"\x00"+uid)).String()
+}
+
+// PushProgram gives every member of class a copy of p, the program
+// with PID src, tagged with the class's WID and added to their
+// programs. key identifies the push: members who already have their
+// copy of src from a push with the same key keep it as it is, so an
+// interrupted push may safely be run again. progress is called after
+// each member.
+func PushProgram(ctx context.Context, d TLADB, class Class, src string, p Program, key string, progress func(done, total int)) error {
+	for i, uid := range class.Members {
+		pid := pushedProgramID(class.CID, src, key, uid)
+
+		// the copy is stored before it is listed, so that a push cut
+		// short in between only ever writes the same copy. A copy
+		// that can't be read isn't replaced, since it may hold the
+		// member's edits.
+		_, err := d.LoadProgram(ctx, pid)
+		switch status.Code(err) {
+		case codes.OK:
+		case codes.NotFound:
+			cp := p.CloneFor(uid, class.WID)
+			cp.UID = pid
+			if err := d.StoreProgram(ctx, cp); err != nil {
+				return err
+			}
+		default:
+			return err
+		}
+		if err := d.AddUserProgram(ctx, uid, pid); err != nil {
+			return err
+		}
+
+		progress(i+1, len(class.Members))
+	}
+	return nil
+}
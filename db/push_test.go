@@ -0,0 +1,69 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushProgram(t *testing.T) {
+	ctx := context.Background()
+	d := OpenMock()
+	class := Class{CID: "test", WID: "wid", Members: []string{"alice", "bob"}, Programs: []string{}}
+	require.NoError(t, d.StoreClass(ctx, class))
+	for _, uid := range class.Members {
+		require.NoError(t, d.StoreUser(ctx, User{UID: uid}))
+	}
+	p := Program{UID: "starter", Code: "import turtle", Language: "python", Owner: "teacher"}
+
+	calls := 0
+	progress := func(done, total int) {
+		calls++
+		assert.Equal(t, calls, done)
+		assert.Equal(t, 2, total)
+	}
+	require.NoError(t, PushProgram(ctx, d, class, "starter", p, "lesson-1", progress))
+	require.NoError(t, PushProgram(ctx, d, class, "starter", p, "lesson-1", func(int, int) {}))
+
+	for _, uid := range class.Members {
+		u, err := d.LoadUser(ctx, uid)
+		require.NoError(t, err)
+		require.Len(t, u.Programs, 1, "pushing twice with the same key copies once")
+		cp, err := d.LoadProgram(ctx, u.Programs[0])
+		require.NoError(t, err)
+		assert.Equal(t, uid, cp.Owner)
+		assert.Equal(t, "wid", cp.WID)
+		assert.Equal(t, "import turtle", cp.Code)
+	}
+	c, err := d.LoadClass(ctx, "test")
+	require.NoError(t, err)
+	assert.Empty(t, c.Programs)
+
+	// pushing again keeps members' edits to their copies.
+	pid := pushedProgramID("test", "starter", "lesson-1", "alice")
+	cp, err := d.LoadProgram(ctx, pid)
+	require.NoError(t, err)
+	cp.Code = "edited"
+	require.NoError(t, d.StoreProgram(ctx, cp))
+	require.NoError(t, PushProgram(ctx, d, class, "starter", p, "lesson-1", func(int, int) {}))
+	cp, err = d.LoadProgram(ctx, pid)
+	require.NoError(t, err)
+	assert.Equal(t, "edited", cp.Code)
+
+	require.NoError(t, PushProgram(ctx, d, class, "starter", p, "lesson-2", func(int, int) {}))
+	u, err := d.LoadUser(ctx, "alice")
+	require.NoError(t, err)
+	assert.Len(t, u.Programs, 2)
+
+	// reusing a key for another program is a new push.
+	other := Program{UID: "other", Code: "print(1)", Language: "python", Owner: "teacher"}
+	require.NoError(t, PushProgram(ctx, d, class, "other", other, "lesson-1", func(int, int) {}))
+	u, err = d.LoadUser(ctx, "alice")
+	require.NoError(t, err)
+	require.Len(t, u.Programs, 3)
+	cp, err = d.LoadProgram(ctx, u.Programs[2])
+	require.NoError(t, err)
+	assert.Equal(t, "print(1)", cp.Code)
+}
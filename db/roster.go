@@ -0,0 +1,215 @@
+package db
+
+import (
+	"context"
+	"encoding/csv"
+	"io"
+	"strings"
+	"time"
+
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// IsEmail reports whether a roster entry is an email address
+// rather than a UID.
+func IsEmail(entry string) bool {
+	return strings.Contains(entry, "@")
+}
+
+// NormalizeEmail returns email in the form it is stored in.
+func NormalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// AddMember enrolls uid as a member of the class, recording when
+// they joined and dropping any invitation addressed to their UID.
+// Returns false if uid already belongs to the class.
+func (c *Class) AddMember(uid string, at time.Time) bool {
+	if containsString(c.Members, uid) || containsString(c.Instructors, uid) {
+		return false
+	}
+	c.Members = append(c.Members, uid)
+	if c.JoinDates == nil {
+		c.JoinDates = make(map[string]time.Time)
+	}
+	c.JoinDates[uid] = at
+	c.Invitations = removeString(c.Invitations, uid)
+	return true
+}
+
+// Invite records a pending invitation to the class for the user
+// with the given UID or email, who doesn't have an account yet.
+// Returns false if they were already invited.
+func (c *Class) Invite(entry string) bool {
+	if containsString(c.Invitations, entry) {
+		return false
+	}
+	c.Invitations = append(c.Invitations, entry)
+	return true
+}
+
+// enroll enrolls u as a member of c on an instructor's behalf, as
+// from a roster or an invitation, in place of any pending
+// invitations to their UID or email or request to join. Unlike
+// joining through the WID, the join code and approval queue don't
+// apply, but banned users aren't enrolled, nor is anyone once the
+// class is archived or full. Returns false if u already belongs to
+// the class.
+func enroll(c *Class, u *User, at time.Time) (bool, error) {
+	enrolled := false
+	if !c.IsInstructor(u.UID) && !containsString(c.Members, u.UID) {
+		if err := c.checkEnroll(u.UID); err != nil {
+			return false, err
+		}
+		c.removePending(u.UID)
+		enrolled = c.AddMember(u.UID, at)
+	}
+	if u.Email != "" {
+		c.Invitations = removeString(c.Invitations, u.Email)
+	}
+	if !containsString(u.Classes, c.CID) {
+		u.Classes = append(u.Classes, c.CID)
+	}
+	return enrolled, nil
+}
+
+// EnrollClassMember enrolls the user uid in the class cid on an
+// instructor's behalf, returning false if they already belong to
+// it.
+func (d *DB) EnrollClassMember(ctx context.Context, cid, uid string) (bool, error) {
+	enrolled := false
+	err := d.classTransaction(ctx, cid, []string{uid}, func(c *Class, u []*User) (err error) {
+		enrolled, err = enroll(c, u[0], time.Now().UTC())
+		return err
+	})
+	return enrolled, err
+}
+
+// InviteClassUsers records pending invitations to the class cid for
+// the users with the given UIDs or emails, who don't have accounts
+// yet.
+func (d *DB) InviteClassUsers(ctx context.Context, cid string, entries []string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		for _, entry := range entries {
+			c.Invite(entry)
+		}
+		return nil
+	})
+}
+
+// ParseRoster reads the UIDs and emails listed in the first column
+// of a CSV roster. A header row, blank rows and duplicate entries
+// are skipped, and emails are normalized.
+func ParseRoster(r io.Reader) ([]string, error) {
+	in := csv.NewReader(r)
+	in.FieldsPerRecord = -1
+	in.TrimLeadingSpace = true
+
+	entries := []string{}
+	seen := make(map[string]bool)
+	for first := true; ; first = false {
+		record, err := in.Read()
+		if err == io.EOF {
+			return entries, nil
+		}
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		entry := strings.TrimSpace(record[0])
+		if IsEmail(entry) {
+			entry = NormalizeEmail(entry)
+		}
+		if first && (strings.EqualFold(entry, "uid") || strings.EqualFold(entry, "email")) {
+			continue
+		}
+		if entry == "" || seen[entry] {
+			continue
+		}
+		seen[entry] = true
+		entries = append(entries, entry)
+	}
+}
+
+// WriteRoster writes the instructors and members of class to w as
+// CSV, with their display names, emails and join dates. Rows are
+// written as each user is loaded, and cells which spreadsheets would
+// evaluate as formulas are quoted. Users whose accounts can no
+// longer be loaded are listed by UID alone.
+func WriteRoster(ctx context.Context, d TLADB, class Class, w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write([]string{"uid", "displayName", "email", "role", "joined"}); err != nil {
+		return err
+	}
+
+	write := func(uid, role string) error {
+		u, _ := d.LoadUser(ctx, uid)
+		joined := ""
+		if at, ok := class.JoinDates[uid]; ok {
+			joined = at.UTC().Format(time.RFC3339)
+		}
+		if err := out.Write([]string{csvCell(uid), csvCell(u.DisplayName), csvCell(u.Email), role, joined}); err != nil {
+			return err
+		}
+		out.Flush()
+		return out.Error()
+	}
+	for _, uid := range class.Instructors {
+		if err := write(uid, "instructor"); err != nil {
+			return err
+		}
+	}
+	for _, uid := range class.Members {
+		if err := write(uid, "member"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadUserByEmail returns the user with the given email.
+func (d *DB) LoadUserByEmail(ctx context.Context, email string) (User, error) {
+	docs := d.Collection(usersPath).Where("email", "==", NormalizeEmail(email)).Limit(1).Documents(ctx)
+	defer docs.Stop()
+
+	doc, err := docs.Next()
+	if err == iterator.Done {
+		return User{}, status.Errorf(codes.NotFound, "no user with email %s", email)
+	}
+	if err != nil {
+		return User{}, err
+	}
+
+	u := User{}
+	if err := doc.DataTo(&u); err != nil {
+		return User{}, err
+	}
+	u.UID = doc.Ref.ID
+	return u, nil
+}
+
+// LoadInvitedClasses returns every class with a pending invitation
+// for the given UID or email.
+func (d *DB) LoadInvitedClasses(ctx context.Context, entry string) ([]Class, error) {
+	docs := d.Collection(classesPath).Where("invitations", "array-contains", entry).Documents(ctx)
+	defer docs.Stop()
+
+	classes := []Class{}
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return classes, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		c := Class{}
+		if err := doc.DataTo(&c); err != nil {
+			return nil, err
+		}
+		classes = append(classes, c)
+	}
+}
@@ -0,0 +1,44 @@
+package db
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestParseRoster(t *testing.T) {
+	entries, err := ParseRoster(strings.NewReader("email,name\n Joe.Bruin@UCLA.edu ,Joe\n\nabc123\njoe.bruin@ucla.edu\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"joe.bruin@ucla.edu", "abc123"}, entries)
+
+	_, err = ParseRoster(strings.NewReader("\"unterminated\n"))
+	assert.Error(t, err)
+}
+
+func TestClassMembership(t *testing.T) {
+	now := time.Now()
+	c := Class{CID: "test", Instructors: []string{"teacher"}, Capacity: 1}
+	assert.True(t, c.Invite("joe@ucla.edu"))
+	assert.False(t, c.Invite("joe@ucla.edu"))
+	assert.True(t, c.Invite("joe"))
+
+	assert.False(t, c.AddMember("teacher", now))
+	joe := User{UID: "joe", Email: "joe@ucla.edu"}
+	enrolled, err := enroll(&c, &joe, now)
+	require.NoError(t, err)
+	assert.True(t, enrolled)
+	assert.Equal(t, []string{"joe"}, c.Members)
+	assert.Equal(t, []string{"test"}, joe.Classes)
+	assert.Equal(t, now, c.JoinDates["joe"])
+	assert.Empty(t, c.Invitations)
+	assert.False(t, c.AddMember("joe", now))
+
+	enrolled, err = enroll(&c, &User{UID: "ann"}, now)
+	assert.Equal(t, codes.FailedPrecondition, status.Code(err), "the class is full")
+	assert.False(t, enrolled)
+}
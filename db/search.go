@@ -0,0 +1,231 @@
+package db
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"google.golang.org/api/iterator"
+)
+
+const (
+	// nameWeight describes how much more a token appearing in
+	// a program's name counts for than one appearing in its code.
+	nameWeight = 3.0
+
+	// minTokenLength is the length below which tokens are
+	// not indexed; single characters match nearly everything.
+	minTokenLength = 2
+)
+
+// SearchResult describes a single program matched by a search,
+// ordered by Score in descending order.
+type SearchResult struct {
+	PID   string  `json:"pid"`
+	Name  string  `json:"name"`
+	Score float64 `json:"score"`
+}
+
+// posting records how often a token occurs in one program.
+type posting struct {
+	name int
+	code int
+}
+
+// indexedProgram is the metadata the index keeps for
+// each program so that it can be removed or re-indexed.
+type indexedProgram struct {
+	name   string
+	public bool
+	tokens []string
+}
+
+// programIndex is an in-process inverted index over program
+// names and code. It is safe for concurrent use.
+type programIndex struct {
+	sync.RWMutex
+	postings map[string]map[string]posting
+	programs map[string]indexedProgram
+}
+
+func newProgramIndex() *programIndex {
+	return &programIndex{
+		postings: make(map[string]map[string]posting),
+		programs: make(map[string]indexedProgram),
+	}
+}
+
+// tokenize splits s into lowercase alphanumeric tokens. camelCase
+// and snake_case identifiers are split into their component words
+// so that "drawSpiral" matches a search for "spiral".
+func tokenize(s string) []string {
+	tokens := []string{}
+	word := []rune{}
+	flush := func() {
+		if len(word) >= minTokenLength {
+			tokens = append(tokens, strings.ToLower(string(word)))
+		}
+		word = word[:0]
+	}
+
+	var prev rune
+	for _, r := range s {
+		switch {
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			word = append(word, r)
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			word = append(word, r)
+		default:
+			flush()
+		}
+		prev = r
+	}
+	flush()
+	return tokens
+}
+
+// add indexes p, replacing any previous entry for p.UID.
+func (idx *programIndex) add(p Program) {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.removeLocked(p.UID)
+	idx.addLocked(p)
+}
+
+// addMissing indexes p only if it has not been indexed yet.
+func (idx *programIndex) addMissing(p Program) {
+	idx.Lock()
+	defer idx.Unlock()
+	if _, ok := idx.programs[p.UID]; !ok {
+		idx.addLocked(p)
+	}
+}
+
+func (idx *programIndex) addLocked(p Program) {
+	counts := make(map[string]posting)
+	for _, t := range tokenize(p.Name) {
+		c := counts[t]
+		c.name++
+		counts[t] = c
+	}
+	for _, t := range tokenize(p.Code) {
+		c := counts[t]
+		c.code++
+		counts[t] = c
+	}
+
+	entry := indexedProgram{name: p.Name, public: p.Public}
+	for t, c := range counts {
+		if idx.postings[t] == nil {
+			idx.postings[t] = make(map[string]posting)
+		}
+		idx.postings[t][p.UID] = c
+		entry.tokens = append(entry.tokens, t)
+	}
+	idx.programs[p.UID] = entry
+}
+
+// remove drops pid from the index.
+func (idx *programIndex) remove(pid string) {
+	idx.Lock()
+	defer idx.Unlock()
+	idx.removeLocked(pid)
+}
+
+func (idx *programIndex) removeLocked(pid string) {
+	entry, ok := idx.programs[pid]
+	if !ok {
+		return
+	}
+	for _, t := range entry.tokens {
+		delete(idx.postings[t], pid)
+		if len(idx.postings[t]) == 0 {
+			delete(idx.postings, t)
+		}
+	}
+	delete(idx.programs, pid)
+}
+
+// search returns the programs matching query, restricted to
+// the programs in pids and, if public is set, public programs.
+// Results are ranked by a tf-idf score in which matches in the
+// program name are weighted above matches in code.
+func (idx *programIndex) search(query string, pids []string, public bool) []SearchResult {
+	idx.RLock()
+	defer idx.RUnlock()
+
+	inScope := make(map[string]bool, len(pids))
+	for _, pid := range pids {
+		inScope[pid] = true
+	}
+
+	scores := make(map[string]float64)
+	total := float64(len(idx.programs))
+	for _, t := range tokenize(query) {
+		matches := idx.postings[t]
+		if len(matches) == 0 {
+			continue
+		}
+		idf := math.Log(1 + total/float64(len(matches)))
+		for pid, c := range matches {
+			if !inScope[pid] && !(public && idx.programs[pid].public) {
+				continue
+			}
+			// dampen repeated occurrences in code so that long
+			// programs don't drown out well-named ones.
+			tf := nameWeight*float64(c.name) + math.Log(1+float64(c.code))
+			scores[pid] += idf * tf
+		}
+	}
+
+	results := make([]SearchResult, 0, len(scores))
+	for pid, score := range scores {
+		results = append(results, SearchResult{
+			PID:   pid,
+			Name:  idx.programs[pid].name,
+			Score: score,
+		})
+	}
+	sort.Slice(results, func(i, j int) bool {
+		if results[i].Score == results[j].Score {
+			return results[i].PID < results[j].PID
+		}
+		return results[i].Score > results[j].Score
+	})
+	return results
+}
+
+// SearchPrograms searches the names and code of the programs in
+// pids and, if public is set, of all public programs.
+func (d *DB) SearchPrograms(_ context.Context, query string, pids []string, public bool) ([]SearchResult, error) {
+	return d.index.search(query, pids, public), nil
+}
+
+// IndexPrograms builds the search index from every program in
+// the database. Programs stored while indexing is in progress are
+// indexed as usual and are not overwritten by older copies.
+func (d *DB) IndexPrograms(ctx context.Context) error {
+	docs := d.Collection(programsPath).Documents(ctx)
+	defer docs.Stop()
+
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		p := Program{}
+		if err := doc.DataTo(&p); err != nil {
+			continue
+		}
+		p.UID = doc.Ref.ID
+		d.index.addMissing(p)
+	}
+}
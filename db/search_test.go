@@ -0,0 +1,57 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenize(t *testing.T) {
+	assert.Equal(t, []string{"draw", "spiral", "turtle", "forward", "75"}, tokenize("drawSpiral(turtle.forward(75))"))
+	assert.Equal(t, []string{"snake", "case"}, tokenize("snake_case x"))
+	assert.Empty(t, tokenize(""))
+}
+
+func TestProgramIndex(t *testing.T) {
+	t.Run("RanksNameAboveCode", func(t *testing.T) {
+		idx := newProgramIndex()
+		idx.add(Program{UID: "name", Name: "turtle spiral"})
+		idx.add(Program{UID: "code", Name: "homework", Code: "import turtle\nspiral = 1"})
+		idx.add(Program{UID: "other", Name: "other", Code: "print('hi')"})
+
+		results := idx.search("turtle spiral", []string{"name", "code", "other"}, false)
+		require.Len(t, results, 2)
+		assert.Equal(t, "name", results[0].PID)
+		assert.Equal(t, "code", results[1].PID)
+	})
+	t.Run("Scope", func(t *testing.T) {
+		idx := newProgramIndex()
+		idx.add(Program{UID: "mine", Name: "turtle"})
+		idx.add(Program{UID: "public", Name: "turtle", Public: true})
+		idx.add(Program{UID: "private", Name: "turtle"})
+
+		results := idx.search("turtle", []string{"mine"}, false)
+		require.Len(t, results, 1)
+		assert.Equal(t, "mine", results[0].PID)
+
+		results = idx.search("turtle", nil, true)
+		require.Len(t, results, 1)
+		assert.Equal(t, "public", results[0].PID)
+	})
+	t.Run("Reindex", func(t *testing.T) {
+		idx := newProgramIndex()
+		idx.add(Program{UID: "test", Name: "turtle"})
+		idx.add(Program{UID: "test", Name: "spiral"})
+
+		assert.Empty(t, idx.search("turtle", []string{"test"}, false))
+		assert.Len(t, idx.search("spiral", []string{"test"}, false), 1)
+
+		idx.addMissing(Program{UID: "test", Name: "turtle"})
+		assert.Empty(t, idx.search("turtle", []string{"test"}, false))
+
+		idx.remove("test")
+		assert.Empty(t, idx.search("spiral", []string{"test"}, false))
+		assert.Empty(t, idx.postings)
+	})
+}
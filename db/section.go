@@ -0,0 +1,223 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Section is a group of members within a class, led by some of the
+// class's instructors as TAs. Each member belongs to at most one
+// section.
+type Section struct {
+	ID      string   `firestore:"id" json:"id"`
+	Name    string   `firestore:"name" json:"name"`
+	TAs     []string `firestore:"tas" json:"tas"`
+	Members []string `firestore:"members" json:"members"`
+}
+
+// section returns the index of the section with the given ID, or
+// -1 if there is none.
+func (c *Class) section(id string) int {
+	for i, s := range c.Sections {
+		if s.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// SectionsOf returns the IDs of the sections uid belongs to, as a
+// member or as a TA.
+func (c *Class) SectionsOf(uid string) []string {
+	ids := []string{}
+	for _, s := range c.Sections {
+		if containsString(s.Members, uid) || containsString(s.TAs, uid) {
+			ids = append(ids, s.ID)
+		}
+	}
+	return ids
+}
+
+// isTA reports whether uid leads any section of the class. The
+// creator of the class, who alone assigns TAs, is never limited to
+// their sections.
+func (c *Class) isTA(uid string) bool {
+	if uid == c.Creator {
+		return false
+	}
+	for _, s := range c.Sections {
+		if containsString(s.TAs, uid) {
+			return true
+		}
+	}
+	return false
+}
+
+// MembersVisibleTo returns the members of the class that uid may
+// see, limited to the section with the given ID if it isn't empty.
+// TAs only see the members of the sections they lead.
+func (c *Class) MembersVisibleTo(uid, section string) ([]string, error) {
+	var scope []string
+	switch {
+	case section != "":
+		i := c.section(section)
+		if i < 0 {
+			return nil, status.Errorf(codes.NotFound, "class %s has no section %s", c.CID, section)
+		}
+		if c.isTA(uid) && !containsString(c.Sections[i].TAs, uid) {
+			return nil, status.Errorf(codes.PermissionDenied, "user %s is not a TA of section %s", uid, section)
+		}
+		scope = c.Sections[i].Members
+	case c.isTA(uid):
+		for _, s := range c.Sections {
+			if containsString(s.TAs, uid) {
+				scope = append(scope, s.Members...)
+			}
+		}
+	default:
+		return c.Members, nil
+	}
+
+	// sections may still list users who have since left the class.
+	members := []string{}
+	for _, m := range c.Members {
+		if containsString(scope, m) {
+			members = append(members, m)
+		}
+	}
+	return members, nil
+}
+
+// CanSee reports whether uid may see the work of the member, as
+// anyone may but a TA outside the sections they lead.
+func (c *Class) CanSee(uid, member string) bool {
+	if !c.isTA(uid) {
+		return true
+	}
+	members, _ := c.MembersVisibleTo(uid, "")
+	return containsString(members, member)
+}
+
+// ForTA returns the class as seen by uid if they are a TA, with
+// only the sections they lead, and the members and join dates of
+// those sections' members and of the instructors. Anyone else sees
+// the class unchanged.
+func (c Class) ForTA(uid string) Class {
+	if !c.isTA(uid) {
+		return c
+	}
+	c.Members, _ = c.MembersVisibleTo(uid, "")
+
+	sections := []Section{}
+	for _, s := range c.Sections {
+		if containsString(s.TAs, uid) {
+			sections = append(sections, s)
+		}
+	}
+	c.Sections = sections
+
+	joinDates := make(map[string]time.Time)
+	for id, t := range c.JoinDates {
+		if containsString(c.Members, id) || c.IsInstructor(id) {
+			joinDates[id] = t
+		}
+	}
+	c.JoinDates = joinDates
+	return c
+}
+
+// CheckSections returns an InvalidArgument error if any of the
+// given IDs isn't a section of the class.
+func (c *Class) CheckSections(ids []string) error {
+	for _, id := range ids {
+		if c.section(id) < 0 {
+			return status.Errorf(codes.InvalidArgument, "class %s has no section %s", c.CID, id)
+		}
+	}
+	return nil
+}
+
+// dropFromSections takes uid out of every section of the class.
+func (c *Class) dropFromSections(uid string) {
+	for i := range c.Sections {
+		c.Sections[i].Members = removeString(c.Sections[i].Members, uid)
+		c.Sections[i].TAs = removeString(c.Sections[i].TAs, uid)
+	}
+}
+
+// setSection creates or replaces the section s of c on behalf of
+// actor, who must be the creator, since sections decide what TAs
+// may see. Members of s must be members of c, and are moved out of
+// any other section. TAs of s must be instructors of c.
+func setSection(c *Class, actor string, s Section) error {
+	if actor != c.Creator {
+		return status.Errorf(codes.PermissionDenied, "only the creator of class %s may manage sections", c.CID)
+	}
+	members, tas := []string{}, []string{}
+	for _, m := range s.Members {
+		if !containsString(c.Members, m) {
+			return status.Errorf(codes.FailedPrecondition, "user %s is not a member of class %s", m, c.CID)
+		}
+		if !containsString(members, m) {
+			members = append(members, m)
+		}
+	}
+	for _, ta := range s.TAs {
+		if !c.IsInstructor(ta) {
+			return status.Errorf(codes.FailedPrecondition, "user %s is not an instructor of class %s", ta, c.CID)
+		}
+		if !containsString(tas, ta) {
+			tas = append(tas, ta)
+		}
+	}
+	s.Members, s.TAs = members, tas
+
+	for i := range c.Sections {
+		if c.Sections[i].ID == s.ID {
+			continue
+		}
+		for _, m := range s.Members {
+			c.Sections[i].Members = removeString(c.Sections[i].Members, m)
+		}
+	}
+	if i := c.section(s.ID); i >= 0 {
+		c.Sections[i] = s
+	} else {
+		c.Sections = append(c.Sections, s)
+	}
+	return nil
+}
+
+// deleteSection removes the section with the given ID from c on
+// behalf of actor, who must be the creator. Its members stay in the
+// class.
+func deleteSection(c *Class, actor, id string) error {
+	if actor != c.Creator {
+		return status.Errorf(codes.PermissionDenied, "only the creator of class %s may manage sections", c.CID)
+	}
+	i := c.section(id)
+	if i < 0 {
+		return status.Errorf(codes.NotFound, "class %s has no section %s", c.CID, id)
+	}
+	c.Sections = append(c.Sections[:i], c.Sections[i+1:]...)
+	return nil
+}
+
+// SetClassSection creates or replaces the section s of the class
+// cid on behalf of actor, who must be its creator.
+func (d *DB) SetClassSection(ctx context.Context, cid, actor string, s Section) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return setSection(c, actor, s)
+	})
+}
+
+// DeleteClassSection removes the section id from the class cid on
+// behalf of actor, who must be its creator.
+func (d *DB) DeleteClassSection(ctx context.Context, cid, actor, id string) error {
+	return d.classTransaction(ctx, cid, nil, func(c *Class, _ []*User) error {
+		return deleteSection(c, actor, id)
+	})
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSection(t *testing.T) {
+	c := Class{
+		CID:         "test",
+		Creator:     "teacher",
+		Instructors: []string{"teacher", "ta"},
+		Members:     []string{"alice", "bob", "carol"},
+		JoinDates: map[string]time.Time{
+			"teacher": time.Unix(0, 0),
+			"alice":   time.Unix(1, 0),
+			"bob":     time.Unix(2, 0),
+		},
+	}
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(setSection(&c, "alice", Section{ID: "a"})))
+	assert.Equal(t, codes.PermissionDenied, status.Code(setSection(&c, "ta", Section{ID: "a"})))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(setSection(&c, "teacher", Section{ID: "a", Members: []string{"dave"}})))
+	assert.Equal(t, codes.FailedPrecondition, status.Code(setSection(&c, "teacher", Section{ID: "a", TAs: []string{"alice"}})))
+
+	require.NoError(t, setSection(&c, "teacher", Section{ID: "a", Name: "A", TAs: []string{"ta"}, Members: []string{"alice", "bob", "alice"}}))
+	require.NoError(t, setSection(&c, "teacher", Section{ID: "b", Name: "B", Members: []string{"bob", "carol"}}))
+	require.Len(t, c.Sections, 2)
+	assert.Equal(t, []string{"alice"}, c.Sections[0].Members, "bob moves to section b")
+	assert.Equal(t, []string{"a"}, c.SectionsOf("alice"))
+	assert.Equal(t, []string{"a"}, c.SectionsOf("ta"))
+
+	t.Run("Visibility", func(t *testing.T) {
+		members, err := c.MembersVisibleTo("ta", "")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice"}, members)
+
+		_, err = c.MembersVisibleTo("ta", "b")
+		assert.Equal(t, codes.PermissionDenied, status.Code(err))
+		_, err = c.MembersVisibleTo("teacher", "nope")
+		assert.Equal(t, codes.NotFound, status.Code(err))
+
+		members, err = c.MembersVisibleTo("teacher", "")
+		require.NoError(t, err)
+		assert.Equal(t, c.Members, members)
+		members, err = c.MembersVisibleTo("teacher", "b")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"bob", "carol"}, members)
+	})
+
+	t.Run("ForTA", func(t *testing.T) {
+		view := c.ForTA("ta")
+		assert.Equal(t, []string{"alice"}, view.Members)
+		require.Len(t, view.Sections, 1)
+		assert.Equal(t, "a", view.Sections[0].ID)
+		assert.Len(t, view.JoinDates, 2)
+		assert.Contains(t, view.JoinDates, "teacher")
+		assert.Contains(t, view.JoinDates, "alice")
+
+		assert.Equal(t, c, c.ForTA("teacher"))
+	})
+
+	t.Run("Assignments", func(t *testing.T) {
+		assert.True(t, Assignment{}.IsFor(c.SectionsOf("carol")))
+		assert.True(t, Assignment{Sections: []string{"b"}}.IsFor(c.SectionsOf("carol")))
+		assert.False(t, Assignment{Sections: []string{"a"}}.IsFor(c.SectionsOf("carol")))
+		assert.Equal(t, codes.InvalidArgument, status.Code(c.CheckSections([]string{"a", "nope"})))
+	})
+
+	require.NoError(t, removeMember(&c, "teacher", &User{UID: "bob"}, "", false))
+	assert.Equal(t, []string{"carol"}, c.Sections[1].Members)
+
+	assert.Equal(t, codes.PermissionDenied, status.Code(deleteSection(&c, "ta", "a")))
+	assert.Equal(t, codes.NotFound, status.Code(deleteSection(&c, "teacher", "nope")))
+	require.NoError(t, deleteSection(&c, "teacher", "a"))
+	require.Len(t, c.Sections, 1)
+	assert.Empty(t, c.SectionsOf("alice"))
+}
@@ -0,0 +1,217 @@
+package db
+
+import (
+	"archive/zip"
+	"context"
+	"io"
+	"sort"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+)
+
+// maxBatchWrites is the most writes Firestore accepts in a single
+// batch.
+const maxBatchWrites = 500
+
+// ClassSnapshot is the code of one program of each member of a
+// class, such as their copies of an assignment, captured at a
+// moment. Snapshots are never changed once taken.
+type ClassSnapshot struct {
+	ID      string    `firestore:"ID" json:"id"`
+	CID     string    `firestore:"CID" json:"cid"`
+	Name    string    `firestore:"name" json:"name"`
+	TakenBy string    `firestore:"takenBy" json:"takenBy"`
+	TakenAt time.Time `firestore:"takenAt" json:"takenAt"`
+
+	// AID is set if the snapshot captured copies of an assignment,
+	// and PushPID and PushKey if it captured copies of a pushed
+	// program.
+	AID     string `firestore:"AID" json:"aid"`
+	PushPID string `firestore:"pushPID" json:"pushPid"`
+	PushKey string `firestore:"pushKey" json:"pushKey"`
+
+	// Members lists the members whose program was captured.
+	Members []string `firestore:"members" json:"members"`
+
+	// Programs holds the captured programs keyed by member UID. It
+	// is stored apart from the snapshot, and only filled in when a
+	// snapshot is browsed.
+	Programs map[string]Program `firestore:"-" json:"programs,omitempty"`
+}
+
+// PushedCopies returns the PIDs of the copies of the program src
+// pushed to the members of class with the given key, keyed by
+// member UID.
+func PushedCopies(class Class, src, key string) map[string]string {
+	copies := make(map[string]string, len(class.Members))
+	for _, uid := range class.Members {
+		copies[uid] = pushedProgramID(class.CID, src, key, uid)
+	}
+	return copies
+}
+
+// TakeSnapshot captures the current code of each program in copies,
+// keyed by member UID, into the new snapshot s of class. Only the
+// copies of current members are captured, and those which don't
+// exist are left out.
+func TakeSnapshot(ctx context.Context, d TLADB, class Class, s ClassSnapshot, copies map[string]string) (ClassSnapshot, error) {
+	pids := []string{}
+	for _, uid := range class.Members {
+		if pid, ok := copies[uid]; ok {
+			pids = append(pids, pid)
+		}
+	}
+	programs, err := d.LoadPrograms(ctx, pids)
+	if err != nil {
+		return ClassSnapshot{}, err
+	}
+
+	captured := make(map[string]Program, len(programs))
+	s.CID = class.CID
+	s.Members = []string{}
+	for _, uid := range class.Members {
+		if p, ok := programs[copies[uid]]; ok {
+			captured[uid] = p
+			s.Members = append(s.Members, uid)
+		}
+	}
+	return d.CreateSnapshot(ctx, s, captured)
+}
+
+// snapshotFileExtensions gives the extension of the file a program
+// is written to when a snapshot is downloaded, by language.
+var snapshotFileExtensions = map[string]string{
+	"python":     ".py",
+	"processing": ".js",
+	"html":       ".html",
+	"react":      ".jsx",
+}
+
+// WriteSnapshotZip writes the programs of the given members
+// captured in the snapshot s to w as a zip archive, one file per
+// member named after their UID. Members who weren't captured are
+// left out.
+func WriteSnapshotZip(ctx context.Context, d TLADB, s ClassSnapshot, members []string, w io.Writer) error {
+	programs, err := d.LoadSnapshotPrograms(ctx, s.ID, members)
+	if err != nil {
+		return err
+	}
+	uids := make([]string, 0, len(programs))
+	for uid := range programs {
+		uids = append(uids, uid)
+	}
+	sort.Strings(uids)
+
+	out := zip.NewWriter(w)
+	for _, uid := range uids {
+		p := programs[uid]
+		f, err := out.CreateHeader(&zip.FileHeader{
+			Name:     uid + snapshotFileExtensions[p.Language],
+			Method:   zip.Deflate,
+			Modified: s.TakenAt,
+		})
+		if err != nil {
+			return err
+		}
+		if _, err := io.WriteString(f, p.Code); err != nil {
+			return err
+		}
+	}
+	return out.Close()
+}
+
+// CreateSnapshot stores s as a new snapshot with the given programs,
+// keyed by member UID, giving it an ID. The programs are written
+// before the snapshot itself, so that a snapshot is never listed
+// before all of its programs are stored.
+func (d *DB) CreateSnapshot(ctx context.Context, s ClassSnapshot, programs map[string]Program) (ClassSnapshot, error) {
+	ref := d.Collection(snapshotsPath).NewDoc()
+	s.ID = ref.ID
+
+	batch, writes := d.Batch(), 0
+	for uid, p := range programs {
+		batch.Create(ref.Collection(snapshotProgramsPath).Doc(uid), p)
+		if writes++; writes == maxBatchWrites {
+			if _, err := batch.Commit(ctx); err != nil {
+				return s, err
+			}
+			batch, writes = d.Batch(), 0
+		}
+	}
+	if writes > 0 {
+		if _, err := batch.Commit(ctx); err != nil {
+			return s, err
+		}
+	}
+
+	if _, err := ref.Create(ctx, s); err != nil {
+		return s, err
+	}
+	return s, nil
+}
+
+// LoadSnapshot returns the snapshot with the given ID, without its
+// programs.
+func (d *DB) LoadSnapshot(ctx context.Context, id string) (ClassSnapshot, error) {
+	doc, err := d.Collection(snapshotsPath).Doc(id).Get(ctx)
+	if err != nil {
+		return ClassSnapshot{}, err
+	}
+
+	s := ClassSnapshot{}
+	if err := doc.DataTo(&s); err != nil {
+		return ClassSnapshot{}, err
+	}
+	return s, nil
+}
+
+// LoadSnapshotPrograms returns the programs of the given members
+// captured in the snapshot id, keyed by member UID. Members who
+// weren't captured are left out.
+func (d *DB) LoadSnapshotPrograms(ctx context.Context, id string, uids []string) (map[string]Program, error) {
+	snaps, err := d.getAll(ctx, snapshotsPath+"/"+id+"/"+snapshotProgramsPath, uids)
+	if err != nil {
+		return nil, err
+	}
+
+	programs := make(map[string]Program, len(snaps))
+	for _, snap := range snaps {
+		p := Program{}
+		if err := snap.DataTo(&p); err != nil {
+			return nil, err
+		}
+		programs[snap.Ref.ID] = p
+	}
+	return programs, nil
+}
+
+// ListSnapshots returns the snapshots of the class cid, newest
+// first, without their programs.
+//
+// The query needs a composite index on CID and takenAt.
+func (d *DB) ListSnapshots(ctx context.Context, cid string) ([]ClassSnapshot, error) {
+	docs := d.Collection(snapshotsPath).
+		Where("CID", "==", cid).
+		OrderBy("takenAt", firestore.Desc).
+		Documents(ctx)
+	defer docs.Stop()
+
+	snapshots := []ClassSnapshot{}
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return snapshots, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		s := ClassSnapshot{}
+		if err := doc.DataTo(&s); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshot(t *testing.T) {
+	ctx := context.Background()
+	d := OpenMock()
+	class := Class{CID: "test", Members: []string{"alice", "bob", "carol"}}
+	require.NoError(t, d.StoreProgram(ctx, Program{UID: "alice-copy", Language: "python", Code: "print('alice')"}))
+	require.NoError(t, d.StoreProgram(ctx, Program{UID: "bob-copy", Language: "html", Code: "<p>bob</p>"}))
+	require.NoError(t, d.StoreProgram(ctx, Program{UID: "dave-copy", Language: "python"}))
+	copies := map[string]string{"alice": "alice-copy", "bob": "bob-copy", "dave": "dave-copy"}
+
+	s, err := TakeSnapshot(ctx, d, class, ClassSnapshot{Name: "Lesson 1", TakenAt: time.Now().UTC()}, copies)
+	require.NoError(t, err)
+	assert.NotEmpty(t, s.ID)
+	assert.Equal(t, []string{"alice", "bob"}, s.Members, "only current members with a copy are captured")
+
+	// later edits don't change the snapshot.
+	require.NoError(t, d.StoreProgram(ctx, Program{UID: "alice-copy", Language: "python", Code: "print('edited')"}))
+	programs, err := d.LoadSnapshotPrograms(ctx, s.ID, s.Members)
+	require.NoError(t, err)
+	assert.Equal(t, "print('alice')", programs["alice"].Code)
+
+	snapshots, err := d.ListSnapshots(ctx, "test")
+	require.NoError(t, err)
+	require.Len(t, snapshots, 1)
+	assert.Equal(t, "Lesson 1", snapshots[0].Name)
+
+	buf := &bytes.Buffer{}
+	require.NoError(t, WriteSnapshotZip(ctx, d, s, s.Members, buf))
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+	require.Len(t, r.File, 2)
+	assert.Equal(t, "alice.py", r.File[0].Name)
+	assert.Equal(t, "bob.html", r.File[1].Name)
+	f, err := r.File[0].Open()
+	require.NoError(t, err)
+	code, err := ioutil.ReadAll(f)
+	require.NoError(t, err)
+	assert.Equal(t, "print('alice')", string(code))
+}
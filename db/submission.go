@@ -0,0 +1,158 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Submission is a struct representation of a member's turned-in
+// version of an assignment. The submitted program is frozen at
+// submission time, so later edits to the member's copy don't
+// change what was turned in.
+type Submission struct {
+	AID         string    `firestore:"AID" json:"aid"`
+	UID         string    `firestore:"UID" json:"uid"`
+	PID         string    `firestore:"PID" json:"pid"`
+	Snapshot    Program   `firestore:"snapshot" json:"snapshot"`
+	SubmittedAt time.Time `firestore:"submittedAt" json:"submittedAt"`
+	Late        bool      `firestore:"late" json:"late"`
+
+	// Attempts counts how many times the member has submitted.
+	Attempts int `firestore:"attempts" json:"attempts"`
+
+	// Grade is nil until an instructor grades the submission.
+	Grade *Grade `firestore:"grade" json:"grade"`
+
+	// TestResults holds the outcome of the most recent autograder
+	// run against the submission, if any.
+	TestResults  []TestResult `firestore:"testResults" json:"testResults"`
+	AutogradedAt time.Time    `firestore:"autogradedAt" json:"autogradedAt"`
+}
+
+// submissionID returns the ID of the document holding the
+// submission of the user uid for the assignment aid. Members
+// have at most one submission per assignment.
+func submissionID(aid, uid string) string {
+	return aid + "_" + uid
+}
+
+// DueFor returns the due date of the assignment for the user
+// uid, taking any extension they were granted into account.
+// A zero time means the assignment has no due date.
+func (a Assignment) DueFor(uid string) time.Time {
+	if ext, ok := a.Extensions[uid]; ok && ext.After(a.DueDate) {
+		return ext
+	}
+	return a.DueDate
+}
+
+// LockFor returns the time after which the user uid may no
+// longer submit. Extensions past the lock date push it back for
+// that user. A zero time means submissions are never locked.
+func (a Assignment) LockFor(uid string) time.Time {
+	if a.LockDate.IsZero() {
+		return a.LockDate
+	}
+	if due := a.DueFor(uid); due.After(a.LockDate) {
+		return due
+	}
+	return a.LockDate
+}
+
+// Submit returns the submission of p by the user uid at the given
+// time, replacing prev if the user has submitted before. Any grade
+// or test results given to prev are dropped, since they no longer
+// apply. Fails if the assignment is locked for the user.
+func (a Assignment) Submit(prev Submission, p Program, uid string, at time.Time) (Submission, error) {
+	if lock := a.LockFor(uid); !lock.IsZero() && at.After(lock) {
+		return prev, status.Errorf(codes.FailedPrecondition, "assignment %s no longer accepts submissions", a.AID)
+	}
+
+	due := a.DueFor(uid)
+	return Submission{
+		AID:         a.AID,
+		UID:         uid,
+		PID:         p.UID,
+		Snapshot:    p,
+		SubmittedAt: at,
+		Late:        !due.IsZero() && at.After(due),
+		Attempts:    prev.Attempts + 1,
+	}, nil
+}
+
+// LoadSubmission returns the submission of the user uid for the
+// assignment aid.
+func (d *DB) LoadSubmission(ctx context.Context, aid, uid string) (Submission, error) {
+	doc, err := d.Collection(submissionsPath).Doc(submissionID(aid, uid)).Get(ctx)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	s := Submission{}
+	if err := doc.DataTo(&s); err != nil {
+		return Submission{}, err
+	}
+	return s, nil
+}
+
+// StoreSubmission stores s, replacing the user's previous
+// submission for the assignment if there is one.
+func (d *DB) StoreSubmission(ctx context.Context, s Submission) error {
+	if _, err := d.Collection(submissionsPath).Doc(submissionID(s.AID, s.UID)).Set(ctx, &s); err != nil {
+		return err
+	}
+	return nil
+}
+
+// GrantExtension records due as the member uid's due date for the
+// assignment aid, leaving the rest of the assignment, including
+// other members' extensions, untouched.
+func (d *DB) GrantExtension(ctx context.Context, aid, uid string, due time.Time) error {
+	_, err := d.Collection(assignmentsPath).Doc(aid).Update(ctx, []firestore.Update{
+		{FieldPath: firestore.FieldPath{"extensions", uid}, Value: due},
+	})
+	return err
+}
+
+// UpdateSubmission stores only the given fields of s, named by
+// their Firestore keys, and returns the submission as stored. It
+// fails with FailedPrecondition if the member has resubmitted
+// since s was loaded, so that work on an older submission is not
+// recorded against the newer one.
+func (d *DB) UpdateSubmission(ctx context.Context, s Submission, fields ...string) (Submission, error) {
+	up, err := fieldUpdates(s, fields)
+	if err != nil {
+		return Submission{}, err
+	}
+
+	ref := d.Collection(submissionsPath).Doc(submissionID(s.AID, s.UID))
+	stored := Submission{}
+	err = d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		stored = Submission{}
+		if err := snap.DataTo(&stored); err != nil {
+			return err
+		}
+		if !stored.SubmittedAt.Equal(s.SubmittedAt) {
+			return status.Error(codes.FailedPrecondition, "the submission has changed since it was loaded")
+		}
+		if len(up) == 0 {
+			return nil
+		}
+		return tx.Update(ref, up)
+	})
+	if err != nil {
+		return Submission{}, err
+	}
+	if err := copyFields(&stored, s, fields); err != nil {
+		return Submission{}, err
+	}
+	return stored, nil
+}
@@ -0,0 +1,52 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSubmit(t *testing.T) {
+	due := time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)
+	a := Assignment{
+		AID:        "test",
+		DueDate:    due,
+		LockDate:   due.Add(24 * time.Hour),
+		Extensions: map[string]time.Time{"slow": due.Add(72 * time.Hour)},
+	}
+	p := Program{UID: "copy", Code: "import turtle"}
+
+	t.Run("OnTime", func(t *testing.T) {
+		s, err := a.Submit(Submission{}, p, "test", due.Add(-time.Hour))
+		require.NoError(t, err)
+		assert.False(t, s.Late)
+		assert.Equal(t, "copy", s.PID)
+		assert.Equal(t, p, s.Snapshot)
+		assert.Equal(t, 1, s.Attempts)
+	})
+	t.Run("Late", func(t *testing.T) {
+		prev := Submission{Attempts: 1}
+		s, err := a.Submit(prev, p, "test", due.Add(time.Hour))
+		require.NoError(t, err)
+		assert.True(t, s.Late)
+		assert.Equal(t, 2, s.Attempts)
+	})
+	t.Run("Locked", func(t *testing.T) {
+		_, err := a.Submit(Submission{}, p, "test", due.Add(48*time.Hour))
+		assert.Equal(t, codes.FailedPrecondition, status.Code(err))
+	})
+	t.Run("Extension", func(t *testing.T) {
+		s, err := a.Submit(Submission{}, p, "slow", due.Add(48*time.Hour))
+		require.NoError(t, err)
+		assert.False(t, s.Late)
+	})
+	t.Run("NoDueDate", func(t *testing.T) {
+		s, err := Assignment{}.Submit(Submission{}, p, "test", due)
+		require.NoError(t, err)
+		assert.False(t, s.Late)
+	})
+}
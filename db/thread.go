@@ -0,0 +1,261 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"cloud.google.com/go/firestore"
+	"google.golang.org/api/iterator"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Snippet is an excerpt of a program attached to a discussion
+// post. Code holds the lines as they were when the post was made,
+// so readers who can't open the program can still see them.
+type Snippet struct {
+	PID       string `firestore:"PID" json:"pid"`
+	StartLine int    `firestore:"startLine" json:"startLine"`
+	EndLine   int    `firestore:"endLine" json:"endLine"`
+	Code      string `firestore:"code" json:"code"`
+}
+
+// NewSnippet returns the snippet of lines start to end of the
+// program p with the given PID, counting from one and including
+// both ends.
+func NewSnippet(pid string, p Program, start, end int) (Snippet, error) {
+	lines := strings.Split(p.Code, "\n")
+	if start < 1 || end < start || end > len(lines) {
+		return Snippet{}, status.Errorf(codes.InvalidArgument, "lines %d to %d are not in a program of %d lines", start, end, len(lines))
+	}
+	return Snippet{
+		PID:       pid,
+		StartLine: start,
+		EndLine:   end,
+		Code:      strings.Join(lines[start-1:end], "\n"),
+	}, nil
+}
+
+// anonymous returns a copy of s without the PID of its program,
+// whose owner would give away who posted it, or nil if s is nil.
+func (s *Snippet) anonymous() *Snippet {
+	if s == nil {
+		return nil
+	}
+	cp := *s
+	cp.PID = ""
+	return &cp
+}
+
+// Thread is a discussion started by someone in a class. Posts made
+// anonymously hide their author from other members, but not from
+// instructors.
+type Thread struct {
+	ID        string    `firestore:"ID" json:"id"`
+	CID       string    `firestore:"CID" json:"cid"`
+	Author    string    `firestore:"author" json:"author"`
+	Anonymous bool      `firestore:"anonymous" json:"anonymous"`
+	Title     string    `firestore:"title" json:"title"`
+	Body      string    `firestore:"body" json:"body"`
+	Snippet   *Snippet  `firestore:"snippet" json:"snippet"`
+	CreatedAt time.Time `firestore:"createdAt" json:"createdAt"`
+
+	// Answered is set while any reply to the thread is endorsed by
+	// an instructor.
+	Answered bool `firestore:"answered" json:"answered"`
+}
+
+// ForUser returns the thread as seen by the user uid.
+func (t Thread) ForUser(uid string, isInstructor bool) Thread {
+	if t.Anonymous && !isInstructor && t.Author != uid {
+		t.Author = ""
+		t.Snippet = t.Snippet.anonymous()
+	}
+	return t
+}
+
+// Reply is a post in reply to a thread.
+type Reply struct {
+	ID        string    `firestore:"ID" json:"id"`
+	ThreadID  string    `firestore:"threadID" json:"threadID"`
+	Author    string    `firestore:"author" json:"author"`
+	Anonymous bool      `firestore:"anonymous" json:"anonymous"`
+	Body      string    `firestore:"body" json:"body"`
+	Snippet   *Snippet  `firestore:"snippet" json:"snippet"`
+	CreatedAt time.Time `firestore:"createdAt" json:"createdAt"`
+
+	// EndorsedBy is the UID of the instructor who endorsed the
+	// reply as a good answer, or empty if none has.
+	EndorsedBy string `firestore:"endorsedBy" json:"endorsedBy"`
+}
+
+// ForUser returns the reply as seen by the user uid.
+func (r Reply) ForUser(uid string, isInstructor bool) Reply {
+	if r.Anonymous && !isInstructor && r.Author != uid {
+		r.Author = ""
+		r.Snippet = r.Snippet.anonymous()
+	}
+	return r
+}
+
+// LoadThread returns the thread with the given ID.
+func (d *DB) LoadThread(ctx context.Context, id string) (Thread, error) {
+	doc, err := d.Collection(threadsPath).Doc(id).Get(ctx)
+	if err != nil {
+		return Thread{}, err
+	}
+
+	t := Thread{}
+	if err := doc.DataTo(&t); err != nil {
+		return Thread{}, err
+	}
+	return t, nil
+}
+
+// CreateThread stores t as a new thread, giving it an ID.
+func (d *DB) CreateThread(ctx context.Context, t Thread) (Thread, error) {
+	ref := d.Collection(threadsPath).NewDoc()
+	t.ID = ref.ID
+	if _, err := ref.Create(ctx, t); err != nil {
+		return t, err
+	}
+	return t, nil
+}
+
+// ListThreads returns up to limit threads of the class cid, newest
+// first, starting after the thread with the ID after, or from the
+// newest if after is empty.
+//
+// The query needs a composite index on CID and createdAt.
+func (d *DB) ListThreads(ctx context.Context, cid, after string, limit int) ([]Thread, error) {
+	q := d.Collection(threadsPath).
+		Where("CID", "==", cid).
+		OrderBy("createdAt", firestore.Desc).
+		OrderBy(firestore.DocumentID, firestore.Desc).
+		Limit(limit)
+	if after != "" {
+		snap, err := d.Collection(threadsPath).Doc(after).Get(ctx)
+		if err != nil {
+			return nil, err
+		}
+		q = q.StartAfter(snap)
+	}
+
+	docs := q.Documents(ctx)
+	defer docs.Stop()
+
+	threads := []Thread{}
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return threads, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		t := Thread{}
+		if err := doc.DataTo(&t); err != nil {
+			return nil, err
+		}
+		threads = append(threads, t)
+	}
+}
+
+// LoadReply returns the reply with the given ID.
+func (d *DB) LoadReply(ctx context.Context, id string) (Reply, error) {
+	doc, err := d.Collection(repliesPath).Doc(id).Get(ctx)
+	if err != nil {
+		return Reply{}, err
+	}
+
+	r := Reply{}
+	if err := doc.DataTo(&r); err != nil {
+		return Reply{}, err
+	}
+	return r, nil
+}
+
+// EndorseReply records the instructor uid as having endorsed the
+// reply id, or withdraws its endorsement if uid is empty, and marks
+// the reply's thread answered while any of its replies is endorsed,
+// all in one transaction. Only the reply's endorsement and whether
+// the thread is answered are written.
+func (d *DB) EndorseReply(ctx context.Context, id, uid string) (Reply, error) {
+	ref := d.Collection(repliesPath).Doc(id)
+	r := Reply{}
+	err := d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		r = Reply{}
+		if err := snap.DataTo(&r); err != nil {
+			return err
+		}
+
+		// reading every reply to the thread makes the transaction
+		// retry if another endorsement lands meanwhile.
+		docs, err := tx.Documents(d.Collection(repliesPath).Where("threadID", "==", r.ThreadID)).GetAll()
+		if err != nil {
+			return err
+		}
+		answered := uid != ""
+		for _, doc := range docs {
+			other := Reply{}
+			if err := doc.DataTo(&other); err != nil {
+				return err
+			}
+			if other.ID != r.ID && other.EndorsedBy != "" {
+				answered = true
+			}
+		}
+
+		r.EndorsedBy = uid
+		if err := tx.Update(ref, []firestore.Update{{Path: "endorsedBy", Value: uid}}); err != nil {
+			return err
+		}
+		return tx.Update(d.Collection(threadsPath).Doc(r.ThreadID), []firestore.Update{{Path: "answered", Value: answered}})
+	})
+	return r, err
+}
+
+// CreateReply stores r as a new reply, giving it an ID.
+func (d *DB) CreateReply(ctx context.Context, r Reply) (Reply, error) {
+	ref := d.Collection(repliesPath).NewDoc()
+	r.ID = ref.ID
+	if _, err := ref.Create(ctx, r); err != nil {
+		return r, err
+	}
+	return r, nil
+}
+
+// ListReplies returns every reply to the thread with the given ID,
+// oldest first.
+//
+// The query needs a composite index on threadID and createdAt.
+func (d *DB) ListReplies(ctx context.Context, threadID string) ([]Reply, error) {
+	docs := d.Collection(repliesPath).
+		Where("threadID", "==", threadID).
+		OrderBy("createdAt", firestore.Asc).
+		Documents(ctx)
+	defer docs.Stop()
+
+	replies := []Reply{}
+	for {
+		doc, err := docs.Next()
+		if err == iterator.Done {
+			return replies, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		r := Reply{}
+		if err := doc.DataTo(&r); err != nil {
+			return nil, err
+		}
+		replies = append(replies, r)
+	}
+}
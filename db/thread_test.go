@@ -0,0 +1,39 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestNewSnippet(t *testing.T) {
+	p := Program{Code: "import turtle\nt = turtle.Turtle()\nt.forward(10)"}
+
+	s, err := NewSnippet("pid", p, 2, 3)
+	require.NoError(t, err)
+	assert.Equal(t, "pid", s.PID)
+	assert.Equal(t, "t = turtle.Turtle()\nt.forward(10)", s.Code)
+
+	for _, r := range [][2]int{{0, 1}, {2, 1}, {3, 4}} {
+		_, err := NewSnippet("pid", p, r[0], r[1])
+		assert.Equal(t, codes.InvalidArgument, status.Code(err), "lines %d to %d", r[0], r[1])
+	}
+}
+
+func TestThreadForUser(t *testing.T) {
+	th := Thread{Author: "alice", Anonymous: true, Snippet: &Snippet{PID: "mine", Code: "x = 1"}}
+	assert.Empty(t, th.ForUser("bob", false).Author)
+	assert.Empty(t, th.ForUser("bob", false).Snippet.PID)
+	assert.Equal(t, "x = 1", th.ForUser("bob", false).Snippet.Code)
+	assert.Equal(t, "mine", th.Snippet.PID, "the stored snippet is left alone")
+	assert.Equal(t, "alice", th.ForUser("alice", false).Author)
+	assert.Equal(t, "mine", th.ForUser("teacher", true).Snippet.PID)
+
+	r := Reply{Author: "bob"}
+	assert.Equal(t, "bob", r.ForUser("alice", false).Author)
+	r = Reply{Author: "bob", Anonymous: true, Snippet: &Snippet{PID: "his"}}
+	assert.Empty(t, r.ForUser("alice", false).Snippet.PID)
+}
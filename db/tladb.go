@@ -2,6 +2,7 @@ package db
 
 import (
 	"context"
+	"time"
 
 	"github.com/labstack/echo/v4"
 )
@@ -20,6 +21,7 @@ type DBContext struct {
 type TLADB interface {
 	LoadProgram(context.Context, string) (Program, error)
 	StoreProgram(context.Context, Program) error
+	UpdateProgramFields(context.Context, Program, ...string) error
 	// Rename to DeleteProgram after moving API handler out of db/program.go
 	RemoveProgram(context.Context, string) error
 
@@ -27,13 +29,95 @@ type TLADB interface {
 	StoreClass(context.Context, Class) error
 	DeleteClass(context.Context, string) error
 
+	LoadAssignment(context.Context, string) (Assignment, error)
+	StoreAssignment(context.Context, Assignment) error
+	UpdateAssignment(context.Context, Assignment, ...string) error
+	DeleteAssignment(context.Context, string) error
+	LinkAssignmentCopy(context.Context, string, string, string) error
+	AddClassAssignment(context.Context, string, string) error
+	RemoveClassAssignment(context.Context, string, string) error
+
+	LoadSubmission(context.Context, string, string) (Submission, error)
+	StoreSubmission(context.Context, Submission) error
+	UpdateSubmission(context.Context, Submission, ...string) (Submission, error)
+	GrantExtension(context.Context, string, string, time.Time) error
+
+	LoadPeerReview(context.Context, string) (PeerReview, error)
+	UpdatePeerReview(context.Context, PeerReview, ...string) error
+	CreatePeerReviews(context.Context, string, int, []PeerReview) ([]PeerReview, error)
+	ListPeerReviews(context.Context, string) ([]PeerReview, error)
+
+	LoadAnnouncement(context.Context, string) (Announcement, error)
+	StoreAnnouncement(context.Context, Announcement) error
+	UpdateAnnouncement(context.Context, Announcement, ...string) error
+	CreateAnnouncement(context.Context, Announcement) (Announcement, error)
+	DeleteAnnouncement(context.Context, string) error
+	ListAnnouncements(context.Context, string, string, int) ([]Announcement, error)
+	MarkAnnouncementsRead(context.Context, string, string, []string) error
+
+	LoadThread(context.Context, string) (Thread, error)
+	CreateThread(context.Context, Thread) (Thread, error)
+	ListThreads(context.Context, string, string, int) ([]Thread, error)
+	LoadReply(context.Context, string) (Reply, error)
+	EndorseReply(context.Context, string, string) (Reply, error)
+	CreateReply(context.Context, Reply) (Reply, error)
+	ListReplies(context.Context, string) ([]Reply, error)
+
+	CreateSnapshot(context.Context, ClassSnapshot, map[string]Program) (ClassSnapshot, error)
+	LoadSnapshot(context.Context, string) (ClassSnapshot, error)
+	LoadSnapshotPrograms(context.Context, string, []string) (map[string]Program, error)
+	ListSnapshots(context.Context, string) ([]ClassSnapshot, error)
+
 	LoadUser(context.Context, string) (User, error)
 	StoreUser(context.Context, User) error
 	DeleteUser(context.Context, string) error
+	LoadUserByEmail(context.Context, string) (User, error)
+	LoadInvitedClasses(context.Context, string) ([]Class, error)
+	EnrollClassMember(context.Context, string, string) (bool, error)
+	InviteClassUsers(context.Context, string, []string) error
+	RecordProgramOpen(context.Context, string, string) error
+	UpdateFolders(context.Context, string, func(map[string]Folder) error) error
+
+	LoadUsers(context.Context, []string) (map[string]User, error)
+	LoadPrograms(context.Context, []string) (map[string]Program, error)
+	LoadSubmissions(context.Context, string, []string) (map[string]Submission, error)
 
 	CreateUser(context.Context, User) (User, error)
 	CreateProgram(context.Context, Program) (Program, error)
+	CreateAssignment(context.Context, Assignment) (Assignment, error)
+	AddClass(context.Context, Class) (Class, error)
+
+	TransferProgram(context.Context, string, string, string) error
+	AddProgramCollaborator(context.Context, string, string, string) error
+	RemoveProgramCollaborator(context.Context, string, string, string) error
+	DeleteOwnedProgram(context.Context, string, string) (Program, error)
+
+	AddClassInstructor(context.Context, string, string, string) error
+	PromoteClassMember(context.Context, string, string, string) error
+	RemoveClassInstructor(context.Context, string, string, string) error
+	TransferClass(context.Context, string, string, string) error
+	RemoveClassMember(context.Context, string, string, string, string, bool) error
+	UnbanClassUser(context.Context, string, string, string, string) error
+	JoinClass(context.Context, string, string, string) (Class, error)
+	RotateClassWID(context.Context, string, string) (string, error)
+	SetClassJoinCode(context.Context, string, string, JoinCode) error
+	SetClassAdmission(context.Context, string, string, int, bool) error
+	ApproveJoinRequest(context.Context, string, string, string) error
+	RejectJoinRequest(context.Context, string, string, string) error
+	ArchiveClass(context.Context, string, string, bool) error
+	SetClassSection(context.Context, string, string, Section) error
+	DeleteClassSection(context.Context, string, string, string) error
+	AddUserProgram(context.Context, string, string) error
+
+	ToggleProgramLike(context.Context, string, string) (bool, error)
+	IncrementProgramViews(context.Context, string) error
+	LoadProgramStats(context.Context, string) (ProgramStats, error)
+
+	SearchPrograms(context.Context, string, []string, bool) ([]SearchResult, error)
 
 	MakeAlias(context.Context, string, string) (string, error)
 	GetUIDFromWID(context.Context, string, string) (string, error)
+	DeleteAlias(context.Context, string, string) error
+	AddUserClass(context.Context, string, string, []string) error
+	RemoveUserClass(context.Context, string, string, []string) error
 }
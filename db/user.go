@@ -3,6 +3,7 @@ package db
 import (
 	"context"
 	"net/http"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/uclaacm/teach-la-go-backend/httpext"
@@ -17,20 +18,76 @@ import (
 // It provides functions for converting the struct
 // to firebase-digestible types.
 type User struct {
-	Classes           []string `firestore:"classes" json:"classes"`
-	DisplayName       string   `firestore:"displayName" json:"displayName"`
-	MostRecentProgram string   `firestore:"mostRecentProgram" json:"mostRecentProgram"`
-	PhotoName         string   `firestore:"photoName" json:"photoName"`
-	Programs          []string `firestore:"programs" json:"programs"`
-	UID               string   `json:"uid"`
-	DeveloperAcc      bool     `firestore:"developerAcc" json:"developerAcc"`
+	Classes           []string          `firestore:"classes" json:"classes"`
+	DisplayName       string            `firestore:"displayName" json:"displayName"`
+	MostRecentProgram string            `firestore:"mostRecentProgram" json:"mostRecentProgram"`
+	PhotoName         string            `firestore:"photoName" json:"photoName"`
+	Programs          []string          `firestore:"programs" json:"programs"`
+	UID               string            `json:"uid"`
+	DeveloperAcc      bool              `firestore:"developerAcc" json:"developerAcc"`
+	LikedPrograms     []string          `firestore:"likedPrograms" json:"likedPrograms"`
+	Folders           map[string]Folder `firestore:"folders" json:"folders"`
+	RecentPrograms    []RecentProgram   `firestore:"recentPrograms" json:"recentPrograms"`
+	Email             string            `firestore:"email" json:"email"`
+}
+
+// RecentProgram records when a user last opened or saved
+// one of their programs.
+type RecentProgram struct {
+	PID        string    `firestore:"pid" json:"pid"`
+	LastOpened time.Time `firestore:"lastOpened" json:"lastOpened"`
+}
+
+// OpenedProgram moves pid to the front of the user's recent
+// programs, dropping the least recently used program if the
+// list grows past MaxRecentPrograms. MostRecentProgram is kept
+// in sync for clients which predate the list.
+func (u *User) OpenedProgram(pid string, at time.Time) {
+	u.ForgetProgram(pid)
+	u.RecentPrograms = append([]RecentProgram{{PID: pid, LastOpened: at}}, u.RecentPrograms...)
+	if len(u.RecentPrograms) > MaxRecentPrograms {
+		u.RecentPrograms = u.RecentPrograms[:MaxRecentPrograms]
+	}
+	u.MostRecentProgram = pid
+}
+
+// ForgetProgram removes pid from the user's recent programs,
+// such as when the program is deleted.
+func (u *User) ForgetProgram(pid string) {
+	for i, r := range u.RecentPrograms {
+		if r.PID == pid {
+			u.RecentPrograms = append(u.RecentPrograms[:i:i], u.RecentPrograms[i+1:]...)
+			break
+		}
+	}
+	if u.MostRecentProgram != pid {
+		return
+	}
+	u.MostRecentProgram = ""
+	if len(u.RecentPrograms) != 0 {
+		u.MostRecentProgram = u.RecentPrograms[0].PID
+	}
+}
+
+// Folder is a user-defined folder for organizing programs.
+// Folders nest by referring to the ID of their parent; an
+// empty Parent places the folder at the top level.
+type Folder struct {
+	ID     string `firestore:"id" json:"id"`
+	Name   string `firestore:"name" json:"name"`
+	Parent string `firestore:"parent" json:"parent"`
 }
 
 // ToFirestoreUpdate returns the database update
-// representation of its UserData struct.
+// representation of its UserData struct, which is empty
+// if none of its updatable fields are set.
 func (u *User) ToFirestoreUpdate() []firestore.Update {
-	f := []firestore.Update{
-		{Path: "mostRecentProgram", Value: u.MostRecentProgram},
+	f := []firestore.Update{}
+	if u.MostRecentProgram != "" {
+		f = append(f,
+			firestore.Update{Path: "mostRecentProgram", Value: u.MostRecentProgram},
+			firestore.Update{Path: "recentPrograms", Value: u.RecentPrograms},
+		)
 	}
 
 	switch {
@@ -72,7 +129,30 @@ func (d *DB) UpdateUser(c echo.Context) error {
 
 	err := d.RunTransaction(c.Request().Context(), func(ctx context.Context, tx *firestore.Transaction) error {
 		ref := d.Collection(usersPath).Doc(uid)
-		return tx.Update(ref, requestObj.ToFirestoreUpdate())
+
+		// clients setting the most recent program are
+		// also bumping it in the recent programs list.
+		if pid := requestObj.MostRecentProgram; pid != "" {
+			snap, err := tx.Get(ref)
+			if err != nil {
+				return err
+			}
+			current := User{}
+			if err := snap.DataTo(&current); err != nil {
+				return err
+			}
+			current.OpenedProgram(pid, time.Now().UTC())
+			requestObj.RecentPrograms = current.RecentPrograms
+		}
+
+		// Firestore rejects updates without any fields, but unknown
+		// users should still be reported.
+		up := requestObj.ToFirestoreUpdate()
+		if len(up) == 0 {
+			_, err := tx.Get(ref)
+			return err
+		}
+		return tx.Update(ref, up)
 	})
 	if err != nil {
 		if status.Code(err) == codes.NotFound {
@@ -83,3 +163,54 @@ func (d *DB) UpdateUser(c echo.Context) error {
 
 	return c.String(http.StatusOK, "user updated successfully")
 }
+
+// RecordProgramOpen records pid as the most recently opened of
+// the user uid's programs. Programs the user does not list are
+// ignored. Only the user's recent programs are written.
+func (d *DB) RecordProgramOpen(ctx context.Context, uid, pid string) error {
+	ref := d.Collection(usersPath).Doc(uid)
+	return d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		u := User{}
+		if err := snap.DataTo(&u); err != nil {
+			return err
+		}
+		if !containsString(u.Programs, pid) {
+			return nil
+		}
+
+		u.OpenedProgram(pid, time.Now().UTC())
+		return tx.Update(ref, []firestore.Update{
+			{Path: "mostRecentProgram", Value: u.MostRecentProgram},
+			{Path: "recentPrograms", Value: u.RecentPrograms},
+		})
+	})
+}
+
+// UpdateFolders runs f on the folders of the user uid, keyed by
+// ID, within a transaction, storing them if f succeeds. Only the
+// user's folders are written.
+func (d *DB) UpdateFolders(ctx context.Context, uid string, f func(map[string]Folder) error) error {
+	ref := d.Collection(usersPath).Doc(uid)
+	return d.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		snap, err := tx.Get(ref)
+		if err != nil {
+			return err
+		}
+		u := User{}
+		if err := snap.DataTo(&u); err != nil {
+			return err
+		}
+		if u.Folders == nil {
+			u.Folders = make(map[string]Folder)
+		}
+
+		if err := f(u.Folders); err != nil {
+			return err
+		}
+		return tx.Update(ref, []firestore.Update{{Path: "folders", Value: u.Folders}})
+	})
+}
@@ -6,42 +6,83 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/stretchr/testify/assert"
 )
 
 func TestUserToFirestoreUpdate(t *testing.T) {
+	t.Run("Empty", func(t *testing.T) {
+		u := User{UID: "test"}
+		assert.Empty(t, u.ToFirestoreUpdate())
+	})
 	t.Run("MostRecentProgram", func(t *testing.T) {
 		u := User{MostRecentProgram: "someHash"}
 		update := u.ToFirestoreUpdate()
-		assert.Len(t, update, 1)
+		assert.Len(t, update, 2)
 		assert.Equal(t, "someHash", update[0].Value)
+		assert.Equal(t, "recentPrograms", update[1].Path)
 	})
 	t.Run("DisplayName", func(t *testing.T) {
 		u := User{DisplayName: "test"}
 		update := u.ToFirestoreUpdate()
-		assert.Len(t, update, 2)
-		assert.Equal(t, "mostRecentProgram", update[0].Path)
-		assert.Equal(t, "test", update[1].Value)
+		assert.Len(t, update, 1)
+		assert.Equal(t, "displayName", update[0].Path)
+		assert.Equal(t, "test", update[0].Value)
 	})
 	t.Run("PhotoName", func(t *testing.T) {
 		u := User{PhotoName: "icecream"}
 		update := u.ToFirestoreUpdate()
-		assert.Len(t, update, 2)
-		assert.Equal(t, "icecream", update[1].Value)
+		assert.Len(t, update, 1)
+		assert.Equal(t, "icecream", update[0].Value)
 	})
 	t.Run("Programs", func(t *testing.T) {
 		u := User{Programs: []string{"hash0", "hash1"}}
 		update := u.ToFirestoreUpdate()
-		assert.Len(t, update, 2)
-		assert.Equal(t, "programs", update[1].Path)
+		assert.Len(t, update, 1)
+		assert.Equal(t, "programs", update[0].Path)
 		// TODO: value cannot be easily verified.
 	})
 }
 
+func TestRecentPrograms(t *testing.T) {
+	t.Run("MovesToFront", func(t *testing.T) {
+		u := User{}
+		now := time.Now()
+		u.OpenedProgram("a", now)
+		u.OpenedProgram("b", now.Add(time.Minute))
+		u.OpenedProgram("a", now.Add(2*time.Minute))
+
+		assert.Equal(t, "a", u.MostRecentProgram)
+		assert.Len(t, u.RecentPrograms, 2)
+		assert.Equal(t, "a", u.RecentPrograms[0].PID)
+		assert.Equal(t, now.Add(2*time.Minute), u.RecentPrograms[0].LastOpened)
+		assert.Equal(t, "b", u.RecentPrograms[1].PID)
+	})
+	t.Run("Bounded", func(t *testing.T) {
+		u := User{}
+		for i := 0; i < MaxRecentPrograms+5; i++ {
+			u.OpenedProgram(strconv.Itoa(i), time.Now())
+		}
+		assert.Len(t, u.RecentPrograms, MaxRecentPrograms)
+		assert.Equal(t, strconv.Itoa(MaxRecentPrograms+4), u.MostRecentProgram)
+	})
+	t.Run("Forget", func(t *testing.T) {
+		u := User{}
+		u.OpenedProgram("a", time.Now())
+		u.OpenedProgram("b", time.Now())
+		u.ForgetProgram("b")
+		assert.Equal(t, "a", u.MostRecentProgram)
+		u.ForgetProgram("a")
+		assert.Empty(t, u.MostRecentProgram)
+		assert.Empty(t, u.RecentPrograms)
+	})
+}
+
 func TestUpdateUser(t *testing.T) {
 	d, err := Open(context.Background(), os.Getenv("TLACFG"))
 	if !assert.NoError(t, err) {
@@ -105,6 +146,15 @@ func TestUpdateUser(t *testing.T) {
 			}
 
 		})
+		t.Run("NothingToUpdate", func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "`+u.UID+`"}`))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			if assert.NoError(t, d.UpdateUser(c)) {
+				assert.Equal(t, http.StatusOK, rec.Code)
+			}
+		})
 		t.Run("MostRecentProgram", func(t *testing.T) {}) // TODO
 		t.Run("PhotoName", func(t *testing.T) {})
 		t.Run("Programs", func(t *testing.T) {})
@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// SetAdmission sets how many members a class may have and whether
+// users who join it need an instructor's approval. Members already
+// in the class are kept if it is over capacity. Any instructor of
+// the class may set them.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "capacity": most members the class may have, unlimited if omitted
+//	    "requireApproval": whether joining needs approval
+//	}
+//
+// Returns status 200 OK on success.
+func SetAdmission(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID             string `json:"uid"`
+		CID             string `json:"cid"`
+		Capacity        int    `json:"capacity"`
+		RequireApproval bool   `json:"requireApproval"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	if err := c.SetClassAdmission(c.Request().Context(), req.CID, req.UID, req.Capacity, req.RequireApproval); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to set admission").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// ListJoinRequests returns the users waiting to join a class, oldest
+// first. Only instructors of the class may list them, and TAs, who
+// only see the members of the sections they lead, get none.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the UID, display name and request time
+// of each pending user.
+func ListJoinRequests(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		CID string `json:"cid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can list join requests")
+	}
+
+	type joinRequest struct {
+		UID         string    `json:"uid"`
+		DisplayName string    `json:"displayName"`
+		RequestedAt time.Time `json:"requestedAt"`
+	}
+	requests := make([]joinRequest, 0, len(class.Pending))
+	for _, r := range class.Pending {
+		if !class.CanSee(req.UID, r.UID) {
+			continue
+		}
+		// users whose accounts can no longer be loaded are
+		// listed by UID alone.
+		u, _ := c.LoadUser(c.Request().Context(), r.UID)
+		requests = append(requests, joinRequest{
+			UID:         r.UID,
+			DisplayName: u.DisplayName,
+			RequestedAt: r.RequestedAt,
+		})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"requests": requests})
+}
+
+// ApproveJoin enrolls a user waiting to join a class as a member.
+// Any instructor of the class may approve join requests, as long
+// as the class isn't full.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "user": REQUIRED, the user to approve
+//	}
+//
+// Returns status 200 OK on success.
+func ApproveJoin(cc echo.Context) error {
+	return reviewJoin(cc, true)
+}
+
+// RejectJoin drops the request of a user waiting to join a class.
+// They may ask to join again. Any instructor of the class may
+// reject join requests.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "user": REQUIRED, the user to reject
+//	}
+//
+// Returns status 200 OK on success.
+func RejectJoin(cc echo.Context) error {
+	return reviewJoin(cc, false)
+}
+
+// reviewJoin approves or rejects a join request for ApproveJoin
+// and RejectJoin.
+func reviewJoin(cc echo.Context, approve bool) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID  string `json:"uid"`
+		CID  string `json:"cid"`
+		User string `json:"user"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.User == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and user fields are all required")
+	}
+
+	if approve {
+		if err := c.ApproveJoinRequest(c.Request().Context(), req.CID, req.UID, req.User); err != nil {
+			return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to approve join request").Error())
+		}
+	} else {
+		if err := c.RejectJoinRequest(c.Request().Context(), req.CID, req.UID, req.User); err != nil {
+			return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to reject join request").Error())
+		}
+	}
+	return c.String(http.StatusOK, "")
+}
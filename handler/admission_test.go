@@ -0,0 +1,86 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestClassCapacity(t *testing.T) {
+	d := openAssignmentMock(t)
+	require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "carol"}))
+
+	rec := callHandler(t, d, handler.SetAdmission, `{"uid": "alice", "cid": "test", "capacity": 2}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.SetAdmission, `{"uid": "teacher", "cid": "test", "capacity": -1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = callHandler(t, d, handler.SetAdmission, `{"uid": "teacher", "cid": "test", "capacity": 2}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	assert.Contains(t, rec.Body.String(), "full")
+
+	// instructors and members already in the class aren't turned away.
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "teacher", "wid": "test"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestJoinApproval(t *testing.T) {
+	d := openAssignmentMock(t)
+	for _, uid := range []string{"carol", "dave"} {
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: uid, DisplayName: uid}))
+	}
+
+	rec := callHandler(t, d, handler.SetAdmission, `{"uid": "teacher", "cid": "test", "capacity": 3, "requireApproval": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	for _, uid := range []string{"carol", "dave"} {
+		rec = callHandler(t, d, handler.JoinClass, `{"uid": "`+uid+`", "wid": "test"}`)
+		require.Equal(t, http.StatusAccepted, rec.Code)
+	}
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob"}, class.Members)
+
+	rec = callHandler(t, d, handler.ListJoinRequests, `{"uid": "alice", "cid": "test"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.ListJoinRequests, `{"uid": "teacher", "cid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := struct {
+		Requests []struct {
+			UID         string `json:"uid"`
+			DisplayName string `json:"displayName"`
+		} `json:"requests"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.Len(t, resp.Requests, 2)
+	assert.Equal(t, "carol", resp.Requests[0].DisplayName)
+
+	rec = callHandler(t, d, handler.ApproveJoin, `{"uid": "alice", "cid": "test", "user": "carol"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.ApproveJoin, `{"uid": "teacher", "cid": "test", "user": "carol"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	u, err := d.LoadUser(context.Background(), "carol")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"test"}, u.Classes)
+
+	// the class is now full.
+	rec = callHandler(t, d, handler.ApproveJoin, `{"uid": "teacher", "cid": "test", "user": "dave"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = callHandler(t, d, handler.RejectJoin, `{"uid": "teacher", "cid": "test", "user": "dave"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.RejectJoin, `{"uid": "teacher", "cid": "test", "user": "dave"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	class, err = d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"alice", "bob", "carol"}, class.Members)
+	assert.Empty(t, class.Pending)
+}
@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// defaultFeedSize and maxFeedSize are the default and largest
+	// number of announcements returned per page of a class feed.
+	defaultFeedSize = 20
+	maxFeedSize     = 100
+)
+
+// loadAnnouncementClass loads the announcement id and the class it
+// was posted to, along with whether uid is an instructor of that
+// class. Fails if uid is not in the class.
+func loadAnnouncementClass(c *db.DBContext, id, uid string) (a db.Announcement, class db.Class, isInstructor bool, err error) {
+	if a, err = c.LoadAnnouncement(c.Request().Context(), id); err != nil {
+		return a, class, false, status.Error(codes.NotFound, "announcement does not exist")
+	}
+	if class, err = c.LoadClass(c.Request().Context(), a.CID); err != nil {
+		return a, class, false, status.Error(codes.NotFound, "class does not exist")
+	}
+
+	isIn, isInstructor := classRole(class, uid)
+	if !isIn {
+		return a, class, false, status.Error(codes.InvalidArgument, "given user not in class")
+	}
+	return a, class, isInstructor, nil
+}
+
+// CreateAnnouncement posts an announcement to everyone in a class.
+// Only instructors of the class may post announcements.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "body": REQUIRED
+//	    "pinned": bool
+//	}
+//
+// Returns: Status 201 with the marshalled Announcement.
+func CreateAnnouncement(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		CID    string `json:"cid"`
+		Body   string `json:"body"`
+		Pinned bool   `json:"pinned"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.UID == "" || req.CID == "" || req.Body == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and body fields are all required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can post announcements")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	a, err := c.CreateAnnouncement(c.Request().Context(), db.Announcement{
+		CID:       class.CID,
+		Author:    req.UID,
+		Body:      req.Body,
+		Pinned:    req.Pinned,
+		CreatedAt: time.Now().UTC(),
+		ReadBy:    []string{},
+	})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create announcement").Error())
+	}
+
+	return c.JSON(http.StatusCreated, &a)
+}
+
+// UpdateAnnouncement edits the body of an announcement, or pins or
+// unpins it. Pinned announcements stay at the top of the feed. Only
+// instructors of the class may edit announcements.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED
+//	    "body": new body, unchanged if omitted
+//	    "pinned": bool, unchanged if omitted
+//	}
+//
+// Returns: Status 200 with the marshalled Announcement.
+func UpdateAnnouncement(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		ID     string `json:"id"`
+		Body   string `json:"body"`
+		Pinned *bool  `json:"pinned"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid and id fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAnnouncementClass(c, req.ID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can edit announcements")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	// only the fields changed are written, so that members reading
+	// the announcement meanwhile are kept.
+	fields := []string{}
+	if body := strings.TrimSpace(req.Body); body != "" && body != a.Body {
+		a.Body = body
+		a.EditedAt = time.Now().UTC()
+		fields = append(fields, "body", "editedAt")
+	}
+	if req.Pinned != nil {
+		a.Pinned = *req.Pinned
+		fields = append(fields, "pinned")
+	}
+	if err := c.UpdateAnnouncement(c.Request().Context(), a, fields...); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to update announcement").Error())
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
+
+// DeleteAnnouncement deletes an announcement. Only instructors of
+// the class may delete announcements.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED
+//	}
+//
+// Returns status 200 OK on deletion.
+func DeleteAnnouncement(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		ID  string `json:"id"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid and id fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAnnouncementClass(c, req.ID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can delete announcements")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	if err := c.DeleteAnnouncement(c.Request().Context(), a.ID); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to delete announcement").Error())
+	}
+	return c.String(http.StatusOK, "announcement deleted successfully")
+}
+
+// ListAnnouncements returns a page of a class's announcement feed,
+// pinned announcements first and then the newest, each marked with
+// whether the requester has read it.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "limit": number of announcements per page, 20 if omitted
+//	    "after": ID of the last announcement of the previous page
+//	}
+//
+// Returns: Status 200 with the announcements and the cursor of the
+// next page, which is empty on the last page.
+func ListAnnouncements(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID   string `json:"uid"`
+		CID   string `json:"cid"`
+		Limit int    `json:"limit"`
+		After string `json:"after"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultFeedSize
+	}
+	if req.Limit > maxFeedSize {
+		req.Limit = maxFeedSize
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	isIn, isInstructor := classRole(class, req.UID)
+	if !isIn {
+		return c.String(http.StatusBadRequest, "given user not in class")
+	}
+
+	// ask for one more than a page to know if there is another.
+	feed, err := c.ListAnnouncements(c.Request().Context(), class.CID, req.After, req.Limit+1)
+	if err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to list announcements").Error())
+	}
+	resp := struct {
+		Announcements []db.Announcement `json:"announcements"`
+		Next          string            `json:"next"`
+	}{}
+	if len(feed) > req.Limit {
+		feed = feed[:req.Limit]
+		resp.Next = feed[len(feed)-1].ID
+	}
+	for i := range feed {
+		feed[i] = feed[i].ForUser(req.UID, isInstructor)
+	}
+	resp.Announcements = feed
+
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// ReadAnnouncements marks announcements of a class as read by the
+// requester.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "ids": REQUIRED, IDs of the announcements read, at most a page
+//	}
+//
+// Returns status 200 OK on success.
+func ReadAnnouncements(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string   `json:"uid"`
+		CID string   `json:"cid"`
+		IDs []string `json:"ids"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || len(req.IDs) == 0 {
+		return c.String(http.StatusBadRequest, "uid, cid and ids fields are all required")
+	}
+	if len(req.IDs) > maxFeedSize {
+		return c.String(http.StatusBadRequest, fmt.Sprintf("at most %d announcements can be read at once", maxFeedSize))
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if isIn, _ := classRole(class, req.UID); !isIn {
+		return c.String(http.StatusBadRequest, "given user not in class")
+	}
+
+	if err := c.MarkAnnouncementsRead(c.Request().Context(), class.CID, req.UID, req.IDs); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to mark announcements read").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
@@ -0,0 +1,94 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+type announcementFeed struct {
+	Announcements []db.Announcement `json:"announcements"`
+	Next          string            `json:"next"`
+}
+
+// listAnnouncements returns the feed page ListAnnouncements responds
+// with for body.
+func listAnnouncements(t *testing.T, d db.TLADB, body string) announcementFeed {
+	rec := callHandler(t, d, handler.ListAnnouncements, body)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	feed := announcementFeed{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &feed))
+	return feed
+}
+
+func TestAnnouncements(t *testing.T) {
+	d := openAssignmentMock(t)
+
+	rec := callHandler(t, d, handler.CreateAnnouncement, `{"uid": "alice", "cid": "test", "body": "hi"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.CreateAnnouncement, `{"uid": "teacher", "cid": "test", "body": "  "}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	posted := []db.Announcement{}
+	for _, body := range []string{"first", "second", "third"} {
+		rec = callHandler(t, d, handler.CreateAnnouncement, `{"uid": "teacher", "cid": "test", "body": "`+body+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		a := db.Announcement{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &a))
+		posted = append(posted, a)
+	}
+
+	// pin the first announcement and edit the second.
+	rec = callHandler(t, d, handler.UpdateAnnouncement, `{"uid": "alice", "id": "`+posted[0].ID+`", "pinned": true}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.UpdateAnnouncement, `{"uid": "teacher", "id": "`+posted[0].ID+`", "pinned": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.UpdateAnnouncement, `{"uid": "teacher", "id": "`+posted[1].ID+`", "body": "edited"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = callHandler(t, d, handler.ListAnnouncements, `{"uid": "carol", "cid": "test"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	feed := listAnnouncements(t, d, `{"uid": "alice", "cid": "test", "limit": 2}`)
+	require.Len(t, feed.Announcements, 2)
+	assert.Equal(t, "first", feed.Announcements[0].Body)
+	assert.True(t, feed.Announcements[0].Pinned)
+	assert.False(t, feed.Announcements[0].Read)
+	require.NotEmpty(t, feed.Next)
+
+	feed = listAnnouncements(t, d, `{"uid": "alice", "cid": "test", "limit": 2, "after": "`+feed.Next+`"}`)
+	require.Len(t, feed.Announcements, 1)
+	assert.Empty(t, feed.Next)
+
+	rec = callHandler(t, d, handler.ReadAnnouncements, `{"uid": "alice", "cid": "test", "ids": ["`+posted[0].ID+`"]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.ReadAnnouncements, `{"uid": "alice", "cid": "test", "ids": ["invalid"]}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = callHandler(t, d, handler.ReadAnnouncements, `{"uid": "carol", "cid": "test", "ids": ["`+posted[0].ID+`"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = callHandler(t, d, handler.ReadAnnouncements, `{"uid": "alice", "cid": "test", "ids": ["`+strings.Repeat(posted[0].ID+`", "`, 100)+posted[0].ID+`"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code, "too many IDs")
+
+	feed = listAnnouncements(t, d, `{"uid": "alice", "cid": "test"}`)
+	require.Len(t, feed.Announcements, 3)
+	assert.True(t, feed.Announcements[0].Read)
+	assert.Empty(t, feed.Announcements[0].ReadBy)
+	assert.False(t, feed.Announcements[1].Read)
+
+	// instructors see who has read each announcement.
+	feed = listAnnouncements(t, d, `{"uid": "teacher", "cid": "test"}`)
+	assert.Equal(t, []string{"alice"}, feed.Announcements[0].ReadBy)
+
+	rec = callHandler(t, d, handler.DeleteAnnouncement, `{"uid": "alice", "id": "`+posted[2].ID+`"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.DeleteAnnouncement, `{"uid": "teacher", "id": "`+posted[2].ID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	feed = listAnnouncements(t, d, `{"uid": "bob", "cid": "test"}`)
+	assert.Len(t, feed.Announcements, 2)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// ArchiveClass archives or unarchives a class. Archived classes
+// are read-only: nobody may join them, create programs or
+// assignments in them, or submit to them. They are hidden from
+// class lists unless asked for. Any instructor of the class may
+// archive it.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "archived": REQUIRED bool
+//	}
+//
+// Returns status 200 OK on success.
+func ArchiveClass(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID      string `json:"uid"`
+		CID      string `json:"cid"`
+		Archived *bool  `json:"archived"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.Archived == nil {
+		return c.String(http.StatusBadRequest, "uid, cid and archived fields are all required")
+	}
+
+	if err := c.ArchiveClass(c.Request().Context(), req.CID, req.UID, *req.Archived); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to archive class").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
@@ -0,0 +1,70 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+// getUserClasses returns the class list GetUser responds with for
+// the given query.
+func getUserClasses(t *testing.T, d db.TLADB, query string) []string {
+	req := httptest.NewRequest(http.MethodGet, "/?"+query, nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, handler.GetUser(&db.DBContext{Context: c, TLADB: d}))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	resp := struct {
+		UserData db.User `json:"userData"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	return resp.UserData.Classes
+}
+
+func TestArchiveClass(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	require.NoError(t, d.StoreClass(context.Background(), db.Class{CID: "other", Members: []string{"alice"}}))
+	require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "alice", Classes: []string{"test", "other"}}))
+	require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "carol"}))
+	rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	rec = callHandler(t, d, handler.ArchiveClass, `{"uid": "alice", "cid": "test", "archived": true}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.ArchiveClass, `{"uid": "teacher", "cid": "test", "archived": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	// the class is read-only.
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = callHandler(t, d, handler.SubmitAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = callHandler(t, d, handler.OpenAssignment, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = callHandler(t, d, handler.CreateProgram, `{"uid": "alice", "wid": "test", "program": {"language": "python"}}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// but members can still read it.
+	rec = callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	assert.Equal(t, []string{"other"}, getUserClasses(t, d, "uid=alice"))
+	assert.Equal(t, []string{"test"}, getUserClasses(t, d, "uid=alice&archived=true"))
+	assert.Equal(t, []string{"test", "other"}, getUserClasses(t, d, "uid=alice&archived=all"))
+
+	rec = callHandler(t, d, handler.ArchiveClass, `{"uid": "teacher", "cid": "test", "archived": false}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, []string{"test", "other"}, getUserClasses(t, d, "uid=alice"))
+}
@@ -0,0 +1,508 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loadAssignmentClass loads the assignment aid and the class it
+// belongs to, along with whether uid is an instructor of that class.
+// Fails if uid is not in the class.
+func loadAssignmentClass(c *db.DBContext, aid, uid string) (a db.Assignment, class db.Class, isInstructor bool, err error) {
+	if a, err = c.LoadAssignment(c.Request().Context(), aid); err != nil {
+		return a, class, false, status.Error(codes.NotFound, "assignment does not exist")
+	}
+	if class, err = c.LoadClass(c.Request().Context(), a.CID); err != nil {
+		return a, class, false, status.Error(codes.NotFound, "class does not exist")
+	}
+
+	isIn, isInstructor := classRole(class, uid)
+	if !isIn {
+		return a, class, false, status.Error(codes.InvalidArgument, "given user not in class")
+	}
+	return a, class, isInstructor, nil
+}
+
+// CreateAssignment attaches a new assignment to a class. Only
+// instructors of the class may create assignments, from a starter
+// program that is public or that they can edit.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "title": REQUIRED
+//	    "instructions": string
+//	    "starterProgram": REQUIRED, PID of the program each member starts from
+//	    "dueDate": RFC 3339 timestamp
+//	    "lockDate": RFC 3339 timestamp after which submissions are refused
+//	    "sections": IDs of the sections to list it for, every member if omitted
+//	}
+//
+// Returns: Status 201 with the marshalled Assignment.
+func CreateAssignment(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID            string    `json:"uid"`
+		CID            string    `json:"cid"`
+		Title          string    `json:"title"`
+		Instructions   string    `json:"instructions"`
+		StarterProgram string    `json:"starterProgram"`
+		DueDate        time.Time `json:"dueDate"`
+		LockDate       time.Time `json:"lockDate"`
+		Sections       []string  `json:"sections"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	req.Title = strings.TrimSpace(req.Title)
+	if req.UID == "" || req.CID == "" || req.Title == "" || req.StarterProgram == "" {
+		return c.String(http.StatusBadRequest, "uid, cid, title and starterProgram fields are all required")
+	}
+	if !req.LockDate.IsZero() && req.LockDate.Before(req.DueDate) {
+		return c.String(http.StatusBadRequest, "lockDate cannot be before dueDate")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can create assignments")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+	if err := class.CheckSections(req.Sections); err != nil {
+		return statusError(c, err)
+	}
+	starter, err := c.LoadProgram(c.Request().Context(), req.StarterProgram)
+	if err != nil {
+		return c.String(http.StatusNotFound, "starter program does not exist")
+	}
+	starter.UID = req.StarterProgram
+	user, err := c.LoadUser(c.Request().Context(), req.UID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "user does not exist")
+	}
+	user.UID = req.UID
+	if !starter.Public && !starter.CanEdit(user) {
+		return c.String(http.StatusForbidden, "starter program must be public or editable by the instructor")
+	}
+
+	a, err := c.CreateAssignment(c.Request().Context(), db.Assignment{
+		CID:            class.CID,
+		Title:          req.Title,
+		Instructions:   req.Instructions,
+		StarterProgram: req.StarterProgram,
+		DueDate:        req.DueDate,
+		LockDate:       req.LockDate,
+		Creator:        req.UID,
+		DateCreated:    time.Now().UTC(),
+		Copies:         make(map[string]string),
+		Sections:       req.Sections,
+	})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create assignment").Error())
+	}
+
+	if err := c.AddClassAssignment(c.Request().Context(), class.CID, a.AID); err != nil {
+		// don't leave the assignment behind without a class.
+		if err := c.DeleteAssignment(c.Request().Context(), a.AID); err != nil {
+			c.Logger().Warnf("Failed to delete unlisted assignment `%s`: %v", a.AID, err)
+		}
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to add assignment to class").Error())
+	}
+
+	return c.JSON(http.StatusCreated, &a)
+}
+
+// UpdateAssignment changes the title, instructions, due date, lock
+// date or sections of an assignment. Only instructors of the class
+// may do so.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "title": new title, unchanged if omitted
+//	    "instructions": new instructions, unchanged if omitted
+//	    "dueDate": new due date, unchanged if omitted
+//	    "lockDate": new lock date, unchanged if omitted
+//	    "sections": new section IDs, unchanged if omitted
+//	}
+//
+// Returns: Status 200 with the marshalled Assignment.
+func UpdateAssignment(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID          string    `json:"uid"`
+		AID          string    `json:"aid"`
+		Title        string    `json:"title"`
+		Instructions string    `json:"instructions"`
+		DueDate      time.Time `json:"dueDate"`
+		LockDate     time.Time `json:"lockDate"`
+		Sections     []string  `json:"sections"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can update assignments")
+	}
+	if err := class.CheckSections(req.Sections); err != nil {
+		return statusError(c, err)
+	}
+
+	fields := []string{}
+	if title := strings.TrimSpace(req.Title); title != "" {
+		a.Title = title
+		fields = append(fields, "title")
+	}
+	if req.Instructions != "" {
+		a.Instructions = req.Instructions
+		fields = append(fields, "instructions")
+	}
+	if !req.DueDate.IsZero() {
+		a.DueDate = req.DueDate
+		fields = append(fields, "dueDate")
+	}
+	if !req.LockDate.IsZero() {
+		a.LockDate = req.LockDate
+		fields = append(fields, "lockDate")
+	}
+	if req.Sections != nil {
+		a.Sections = req.Sections
+		fields = append(fields, "sections")
+	}
+	if !a.LockDate.IsZero() && a.LockDate.Before(a.DueDate) {
+		return c.String(http.StatusBadRequest, "lockDate cannot be before dueDate")
+	}
+
+	// only the fields changed are written, so that copies linked
+	// by members opening the assignment meanwhile are kept.
+	if err := c.UpdateAssignment(c.Request().Context(), a, fields...); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to update assignment").Error())
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
+
+// DeleteAssignment removes an assignment from its class. Members'
+// copies are left in place as ordinary programs.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 on deletion.
+func DeleteAssignment(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can delete assignments")
+	}
+
+	if err := c.RemoveClassAssignment(c.Request().Context(), class.CID, a.AID); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to remove assignment from class").Error())
+	}
+	if err := c.DeleteAssignment(c.Request().Context(), a.AID); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to delete assignment").Error())
+	}
+
+	return c.String(http.StatusOK, "")
+}
+
+// GetAssignment returns a single assignment. Members of the class
+// only see which copy is their own, and only assignments for their
+// section; instructors see every copy.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the marshalled Assignment.
+func GetAssignment(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor && !a.IsFor(class.SectionsOf(req.UID)) {
+		return c.String(http.StatusNotFound, "assignment does not exist")
+	}
+	if !isInstructor {
+		a = a.ForMember(req.UID)
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
+
+// ListAssignments returns the assignments of a class, in the
+// order they were created. Members only see the assignments listed
+// for their section, and instructors may ask for those of a given
+// section.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "section": ID of the section to list assignments for
+//	}
+//
+// Returns: Status 200 with an array of marshalled Assignments.
+func ListAssignments(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string `json:"uid"`
+		CID     string `json:"cid"`
+		Section string `json:"section"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	isIn, isInstructor := classRole(class, req.UID)
+	if !isIn {
+		return c.String(http.StatusBadRequest, "given user not in class")
+	}
+
+	// nil lists every assignment.
+	var sections []string
+	switch {
+	case !isInstructor:
+		sections = class.SectionsOf(req.UID)
+	case req.Section != "":
+		if err := class.CheckSections([]string{req.Section}); err != nil {
+			return statusError(c, err)
+		}
+		sections = []string{req.Section}
+	}
+
+	assignments := make([]db.Assignment, 0, len(class.Assignments))
+	for _, aid := range class.Assignments {
+		a, err := c.LoadAssignment(c.Request().Context(), aid)
+		if err != nil {
+			c.Logger().Warnf("Failed to load assignment with aid `%s` for class with cid `%s`", aid, class.CID)
+			continue
+		}
+		if sections != nil && !a.IsFor(sections) {
+			continue
+		}
+		if !isInstructor {
+			a = a.ForMember(req.UID)
+		}
+		assignments = append(assignments, a)
+	}
+
+	return c.JSON(http.StatusOK, assignments)
+}
+
+// OpenAssignment returns the requesting member's copy of an
+// assignment for their section, creating it from the starter
+// program on first open.
+// The copy is added to the member's programs and tagged with the
+// class WID and the assignment's AID. Each member's copy has a
+// fixed PID, so opening an assignment twice at once makes one copy.
+// The copy is stored, listed for the member and linked to the
+// assignment as separate writes, none of which undoes the others,
+// so an open cut short is finished by opening again. A copy which
+// can't be read is never replaced, since it may hold the member's
+// work.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the marshalled copy, or status 201 if
+// the copy was just created.
+func OpenAssignment(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor && !a.IsFor(class.SectionsOf(req.UID)) {
+		return c.String(http.StatusNotFound, "assignment does not exist")
+	}
+
+	if pid, ok := a.Copies[req.UID]; ok {
+		p, err := c.LoadProgram(c.Request().Context(), pid)
+		switch {
+		case err == nil:
+			return c.JSON(http.StatusOK, &p)
+		case status.Code(err) != codes.NotFound:
+			return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to load copy").Error())
+		}
+		// the copy has been deleted, so start over.
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	// a concurrent open may have made the copy without linking it
+	// yet, in which case it is kept rather than reset.
+	pid := a.CopyID(req.UID)
+	p, err := c.LoadProgram(c.Request().Context(), pid)
+	switch {
+	case status.Code(err) == codes.NotFound:
+		starter, err := c.LoadProgram(c.Request().Context(), a.StarterProgram)
+		if err != nil {
+			return c.String(http.StatusNotFound, "starter program does not exist")
+		}
+		p = a.CopyFor(starter, req.UID, class.WID)
+		p.UID = pid
+		if err := c.StoreProgram(c.Request().Context(), p); err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to copy starter program").Error())
+		}
+	case err != nil:
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to load copy").Error())
+	}
+	p.UID = pid
+
+	if err := c.AddUserProgram(c.Request().Context(), req.UID, pid); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to add copy to user").Error())
+	}
+	if err := c.LinkAssignmentCopy(c.Request().Context(), a.AID, req.UID, pid); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to link copy to assignment").Error())
+	}
+
+	return c.JSON(http.StatusCreated, &p)
+}
+
+// GetAssignmentCopies returns every member's copy of an assignment.
+// Only instructors of the class may list copies, and TAs only see
+// the members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with a map of member UIDs to their copies and
+// the UIDs of members who have not opened the assignment yet.
+func GetAssignmentCopies(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can list assignment copies")
+	}
+	members, err := class.MembersVisibleTo(req.UID, "")
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	resp := struct {
+		Copies     map[string]db.Program `json:"copies"`
+		NotStarted []string              `json:"notStarted"`
+	}{
+		Copies:     make(map[string]db.Program),
+		NotStarted: []string{},
+	}
+	partial := false
+	for _, uid := range members {
+		pid, ok := a.Copies[uid]
+		if !ok {
+			resp.NotStarted = append(resp.NotStarted, uid)
+			continue
+		}
+		p, err := c.LoadProgram(c.Request().Context(), pid)
+		if err != nil {
+			partial = true
+			continue
+		}
+		resp.Copies[uid] = p
+	}
+
+	if partial {
+		return c.JSON(http.StatusPartialContent, &resp)
+	}
+	return c.JSON(http.StatusOK, &resp)
+}
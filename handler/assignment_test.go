@@ -0,0 +1,267 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// openAssignmentMock returns a MockDB holding a class "test" created
+// by the instructor "teacher", with members "alice" and "bob", and a starter
+// program "starter".
+func openAssignmentMock(t *testing.T) *db.MockDB {
+	d := db.OpenMock()
+	require.NoError(t, d.StoreClass(context.Background(), db.Class{
+		CID:         "test",
+		WID:         "test",
+		Creator:     "teacher",
+		Instructors: []string{"teacher"},
+		Members:     []string{"alice", "bob"},
+	}))
+	for _, uid := range []string{"teacher", "alice", "bob"} {
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: uid}))
+	}
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{
+		UID:      "starter",
+		Code:     "import turtle",
+		Language: "python",
+		Owner:    "teacher",
+	}))
+	return d
+}
+
+// callHandler invokes h with the given body against d, returning
+// the recorded response.
+func callHandler(t *testing.T, d db.TLADB, h echo.HandlerFunc, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body))
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, h(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	}))
+	return rec
+}
+
+// createTestAssignment creates an assignment in the class "test"
+// of a MockDB from openAssignmentMock.
+func createTestAssignment(t *testing.T, d *db.MockDB) db.Assignment {
+	rec := callHandler(t, d, handler.CreateAssignment, `{"uid": "teacher", "cid": "test", "title": "Spirals", "starterProgram": "starter", "dueDate": "2030-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	a := db.Assignment{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &a))
+	return a
+}
+
+func TestCreateAssignment(t *testing.T) {
+	t.Run("MissingFields", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.CreateAssignment, `{"uid": "teacher", "cid": "test"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("NotInstructor", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.CreateAssignment, `{"uid": "alice", "cid": "test", "title": "Spirals", "starterProgram": "starter"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("StarterDNE", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.CreateAssignment, `{"uid": "teacher", "cid": "test", "title": "Spirals", "starterProgram": "nope"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("StarterNotEditable", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "private", Code: "secret", Owner: "alice"}))
+		rec := callHandler(t, d, handler.CreateAssignment, `{"uid": "teacher", "cid": "test", "title": "Spirals", "starterProgram": "private"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		assert.Equal(t, "Spirals", a.Title)
+		assert.Equal(t, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), a.DueDate.UTC())
+
+		class, err := d.LoadClass(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{a.AID}, class.Assignments)
+	})
+}
+
+func TestUpdateAssignment(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+
+	rec := callHandler(t, d, handler.UpdateAssignment, `{"uid": "alice", "aid": "`+a.AID+`", "title": "Squares"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.UpdateAssignment, `{"uid": "teacher", "aid": "`+a.AID+`", "title": "Squares"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	updated, err := d.LoadAssignment(context.Background(), a.AID)
+	require.NoError(t, err)
+	assert.Equal(t, "Squares", updated.Title)
+	assert.Equal(t, a.DueDate.UTC(), updated.DueDate.UTC())
+}
+
+func TestDeleteAssignment(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+
+	rec := callHandler(t, d, handler.DeleteAssignment, `{"uid": "teacher", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	_, err := d.LoadAssignment(context.Background(), a.AID)
+	assert.Error(t, err)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Empty(t, class.Assignments)
+}
+
+func TestOpenAssignment(t *testing.T) {
+	t.Run("NotInClass", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "outsider", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("AssignmentDNE", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "nope"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("CopiesOnce", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		first := db.Program{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &first))
+		assert.Equal(t, "import turtle", first.Code)
+		assert.Equal(t, "Spirals", first.Name)
+		assert.Equal(t, "alice", first.Owner)
+		assert.Equal(t, a.AID, first.Assignment)
+		assert.Equal(t, "test", first.WID)
+
+		rec = callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		second := db.Program{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &second))
+		assert.Equal(t, first.UID, second.UID)
+
+		u, err := d.LoadUser(context.Background(), "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []string{first.UID}, u.Programs)
+		class, err := d.LoadClass(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Empty(t, class.Programs, "copies are hidden from the rest of the class")
+	})
+	t.Run("ConcurrentOpen", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+
+		// another open has made and listed the copy, but not yet
+		// linked it to the assignment.
+		pid := a.CopyID("alice")
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: pid, Code: "edited", Owner: "alice"}))
+		require.NoError(t, d.AddUserProgram(context.Background(), "alice", pid))
+
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		p := db.Program{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+		assert.Equal(t, pid, p.UID)
+		assert.Equal(t, "edited", p.Code)
+
+		u, err := d.LoadUser(context.Background(), "alice")
+		require.NoError(t, err)
+		assert.Equal(t, []string{pid}, u.Programs)
+		a, err = d.LoadAssignment(context.Background(), a.AID)
+		require.NoError(t, err)
+		assert.Equal(t, pid, a.Copies["alice"])
+	})
+	t.Run("CopyUnavailable", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		pid := a.CopyID("alice")
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: pid, Code: "edited", Owner: "alice"}))
+
+		unavailable := &failingProgramDB{MockDB: d, pid: pid}
+		rec := callHandler(t, unavailable, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+		require.NoError(t, d.LinkAssignmentCopy(context.Background(), a.AID, "alice", pid))
+		rec = callHandler(t, unavailable, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+		p, err := d.LoadProgram(context.Background(), pid)
+		require.NoError(t, err)
+		assert.Equal(t, "edited", p.Code, "the copy is kept")
+	})
+}
+
+// failingProgramDB is a MockDB which can't load the program pid.
+type failingProgramDB struct {
+	*db.MockDB
+	pid string
+}
+
+func (d *failingProgramDB) LoadProgram(ctx context.Context, pid string) (db.Program, error) {
+	if pid == d.pid {
+		return db.Program{}, status.Error(codes.Unavailable, "try again later")
+	}
+	return d.MockDB.LoadProgram(ctx, pid)
+}
+
+func TestGetAssignment(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	a.Tests = []db.TestCase{{ID: "hidden", Input: "1\n", Expected: "2"}}
+	require.NoError(t, d.StoreAssignment(context.Background(), a))
+	require.Equal(t, http.StatusCreated, callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`).Code)
+	require.Equal(t, http.StatusCreated, callHandler(t, d, handler.OpenAssignment, `{"uid": "bob", "aid": "`+a.AID+`"}`).Code)
+
+	rec := callHandler(t, d, handler.GetAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	got := db.Assignment{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Len(t, got.Copies, 1)
+	assert.Contains(t, got.Copies, "alice")
+	assert.Empty(t, got.Tests)
+
+	rec = callHandler(t, d, handler.ListAssignments, `{"uid": "teacher", "cid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	list := []db.Assignment{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &list))
+	require.Len(t, list, 1)
+	assert.Len(t, list[0].Copies, 2)
+	assert.Len(t, list[0].Tests, 1)
+}
+
+func TestGetAssignmentCopies(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	require.Equal(t, http.StatusCreated, callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`).Code)
+
+	rec := callHandler(t, d, handler.GetAssignmentCopies, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.GetAssignmentCopies, `{"uid": "teacher", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := struct {
+		Copies     map[string]db.Program `json:"copies"`
+		NotStarted []string              `json:"notStarted"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Copies, "alice")
+	assert.Equal(t, []string{"bob"}, resp.NotStarted)
+}
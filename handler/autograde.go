@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/autograder"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetTestCases replaces the autograder test cases of an assignment.
+// Test cases without an ID are given one. Only instructors of the
+// class may set test cases.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "tests": REQUIRED array of {"id", "name", "input", "expected"}
+//	}
+//
+// Returns: Status 200 with the marshalled Assignment.
+func SetTestCases(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID   string        `json:"uid"`
+		AID   string        `json:"aid"`
+		Tests []db.TestCase `json:"tests"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" || req.Tests == nil {
+		return c.String(http.StatusBadRequest, "uid, aid and tests fields are all required")
+	}
+
+	seen := make(map[string]bool, len(req.Tests))
+	for i := range req.Tests {
+		t := &req.Tests[i]
+		t.Name = strings.TrimSpace(t.Name)
+		if t.ID == "" {
+			t.ID = uuid.New().String()
+		}
+		if seen[t.ID] {
+			return c.String(http.StatusBadRequest, "test case IDs must be unique")
+		}
+		seen[t.ID] = true
+	}
+
+	a, _, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can set test cases")
+	}
+
+	a.Tests = req.Tests
+	if err := c.UpdateAssignment(c.Request().Context(), a, "tests"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to set test cases").Error())
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
+
+// AutogradeSubmission returns a handler that runs a member's
+// submission against the assignment's test cases with r, storing
+// the results on the submission. Members may autograde their own
+// submission; instructors may autograde any member's, and TAs
+// those of the members of the sections they lead. Only a few
+// submissions are autograded at once, and each user may autograde
+// a given submission only once per autograder.DefaultCooldown.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "student": UID of the member whose submission to run, uid if omitted
+//	}
+//
+// Returns: Status 200 with the marshalled Submission, status 409 if
+// the member resubmitted while the tests ran, status 429 if the
+// user autograded the submission too recently, or status 503 if
+// too many submissions are being autograded.
+func AutogradeSubmission(r autograder.Runner) echo.HandlerFunc {
+	throttle := autograder.NewThrottle(autograder.DefaultConcurrency, autograder.DefaultCooldown)
+	return func(cc echo.Context) error {
+		c := cc.(*db.DBContext)
+		var req struct {
+			UID     string `json:"uid"`
+			AID     string `json:"aid"`
+			Student string `json:"student"`
+		}
+		if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+		}
+		if req.UID == "" || req.AID == "" {
+			return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+		}
+		if req.Student == "" {
+			req.Student = req.UID
+		}
+
+		a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+		if err != nil {
+			return statusError(c, err)
+		}
+		if req.Student != req.UID && !isInstructor {
+			return c.String(http.StatusForbidden, "only instructors can autograde other members' submissions")
+		}
+		if !class.CanSee(req.UID, req.Student) {
+			return c.String(http.StatusForbidden, "TAs can only autograde submissions from their sections")
+		}
+		if len(a.Tests) == 0 {
+			return c.String(http.StatusConflict, "assignment has no test cases")
+		}
+
+		s, err := c.LoadSubmission(c.Request().Context(), a.AID, req.Student)
+		if err != nil {
+			return c.String(http.StatusNotFound, "submission does not exist")
+		}
+
+		release, err := throttle.Acquire(req.UID + "\x00" + req.Student)
+		switch {
+		case errors.Is(err, autograder.ErrTooSoon):
+			return c.String(http.StatusTooManyRequests, "submission was autograded too recently")
+		case errors.Is(err, autograder.ErrBusy):
+			return c.String(http.StatusServiceUnavailable, "too many submissions are being autograded")
+		}
+		results, err := r.Run(c.Request().Context(), s.Snapshot, a.Tests)
+		release()
+		switch {
+		case errors.Is(err, autograder.ErrUnsupportedLanguage):
+			return c.String(http.StatusBadRequest, "submissions in "+s.Snapshot.Language+" cannot be autograded")
+		case err != nil:
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to run tests").Error())
+		}
+
+		// only the results are written, and not at all if the member
+		// resubmitted while the tests ran.
+		s.TestResults = results
+		s.AutogradedAt = time.Now().UTC()
+		if s, err = c.UpdateSubmission(c.Request().Context(), s, "testResults", "autogradedAt"); err != nil {
+			if status.Code(err) == codes.FailedPrecondition {
+				return c.String(http.StatusConflict, "submission was resubmitted while being autograded")
+			}
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to store test results").Error())
+		}
+		if !isInstructor && !a.GradesReleased {
+			s.Grade = nil
+		}
+
+		return c.JSON(http.StatusOK, &s)
+	}
+}
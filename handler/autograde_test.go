@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/autograder"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestSetTestCases(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+
+	rec := callHandler(t, d, handler.SetTestCases, `{"uid": "alice", "aid": "`+a.AID+`", "tests": []}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.SetTestCases, `{"uid": "teacher", "aid": "`+a.AID+`", "tests": [{"id": "a"}, {"id": "a"}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = callHandler(t, d, handler.SetTestCases, `{"uid": "teacher", "aid": "`+a.AID+`", "tests": [{"name": "doubles", "input": "2", "expected": "4"}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	a, err := d.LoadAssignment(context.Background(), a.AID)
+	require.NoError(t, err)
+	require.Len(t, a.Tests, 1)
+	assert.NotEmpty(t, a.Tests[0].ID)
+}
+
+func TestAutogradeSubmission(t *testing.T) {
+	setup := func(t *testing.T) (*db.MockDB, db.Assignment) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		a.Tests = []db.TestCase{{ID: "one", Expected: "1"}, {ID: "two", Expected: "2"}}
+		require.NoError(t, d.StoreAssignment(context.Background(), a))
+		require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{
+			AID:      a.AID,
+			UID:      "alice",
+			Snapshot: db.Program{Language: "python", Code: "print(1)"},
+		}))
+		return d, a
+	}
+
+	t.Run("OtherMember", func(t *testing.T) {
+		d, a := setup(t)
+		rec := callHandler(t, d, handler.AutogradeSubmission(&autograder.FakeRunner{}), `{"uid": "bob", "aid": "`+a.AID+`", "student": "alice"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("Unsupported", func(t *testing.T) {
+		d, a := setup(t)
+		r := &autograder.FakeRunner{Err: autograder.ErrUnsupportedLanguage}
+		rec := callHandler(t, d, handler.AutogradeSubmission(r), `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		d, a := setup(t)
+		r := &autograder.FakeRunner{Outputs: map[string]string{"one": "1\n", "two": "1\n"}}
+		rec := callHandler(t, d, handler.AutogradeSubmission(r), `{"uid": "teacher", "aid": "`+a.AID+`", "student": "alice"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.Len(t, r.Runs, 1)
+		assert.Equal(t, "print(1)", r.Runs[0].Code)
+
+		s := db.Submission{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+		require.Len(t, s.TestResults, 2)
+		assert.True(t, s.TestResults[0].Passed)
+		assert.False(t, s.TestResults[1].Passed)
+
+		stored, err := d.LoadSubmission(context.Background(), a.AID, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, s.TestResults, stored.TestResults)
+		assert.False(t, stored.AutogradedAt.IsZero())
+	})
+	t.Run("TooSoon", func(t *testing.T) {
+		d, a := setup(t)
+		h := handler.AutogradeSubmission(&autograder.FakeRunner{})
+		rec := callHandler(t, d, h, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		rec = callHandler(t, d, h, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+
+		// others' cooldowns are their own.
+		rec = callHandler(t, d, h, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "alice"}`)
+		assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	})
+}
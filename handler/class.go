@@ -68,11 +68,15 @@ func GetClassMembers(cc echo.Context) error {
 // GetClass takes the UID (either of a member or an instructor)
 // and a CID (wid) as a JSON, and returns an object representing the class.
 // If the given UID is not a member or an instructor, an error is returned.
+// An optional section ID limits the members returned to that section, and
+// TAs only ever see the members of the sections they lead, and their
+// programs.
 func GetClass(cc echo.Context) error {
 	var (
 		req struct {
-			UID string `json:"uid"`
-			CID string `json:"cid"`
+			UID     string `json:"uid"`
+			CID     string `json:"cid"`
+			Section string `json:"section"`
 		}
 		res struct {
 			*db.Class
@@ -119,6 +123,13 @@ func GetClass(cc echo.Context) error {
 	if !isIn {
 		return c.String(http.StatusBadRequest, "given user not in class")
 	}
+	if class.Members, err = class.MembersVisibleTo(req.UID, req.Section); err != nil {
+		return statusError(c, err)
+	}
+	class = class.ForTA(req.UID)
+	if !isInstructor {
+		class = class.ForMember()
+	}
 
 	// If program data is requested.
 	partial := false
@@ -128,6 +139,11 @@ func GetClass(cc echo.Context) error {
 			if err != nil {
 				partial = true
 			}
+			// TAs only see the programs of their sections' members
+			// and of the instructors.
+			if err == nil && !class.IsInstructor(program.Owner) && !class.CanSee(req.UID, program.Owner) {
+				continue
+			}
 			res.ProgramData = append(res.ProgramData, program)
 		}
 	}
@@ -214,18 +230,13 @@ func addClassToUser(u *db.User, cid string) {
 	(*u).Classes = append((*u).Classes, cid)
 }
 
-func addUserToClass(uid string, c *db.Class) {
-	for _, user := range (*c).Members {
-		if user == uid {
-			return
-		}
-	}
-	(*c).Members = append((*c).Members, uid)
-}
-
 // JoinClass takes a UID and cid(wid) as a JSON, and attempts to
 // add the UID to the class given by cid. The updated struct of the class is returned as a
-// JSON
+// JSON. Joining fails if the WID has been retired, has expired or
+// been used up, if joining the class is paused, if the class is
+// full, or if the user is banned from the class. If joining the
+// class needs approval, status 202 is returned and the user waits
+// until an instructor approves them.
 func JoinClass(cc echo.Context) error {
 	req := struct {
 		UID string `json:"uid"`
@@ -245,31 +256,39 @@ func JoinClass(cc echo.Context) error {
 		return c.String(http.StatusBadRequest, "wid is required")
 	}
 
-	// get the class as a struct
-	class, err := c.LoadClass(c.Request().Context(), req.WID)
+	// resolve the WID to the class it currently belongs to.
+	cid, err := c.GetUIDFromWID(c.Request().Context(), req.WID, db.ClassesAliasPath)
 	if err != nil {
 		return c.String(http.StatusNotFound, "class does not exist")
 	}
 
-	// check if user exists
-	user, err := c.LoadUser(c.Request().Context(), req.UID)
+	// add user to the class, and the class to the user's
+	// "Classes" list, if the class's join code allows it.
+	class, err := c.JoinClass(c.Request().Context(), cid, req.WID, req.UID)
 	if err != nil {
-		return c.String(http.StatusNotFound, "user does not exist")
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "Failed to add user to class").Error())
 	}
 
-	// add user to the class
-	addUserToClass(user.UID, &class)
-	err = c.StoreClass(c.Request().Context(), class)
-	if err != nil {
-		return c.String(http.StatusInternalServerError, errors.Wrap(err, "Failed to add user to class").Error())
+	// the user is waiting for an instructor's approval.
+	if class.IsPending(req.UID) {
+		return c.JSON(http.StatusAccepted, class.ForMember())
 	}
+	return c.JSON(http.StatusOK, class.ForMember())
+}
 
-	// add this class to the user's "Classes" list
-	addClassToUser(&user, class.CID)
-	err = c.StoreUser(c.Request().Context(), user)
-	if err != nil {
-		return c.String(http.StatusInternalServerError, errors.Wrap(err, "Failed to add user to class").Error())
+// classRole reports whether uid belongs to the class, either
+// as a member or as an instructor, and whether uid is an
+// instructor of the class.
+func classRole(class db.Class, uid string) (isIn, isInstructor bool) {
+	for _, i := range class.Instructors {
+		if i == uid {
+			return true, true
+		}
 	}
-
-	return c.JSON(http.StatusOK, class)
+	for _, m := range class.Members {
+		if m == uid {
+			return true, false
+		}
+	}
+	return false, false
 }
@@ -401,6 +401,7 @@ func TestJoinClass(t *testing.T) {
 		d := db.OpenMock()
 		require.NoError(t, d.StoreClass(context.Background(), db.Class{
 			CID: "test",
+			WID: "test",
 		}))
 		require.NoError(t, d.StoreUser(context.Background(), db.User{
 			UID: "test",
@@ -456,6 +457,7 @@ func TestJoinClass(t *testing.T) {
 		d := db.OpenMock()
 		require.NoError(t, d.StoreClass(context.Background(), db.Class{
 			CID: "test",
+			WID: "test",
 		}))
 		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test", "wid": "test"}`))
 		rec := httptest.NewRecorder()
@@ -504,6 +506,7 @@ func TestJoinClass(t *testing.T) {
 		d := db.OpenMock()
 		require.NoError(t, d.StoreClass(context.Background(), db.Class{
 			CID:     "test",
+			WID:     "test",
 			Members: []string{"test"},
 		}))
 		require.NoError(t, d.StoreUser(context.Background(), db.User{
@@ -542,6 +545,7 @@ func TestJoinClass(t *testing.T) {
 		d := db.OpenMock()
 		require.NoError(t, d.StoreClass(context.Background(), db.Class{
 			CID: "test",
+			WID: "test",
 		}))
 		require.NoError(t, d.StoreUser(context.Background(), db.User{
 			UID:     "test",
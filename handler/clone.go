@@ -0,0 +1,190 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// CloneClass creates a new class for the next term from an
+// existing one, with a fresh WID. The description, thumbnail,
+// instructors and assignments are copied, along with a copy owned
+// by the requester of each of the class's templates, the programs
+// its instructors added to it, and of each assignment's starter
+// program. Due and lock dates are moved forward by the given
+// offset. Members and their programs are not copied. If cloning
+// fails partway through, nothing of the new class is kept. Any
+// instructor of the class may clone it, including once it has
+// been archived.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED, the class to clone
+//	    "name": name of the new class, the same as the old one if omitted
+//	    "offsetDays": number of days to move due dates by
+//	}
+//
+// Returns: Status 201 with the marshalled Class.
+func CloneClass(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID        string `json:"uid"`
+		CID        string `json:"cid"`
+		Name       string `json:"name"`
+		OffsetDays int    `json:"offsetDays"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	src, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(src, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can clone a class")
+	}
+	if _, err := c.LoadUser(c.Request().Context(), req.UID); err != nil {
+		return c.String(http.StatusNotFound, "user does not exist")
+	}
+
+	// everything copied is read before the new class is made, so
+	// that a missing program doesn't leave half a class behind.
+	assignments := make([]db.Assignment, 0, len(src.Assignments))
+	for _, aid := range src.Assignments {
+		a, err := c.LoadAssignment(c.Request().Context(), aid)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load assignment").Error())
+		}
+		assignments = append(assignments, a)
+	}
+	programs, err := c.LoadPrograms(c.Request().Context(), src.Programs)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load class programs").Error())
+	}
+	templates := []string{}
+	for _, pid := range src.Programs {
+		if p, ok := programs[pid]; ok && p.IsTemplateOf(src) {
+			templates = append(templates, pid)
+		}
+	}
+	for _, a := range assignments {
+		if _, ok := programs[a.StarterProgram]; ok {
+			continue
+		}
+		p, err := c.LoadProgram(c.Request().Context(), a.StarterProgram)
+		if err != nil {
+			return c.String(http.StatusNotFound, "starter program of "+a.Title+" does not exist")
+		}
+		programs[a.StarterProgram] = p
+	}
+
+	now := time.Now().UTC()
+	class, err := c.AddClass(c.Request().Context(), src.CloneFor(req.UID, req.Name, now))
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create class").Error())
+	}
+
+	// copies maps the PID of each program copied to the new class
+	// to its copy, so that a program several assignments start
+	// from is copied once. cloned lists the copies in the order
+	// they were made.
+	copies := make(map[string]string)
+	cloned := []string{}
+
+	// added lists the users the new class has been added to.
+	added := []string{}
+
+	// fail removes whatever was made of the new class before
+	// responding with msg.
+	fail := func(err error, msg string) error {
+		ctx := c.Request().Context()
+		for _, uid := range added {
+			_ = c.RemoveUserClass(ctx, uid, class.CID, cloned)
+		}
+		for _, aid := range class.Assignments {
+			_ = c.DeleteAssignment(ctx, aid)
+		}
+		for _, pid := range copies {
+			_ = c.RemoveProgram(ctx, pid)
+		}
+		if class.WID != "" {
+			_ = c.DeleteAlias(ctx, class.WID, db.ClassesAliasPath)
+		}
+		_ = c.DeleteClass(ctx, class.CID)
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, msg).Error())
+	}
+
+	if class.WID, err = c.MakeAlias(c.Request().Context(), class.CID, db.ClassesAliasPath); err != nil {
+		return fail(err, "failed to create class alias")
+	}
+
+	copyProgram := func(pid string) (string, error) {
+		if cp, ok := copies[pid]; ok {
+			return cp, nil
+		}
+		p, err := c.CreateProgram(c.Request().Context(), programs[pid].CloneFor(req.UID, class.WID))
+		if err != nil {
+			return "", err
+		}
+		copies[pid] = p.UID
+		cloned = append(cloned, p.UID)
+		return p.UID, nil
+	}
+	for _, pid := range templates {
+		cp, err := copyProgram(pid)
+		if err != nil {
+			return fail(err, "failed to copy class program")
+		}
+		class.Programs = append(class.Programs, cp)
+	}
+
+	offset := time.Duration(req.OffsetDays) * 24 * time.Hour
+	for _, a := range assignments {
+		starter, err := copyProgram(a.StarterProgram)
+		if err != nil {
+			return fail(err, "failed to copy starter program")
+		}
+		a, err = c.CreateAssignment(c.Request().Context(), a.CloneFor(class.CID, starter, req.UID, offset, now))
+		if err != nil {
+			return fail(err, "failed to copy assignment")
+		}
+		class.Assignments = append(class.Assignments, a.AID)
+	}
+
+	// the class is added to each instructor's classes, and the
+	// copies to the cloner's programs, before the class is stored,
+	// so that a failure anywhere can be undone. Instructors whose
+	// accounts have since been deleted are skipped.
+	for _, uid := range class.Instructors {
+		var pids []string
+		if uid == req.UID {
+			pids = cloned
+		}
+		err := c.AddUserClass(c.Request().Context(), uid, class.CID, pids)
+		switch {
+		case status.Code(err) == codes.NotFound && uid != req.UID:
+			continue
+		case err != nil:
+			return fail(err, "failed to add class to instructor")
+		}
+		added = append(added, uid)
+	}
+
+	if err := c.StoreClass(c.Request().Context(), class); err != nil {
+		return fail(err, "failed to store class")
+	}
+
+	return c.JSON(http.StatusCreated, &class)
+}
@@ -0,0 +1,97 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestCloneClass(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	// the teacher's template is copied, but not alice's program.
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "template", Code: "print('hi')", Owner: "teacher", WID: "test"}))
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "alices", Code: "print('mine')", Owner: "alice", WID: "test"}))
+	src, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	src.Programs = []string{"template", "alices"}
+	require.NoError(t, d.StoreClass(context.Background(), src))
+
+	rec = callHandler(t, d, handler.CloneClass, `{"uid": "alice", "cid": "test"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.CloneClass, `{"uid": "teacher", "cid": "test", "name": "Fall", "offsetDays": 7}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	class := db.Class{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &class))
+	assert.NotEqual(t, "test", class.CID)
+	assert.NotEmpty(t, class.WID)
+	assert.Equal(t, "Fall", class.Name)
+	assert.Equal(t, []string{"teacher"}, class.Instructors)
+	assert.Empty(t, class.Members)
+	require.Len(t, class.Programs, 1)
+	template, err := d.LoadProgram(context.Background(), class.Programs[0])
+	require.NoError(t, err)
+	assert.Equal(t, "print('hi')", template.Code)
+	assert.Equal(t, "teacher", template.Owner)
+	assert.Equal(t, class.WID, template.WID)
+
+	cid, err := d.GetUIDFromWID(context.Background(), class.WID, db.ClassesAliasPath)
+	require.NoError(t, err)
+	assert.Equal(t, class.CID, cid)
+
+	require.Len(t, class.Assignments, 1)
+	clone, err := d.LoadAssignment(context.Background(), class.Assignments[0])
+	require.NoError(t, err)
+	assert.Equal(t, class.CID, clone.CID)
+	assert.Equal(t, "Spirals", clone.Title)
+	assert.Equal(t, time.Date(2030, 1, 8, 0, 0, 0, 0, time.UTC), clone.DueDate)
+	assert.Empty(t, clone.Copies)
+
+	// the starter program is copied for the new class.
+	assert.NotEqual(t, "starter", clone.StarterProgram)
+	p, err := d.LoadProgram(context.Background(), clone.StarterProgram)
+	require.NoError(t, err)
+	assert.Equal(t, "import turtle", p.Code)
+	assert.Equal(t, class.WID, p.WID)
+
+	teacher, err := d.LoadUser(context.Background(), "teacher")
+	require.NoError(t, err)
+	assert.Contains(t, teacher.Classes, class.CID)
+	assert.Contains(t, teacher.Programs, clone.StarterProgram)
+}
+
+// failingStoreClassDB is a MockDB which can't store classes.
+type failingStoreClassDB struct {
+	*db.MockDB
+}
+
+func (d *failingStoreClassDB) StoreClass(context.Context, db.Class) error {
+	return errors.New("unavailable")
+}
+
+func TestCloneClassUndo(t *testing.T) {
+	d := openAssignmentMock(t)
+	createTestAssignment(t, d)
+	before, err := d.LoadUser(context.Background(), "teacher")
+	require.NoError(t, err)
+
+	rec := callHandler(t, &failingStoreClassDB{d}, handler.CloneClass, `{"uid": "teacher", "cid": "test"}`)
+	require.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	after, err := d.LoadUser(context.Background(), "teacher")
+	require.NoError(t, err)
+	assert.ElementsMatch(t, before.Classes, after.Classes)
+	assert.ElementsMatch(t, before.Programs, after.Programs)
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// GetDashboard returns the progress of each member of a class on
+// its assignments, along with their program count and when they
+// were last active. Only instructors of the class may see it, and
+// TAs only see the members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "section": ID of a section to limit the dashboard to
+//	}
+//
+// Returns: Status 200 with the marshalled Dashboard.
+func GetDashboard(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string `json:"uid"`
+		CID     string `json:"cid"`
+		Section string `json:"section"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can see the dashboard")
+	}
+	if class.Members, err = class.MembersVisibleTo(req.UID, req.Section); err != nil {
+		return statusError(c, err)
+	}
+
+	dash, err := db.LoadDashboard(c.Request().Context(), c, class)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load dashboard").Error())
+	}
+	return c.JSON(http.StatusOK, &dash)
+}
@@ -0,0 +1,46 @@
+package handler_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestGetDashboard(t *testing.T) {
+	d := openSectionMock(t)
+	a := createTestAssignment(t, d)
+	rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	rec = callHandler(t, d, handler.SubmitAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+
+	t.Run("NotInstructor", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetDashboard, `{"uid": "alice", "cid": "test"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetDashboard, `{"uid": "teacher", "cid": "test"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		dash := db.Dashboard{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dash))
+		require.Len(t, dash.Assignments, 1)
+		require.Len(t, dash.Members, 2)
+		assert.Equal(t, db.ProgressSubmitted, dash.Members[0].Assignments[a.AID].Status)
+		assert.Equal(t, 1, dash.Members[0].ProgramCount)
+		assert.False(t, dash.Members[0].LastActive.IsZero())
+		assert.Equal(t, db.ProgressNotStarted, dash.Members[1].Assignments[a.AID].Status)
+	})
+	t.Run("TA", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetDashboard, `{"uid": "ta", "cid": "test"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		dash := db.Dashboard{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &dash))
+		require.Len(t, dash.Members, 1)
+		assert.Equal(t, "alice", dash.Members[0].UID)
+	})
+}
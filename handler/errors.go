@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// storageErrorStatus maps an error returned by a TLADB operation
+// to the HTTP status code describing it. Operations report why
+// they were refused through gRPC status codes, as Firestore does.
+func storageErrorStatus(err error) int {
+	switch status.Code(err) {
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.InvalidArgument:
+		return http.StatusBadRequest
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.FailedPrecondition, codes.AlreadyExists:
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// statusError responds with the message of err and the HTTP
+// status corresponding to its gRPC status code.
+func statusError(c echo.Context, err error) error {
+	return c.String(storageErrorStatus(err), status.Convert(err).Message())
+}
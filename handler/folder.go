@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// isFolderDescendant reports whether folder id is the folder
+// ancestor or lies anywhere beneath it.
+func isFolderDescendant(folders map[string]db.Folder, id, ancestor string) bool {
+	// bound the walk by the number of folders in case the
+	// stored hierarchy is already corrupted by a cycle.
+	for i := 0; id != "" && i <= len(folders); i++ {
+		if id == ancestor {
+			return true
+		}
+		id = folders[id].Parent
+	}
+	return false
+}
+
+// CreateFolder creates a new folder for organizing a user's programs.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "name": REQUIRED
+//	    "parent": ID of the containing folder, top level if omitted
+//	}
+//
+// Returns: Status 201 with the marshalled Folder.
+func CreateFolder(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		Name   string `json:"name"`
+		Parent string `json:"parent"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.UID == "" || req.Name == "" {
+		return c.String(http.StatusBadRequest, "uid and name fields are both required")
+	}
+
+	f := db.Folder{
+		ID:     uuid.New().String(),
+		Name:   req.Name,
+		Parent: req.Parent,
+	}
+	err := c.UpdateFolders(c.Request().Context(), req.UID, func(folders map[string]db.Folder) error {
+		if _, ok := folders[f.Parent]; f.Parent != "" && !ok {
+			return status.Error(codes.NotFound, "parent folder does not exist")
+		}
+		folders[f.ID] = f
+		return nil
+	})
+	if err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to create folder").Error())
+	}
+
+	return c.JSON(http.StatusCreated, &f)
+}
+
+// UpdateFolder renames a folder and/or moves it under a new parent.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED
+//	    "name": new name, unchanged if omitted
+//	    "parent": ID of the new containing folder, top level if empty,
+//	              unchanged if omitted
+//	}
+//
+// Returns: Status 200 with the marshalled Folder.
+func UpdateFolder(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string  `json:"uid"`
+		ID     string  `json:"id"`
+		Name   string  `json:"name"`
+		Parent *string `json:"parent"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid and id fields are both required")
+	}
+
+	var f db.Folder
+	err := c.UpdateFolders(c.Request().Context(), req.UID, func(folders map[string]db.Folder) error {
+		var ok bool
+		if f, ok = folders[req.ID]; !ok {
+			return status.Error(codes.NotFound, "folder does not exist")
+		}
+		if req.Parent != nil {
+			if _, ok := folders[*req.Parent]; *req.Parent != "" && !ok {
+				return status.Error(codes.NotFound, "parent folder does not exist")
+			}
+			if isFolderDescendant(folders, *req.Parent, req.ID) {
+				return status.Error(codes.InvalidArgument, "a folder cannot be moved into itself")
+			}
+			f.Parent = *req.Parent
+		}
+
+		if name := strings.TrimSpace(req.Name); name != "" {
+			f.Name = name
+		}
+		folders[f.ID] = f
+		return nil
+	})
+	if err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to update folder").Error())
+	}
+
+	return c.JSON(http.StatusOK, &f)
+}
+
+// DeleteFolder deletes a folder. Its programs and subfolders are
+// moved up into the deleted folder's parent rather than deleted.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED
+//	}
+//
+// Returns: Status 200 on deletion.
+func DeleteFolder(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		ID  string `json:"id"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid and id fields are both required")
+	}
+
+	u, err := c.LoadUser(c.Request().Context(), req.UID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "user does not exist")
+	}
+	f, ok := u.Folders[req.ID]
+	if !ok {
+		return c.String(http.StatusNotFound, "folder does not exist")
+	}
+
+	for _, pid := range u.Programs {
+		p, err := c.LoadProgram(c.Request().Context(), pid)
+		if err != nil || p.Folder != f.ID {
+			continue
+		}
+		p.UID = pid
+		p.Folder = f.Parent
+		if err := c.UpdateProgramFields(c.Request().Context(), p, "folder"); err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to move program out of folder").Error())
+		}
+	}
+
+	err = c.UpdateFolders(c.Request().Context(), req.UID, func(folders map[string]db.Folder) error {
+		f, ok := folders[req.ID]
+		if !ok {
+			return status.Error(codes.NotFound, "folder does not exist")
+		}
+		for id, sub := range folders {
+			if sub.Parent == f.ID {
+				sub.Parent = f.Parent
+				folders[id] = sub
+			}
+		}
+		delete(folders, f.ID)
+		return nil
+	})
+	if err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to delete folder").Error())
+	}
+
+	return c.String(http.StatusOK, "")
+}
+
+// MoveProgram moves one of a user's programs into a folder.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "pid": REQUIRED
+//	    "folder": ID of the destination folder, top level if omitted
+//	}
+//
+// Returns: Status 200 with the marshalled Program.
+func MoveProgram(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		PID    string `json:"pid"`
+		Folder string `json:"folder"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" {
+		return c.String(http.StatusBadRequest, "uid and pid fields are both required")
+	}
+
+	u, err := c.LoadUser(c.Request().Context(), req.UID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "user does not exist")
+	}
+	u.UID = req.UID
+	if _, ok := u.Folders[req.Folder]; req.Folder != "" && !ok {
+		return c.String(http.StatusNotFound, "folder does not exist")
+	}
+
+	p, err := c.LoadProgram(c.Request().Context(), req.PID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "program does not exist")
+	}
+	p.UID = req.PID
+	if !p.IsOwnedBy(u) {
+		return c.String(http.StatusForbidden, "only the owner can move a program")
+	}
+	p.Folder = req.Folder
+	if err := c.UpdateProgramFields(c.Request().Context(), p, "folder"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to move program").Error())
+	}
+
+	return c.JSON(http.StatusOK, &p)
+}
+
+// TagProgram adds free-form tags to one of a user's programs.
+// Tags are trimmed of surrounding whitespace and deduplicated.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "pid": REQUIRED
+//	    "tags": REQUIRED array of tags to add
+//	}
+//
+// Returns: Status 200 with the marshalled Program.
+func TagProgram(cc echo.Context) error {
+	return updateTags(cc, func(tags []string, tag string) []string {
+		for _, t := range tags {
+			if t == tag {
+				return tags
+			}
+		}
+		return append(tags, tag)
+	})
+}
+
+// UntagProgram removes tags from one of a user's programs.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "pid": REQUIRED
+//	    "tags": REQUIRED array of tags to remove
+//	}
+//
+// Returns: Status 200 with the marshalled Program.
+func UntagProgram(cc echo.Context) error {
+	return updateTags(cc, func(tags []string, tag string) []string {
+		for i, t := range tags {
+			if t == tag {
+				return append(tags[:i], tags[i+1:]...)
+			}
+		}
+		return tags
+	})
+}
+
+// updateTags applies op with each requested tag to the tags
+// of the requested program.
+func updateTags(cc echo.Context, op func([]string, string) []string) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID  string   `json:"uid"`
+		PID  string   `json:"pid"`
+		Tags []string `json:"tags"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" || len(req.Tags) == 0 {
+		return c.String(http.StatusBadRequest, "uid, pid and tags fields are all required")
+	}
+
+	u, err := c.LoadUser(c.Request().Context(), req.UID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "user does not exist")
+	}
+	u.UID = req.UID
+
+	p, err := c.LoadProgram(c.Request().Context(), req.PID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "program does not exist")
+	}
+	p.UID = req.PID
+	if !p.IsOwnedBy(u) {
+		return c.String(http.StatusForbidden, "only the owner can tag a program")
+	}
+	for _, tag := range req.Tags {
+		if tag = strings.TrimSpace(tag); tag == "" {
+			return c.String(http.StatusBadRequest, "tags cannot be empty")
+		}
+		p.Tags = op(p.Tags, tag)
+	}
+	if err := c.UpdateProgramFields(c.Request().Context(), p, "tags"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to update program tags").Error())
+	}
+
+	return c.JSON(http.StatusOK, &p)
+}
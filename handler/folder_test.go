@@ -0,0 +1,263 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestCreateFolder(t *testing.T) {
+	t.Run("MissingName", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test", "name": "  "}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.CreateFolder(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("ParentDNE", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test"}))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test", "name": "turtles", "parent": "nope"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.CreateFolder(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+	t.Run("Nested", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:     "test",
+			Folders: map[string]db.Folder{"parent": {ID: "parent", Name: "camp"}},
+		}))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test", "name": "turtles", "parent": "parent"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.CreateFolder(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusCreated, rec.Code)
+			f := db.Folder{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &f))
+			assert.NotEmpty(t, f.ID)
+			assert.Equal(t, "parent", f.Parent)
+
+			u, err := d.LoadUser(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, f, u.Folders[f.ID])
+		}
+	})
+}
+
+func TestUpdateFolder(t *testing.T) {
+	t.Run("IntoDescendant", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID: "test",
+			Folders: map[string]db.Folder{
+				"a": {ID: "a", Name: "a"},
+				"b": {ID: "b", Name: "b", Parent: "a"},
+			},
+		}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "id": "a", "parent": "b"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.UpdateFolder(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("Rename", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID: "test",
+			Folders: map[string]db.Folder{
+				"a": {ID: "a", Name: "a"},
+				"b": {ID: "b", Name: "b", Parent: "a"},
+			},
+		}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "id": "b", "name": "renamed"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.UpdateFolder(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			u, err := d.LoadUser(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, "renamed", u.Folders["b"].Name)
+			assert.Equal(t, "a", u.Folders["b"].Parent, "renaming doesn't move the folder")
+		}
+	})
+	t.Run("ToTopLevel", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID: "test",
+			Folders: map[string]db.Folder{
+				"a": {ID: "a", Name: "a"},
+				"b": {ID: "b", Name: "b", Parent: "a"},
+			},
+		}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "id": "b", "parent": ""}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.UpdateFolder(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			u, err := d.LoadUser(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, "b", u.Folders["b"].Name)
+			assert.Empty(t, u.Folders["b"].Parent)
+		}
+	})
+}
+
+func TestDeleteFolder(t *testing.T) {
+	d := db.OpenMock()
+	require.NoError(t, d.StoreUser(context.Background(), db.User{
+		UID:      "test",
+		Programs: []string{"inside", "outside"},
+		Folders: map[string]db.Folder{
+			"a": {ID: "a", Name: "a"},
+			"b": {ID: "b", Name: "b", Parent: "a"},
+			"c": {ID: "c", Name: "c", Parent: "b"},
+		},
+	}))
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "inside", Folder: "b"}))
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "outside"}))
+	req := httptest.NewRequest(http.MethodDelete, "/", strings.NewReader(`{"uid": "test", "id": "b"}`))
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+
+	if assert.NoError(t, handler.DeleteFolder(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	})) {
+		require.Equal(t, http.StatusOK, rec.Code)
+		u, err := d.LoadUser(context.Background(), "test")
+		require.NoError(t, err)
+		assert.NotContains(t, u.Folders, "b")
+		assert.Equal(t, "a", u.Folders["c"].Parent)
+
+		p, err := d.LoadProgram(context.Background(), "inside")
+		require.NoError(t, err)
+		assert.Equal(t, "a", p.Folder)
+	}
+}
+
+func TestMoveProgram(t *testing.T) {
+	t.Run("NotOwned", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test"}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "test"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.MoveProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+	t.Run("Collaborator", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test", Programs: []string{"test"}}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test", Owner: "owner", Collaborators: []string{"test"}}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "test"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.MoveProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+	t.Run("Valid", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "test",
+			Programs: []string{"test"},
+			Folders:  map[string]db.Folder{"a": {ID: "a", Name: "a"}},
+		}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test"}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "test", "folder": "a"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.MoveProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			p, err := d.LoadProgram(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, "a", p.Folder)
+		}
+	})
+}
+
+func TestTagProgram(t *testing.T) {
+	d := db.OpenMock()
+	require.NoError(t, d.StoreUser(context.Background(), db.User{
+		UID:      "test",
+		Programs: []string{"test"},
+	}))
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test"}))
+
+	req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "test", "tags": ["turtle", " loops ", "turtle"]}`))
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, handler.TagProgram(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	p, err := d.LoadProgram(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"turtle", "loops"}, p.Tags)
+
+	req = httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "test", "tags": ["turtle"]}`))
+	rec = httptest.NewRecorder()
+	c = echo.New().NewContext(req, rec)
+	require.NoError(t, handler.UntagProgram(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	p, err = d.LoadProgram(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"loops"}, p.Tags)
+}
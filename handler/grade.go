@@ -0,0 +1,274 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SetRubric replaces the rubric of an assignment. Criteria without
+// an ID are given one. Only instructors of the class may set the
+// rubric; grades already given are kept as they are.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "rubric": REQUIRED array of {"id", "description", "points"}
+//	}
+//
+// Returns: Status 200 with the marshalled Assignment.
+func SetRubric(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string         `json:"uid"`
+		AID    string         `json:"aid"`
+		Rubric []db.Criterion `json:"rubric"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" || len(req.Rubric) == 0 {
+		return c.String(http.StatusBadRequest, "uid, aid and rubric fields are all required")
+	}
+
+	seen := make(map[string]bool, len(req.Rubric))
+	for i := range req.Rubric {
+		cr := &req.Rubric[i]
+		cr.Description = strings.TrimSpace(cr.Description)
+		if cr.Description == "" || cr.Points <= 0 {
+			return c.String(http.StatusBadRequest, "each criterion needs a description and a positive number of points")
+		}
+		if cr.ID == "" {
+			cr.ID = uuid.New().String()
+		}
+		if seen[cr.ID] {
+			return c.String(http.StatusBadRequest, "criterion IDs must be unique")
+		}
+		seen[cr.ID] = true
+	}
+
+	a, _, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can set rubrics")
+	}
+
+	a.Rubric = req.Rubric
+	if err := c.UpdateAssignment(c.Request().Context(), a, "rubric"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to set rubric").Error())
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
+
+// GradeSubmission grades a member's submission against the
+// assignment's rubric, replacing any previous grade. Only
+// instructors of the class may grade, and TAs only the members of
+// the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "student": REQUIRED, UID of the member whose submission is graded
+//	    "scores": map of criterion IDs to points awarded
+//	    "comments": map of criterion IDs to comments
+//	    "feedback": overall written feedback
+//	}
+//
+// Returns: Status 200 with the marshalled Submission, or status 409
+// if the member resubmitted while it was being graded.
+func GradeSubmission(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID      string             `json:"uid"`
+		AID      string             `json:"aid"`
+		Student  string             `json:"student"`
+		Scores   map[string]float64 `json:"scores"`
+		Comments map[string]string  `json:"comments"`
+		Feedback string             `json:"feedback"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" || req.Student == "" {
+		return c.String(http.StatusBadRequest, "uid, aid and student fields are all required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can grade submissions")
+	}
+	if !class.CanSee(req.UID, req.Student) {
+		return c.String(http.StatusForbidden, "TAs can only grade submissions from their sections")
+	}
+
+	s, err := c.LoadSubmission(c.Request().Context(), a.AID, req.Student)
+	if err != nil {
+		return c.String(http.StatusNotFound, "submission does not exist")
+	}
+	g, err := a.Grade(req.Scores, req.Comments, req.Feedback, req.UID, time.Now().UTC())
+	if err != nil {
+		return statusError(c, err)
+	}
+	s.Grade = &g
+	if s, err = c.UpdateSubmission(c.Request().Context(), s, "grade"); err != nil {
+		if status.Code(err) == codes.FailedPrecondition {
+			return c.String(http.StatusConflict, "submission was resubmitted while being graded")
+		}
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to store grade").Error())
+	}
+
+	return c.JSON(http.StatusOK, &s)
+}
+
+// ReleaseGrades publishes or withdraws the grades of an assignment.
+// Members can only see their grades while they are released. Only
+// instructors of the class may release grades.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "released": REQUIRED bool
+//	}
+//
+// Returns: Status 200 with the marshalled Assignment.
+func ReleaseGrades(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID      string `json:"uid"`
+		AID      string `json:"aid"`
+		Released *bool  `json:"released"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" || req.Released == nil {
+		return c.String(http.StatusBadRequest, "uid, aid and released fields are all required")
+	}
+
+	a, _, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can release grades")
+	}
+
+	a.GradesReleased = *req.Released
+	if err := c.UpdateAssignment(c.Request().Context(), a, "gradesReleased"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to release grades").Error())
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
+
+// GetGrade returns the requesting member's grade for an assignment
+// along with the rubric it was given against. Grades are only
+// visible once the instructor has released them.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the grade, rubric and maximum points.
+func GetGrade(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, _, _, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !a.GradesReleased {
+		return c.String(http.StatusForbidden, "grades have not been released")
+	}
+	s, err := c.LoadSubmission(c.Request().Context(), a.AID, req.UID)
+	if err != nil || s.Grade == nil {
+		return c.String(http.StatusNotFound, "submission has not been graded")
+	}
+
+	resp := struct {
+		Grade     *db.Grade      `json:"grade"`
+		Rubric    []db.Criterion `json:"rubric"`
+		MaxPoints float64        `json:"maxPoints"`
+	}{
+		Grade:     s.Grade,
+		Rubric:    a.Rubric,
+		MaxPoints: a.MaxPoints(),
+	}
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// ExportGradebook streams the gradebook of a class as CSV, with one
+// row per member. Only instructors of the class may export it, and
+// TAs only get the rows of the sections they lead.
+//
+// Query parameters:
+//   - uid string: REQUIRED requester
+//   - cid string: REQUIRED class to export
+//   - section string: ID of a section to limit the export to
+//
+// Returns status 200 OK with a text/csv attachment.
+func ExportGradebook(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	uid, cid, section := c.QueryParam("uid"), c.QueryParam("cid"), c.QueryParam("section")
+	if uid == "" || cid == "" {
+		return c.String(http.StatusBadRequest, "`uid` and `cid` are required query parameters.")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), cid)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, uid); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can export the gradebook")
+	}
+	if class.Members, err = class.MembersVisibleTo(uid, section); err != nil {
+		return statusError(c, err)
+	}
+
+	// the status is sent with the first row, so failing to load the
+	// assignments can still be reported.
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="gradebook-`+class.CID+`.csv"`)
+	if err := db.WriteGradebook(c.Request().Context(), c, class, c.Response()); err != nil {
+		if !c.Response().Committed {
+			c.Response().Header().Del(echo.HeaderContentDisposition)
+			return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to export gradebook").Error())
+		}
+		// otherwise all we can do is cut the response short.
+		c.Logger().Errorf("Failed to export gradebook for class with cid `%s`: %v", class.CID, err)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestSetRubric(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+
+	rec := callHandler(t, d, handler.SetRubric, `{"uid": "alice", "aid": "`+a.AID+`", "rubric": [{"description": "Works", "points": 5}]}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.SetRubric, `{"uid": "teacher", "aid": "`+a.AID+`", "rubric": [{"description": "Works", "points": 0}]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = callHandler(t, d, handler.SetRubric, `{"uid": "teacher", "aid": "`+a.AID+`", "rubric": [{"id": "style", "description": "Style", "points": 2}, {"description": "Works", "points": 5}]}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	a, err := d.LoadAssignment(context.Background(), a.AID)
+	require.NoError(t, err)
+	require.Len(t, a.Rubric, 2)
+	assert.Equal(t, "style", a.Rubric[0].ID)
+	assert.NotEmpty(t, a.Rubric[1].ID)
+}
+
+func TestGradeSubmission(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	a.Rubric = []db.Criterion{{ID: "works", Description: "Works", Points: 5}}
+	require.NoError(t, d.StoreAssignment(context.Background(), a))
+	require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: a.AID, UID: "alice", Attempts: 1}))
+
+	t.Run("NotSubmitted", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GradeSubmission, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "bob", "scores": {"works": 5}}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("OutOfRange", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GradeSubmission, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "alice", "scores": {"works": 6}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("ReleaseFlow", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GradeSubmission, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "alice", "scores": {"works": 4}, "feedback": "good"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		// grades are hidden from members until released.
+		rec = callHandler(t, d, handler.GetGrade, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callHandler(t, d, handler.GetSubmission, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		s := db.Submission{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+		assert.Nil(t, s.Grade)
+
+		rec = callHandler(t, d, handler.ReleaseGrades, `{"uid": "alice", "aid": "`+a.AID+`", "released": true}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callHandler(t, d, handler.ReleaseGrades, `{"uid": "teacher", "aid": "`+a.AID+`", "released": true}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = callHandler(t, d, handler.GetGrade, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		resp := struct {
+			Grade     db.Grade `json:"grade"`
+			MaxPoints float64  `json:"maxPoints"`
+		}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 4.0, resp.Grade.Total)
+		assert.Equal(t, "good", resp.Grade.Feedback)
+		assert.Equal(t, 5.0, resp.MaxPoints)
+
+		rec = callHandler(t, d, handler.GetGrade, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestExportGradebook(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: a.AID, UID: "alice", Grade: &db.Grade{Total: 3}}))
+
+	t.Run("NotInstructor", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?uid=alice&cid=test", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		if assert.NoError(t, handler.ExportGradebook(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+	t.Run("Valid", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?uid=teacher&cid=test", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		if assert.NoError(t, handler.ExportGradebook(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/csv", rec.Header().Get(echo.HeaderContentType))
+			rows, err := csv.NewReader(rec.Body).ReadAll()
+			require.NoError(t, err)
+			require.Len(t, rows, 3)
+			assert.Equal(t, []string{"alice", "", "3", "false", "3"}, rows[1])
+			assert.Equal(t, []string{"bob", "", "", "", "0"}, rows[2])
+		}
+	})
+}
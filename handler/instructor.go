@@ -0,0 +1,236 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// AddInstructor makes a user a co-instructor of a class. Any
+// instructor of the class other than a TA may add others.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "instructor": REQUIRED, the user to add
+//	}
+//
+// Returns status 200 OK on success.
+func AddInstructor(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID        string `json:"uid"`
+		CID        string `json:"cid"`
+		Instructor string `json:"instructor"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.Instructor == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and instructor fields are all required")
+	}
+
+	if err := c.AddClassInstructor(c.Request().Context(), req.CID, req.UID, req.Instructor); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to add instructor").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// PromoteMember makes a member of a class one of its instructors.
+// Any instructor of the class other than a TA may promote members.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "member": REQUIRED, the member to promote
+//	}
+//
+// Returns status 200 OK on success.
+func PromoteMember(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		CID    string `json:"cid"`
+		Member string `json:"member"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.Member == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and member fields are all required")
+	}
+
+	if err := c.PromoteClassMember(c.Request().Context(), req.CID, req.UID, req.Member); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to promote member").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// RemoveInstructor removes a co-instructor from a class. Only the
+// creator of the class may remove instructors, and the creator
+// can't be removed without first transferring the class.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the creator of the class
+//	    "cid": REQUIRED
+//	    "instructor": REQUIRED, the instructor to remove
+//	}
+//
+// Returns status 200 OK on success.
+func RemoveInstructor(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID        string `json:"uid"`
+		CID        string `json:"cid"`
+		Instructor string `json:"instructor"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.Instructor == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and instructor fields are all required")
+	}
+
+	if err := c.RemoveClassInstructor(c.Request().Context(), req.CID, req.UID, req.Instructor); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to remove instructor").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// TransferClass makes another user of a class its creator. A new
+// creator who is a member is promoted to instructor.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the current creator
+//	    "cid": REQUIRED
+//	    "to": REQUIRED, the new creator
+//	}
+//
+// Returns status 200 OK on success.
+func TransferClass(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		CID string `json:"cid"`
+		To  string `json:"to"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.To == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and to fields are all required")
+	}
+	if req.UID == req.To {
+		return c.String(http.StatusBadRequest, "class is already owned by the given user")
+	}
+
+	if err := c.TransferClass(c.Request().Context(), req.CID, req.UID, req.To); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to transfer class").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// RemoveMember takes a member out of a class. Any instructor of the
+// class may remove members, and TAs those of the sections they
+// lead; the removal is recorded in the class's moderation log. The member may rejoin with the class's WID.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "member": REQUIRED, the member to remove
+//	    "reason": why the member was removed
+//	}
+//
+// Returns status 200 OK on success.
+func RemoveMember(cc echo.Context) error {
+	return removeMember(cc, false)
+}
+
+// BanMember takes a member out of a class and prevents them from
+// rejoining it. Any instructor of the class may ban members, and
+// TAs those of the sections they lead; the ban is recorded in the
+// class's moderation log.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "member": REQUIRED, the member to ban
+//	    "reason": why the member was banned
+//	}
+//
+// Returns status 200 OK on success.
+func BanMember(cc echo.Context) error {
+	return removeMember(cc, true)
+}
+
+// removeMember removes the requested member from the class,
+// banning them if ban is set.
+func removeMember(cc echo.Context, ban bool) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		CID    string `json:"cid"`
+		Member string `json:"member"`
+		Reason string `json:"reason"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.Member == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and member fields are all required")
+	}
+
+	if err := c.RemoveClassMember(c.Request().Context(), req.CID, req.UID, req.Member, req.Reason, ban); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to remove member").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// UnbanUser lets a banned user rejoin a class. Any instructor of
+// the class may lift bans.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "user": REQUIRED, the banned user
+//	    "reason": why the ban was lifted
+//	}
+//
+// Returns status 200 OK on success.
+func UnbanUser(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		CID    string `json:"cid"`
+		User   string `json:"user"`
+		Reason string `json:"reason"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.User == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and user fields are all required")
+	}
+
+	if err := c.UnbanClassUser(c.Request().Context(), req.CID, req.UID, req.User, req.Reason); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to unban user").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
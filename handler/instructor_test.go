@@ -0,0 +1,100 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestInstructorManagement(t *testing.T) {
+	d := openAssignmentMock(t)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	class.Creator = "teacher"
+	require.NoError(t, d.StoreClass(context.Background(), class))
+	require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "carol"}))
+
+	rec := callHandler(t, d, handler.AddInstructor, `{"uid": "alice", "cid": "test", "instructor": "carol"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.AddInstructor, `{"uid": "teacher", "cid": "test", "instructor": "nobody"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	rec = callHandler(t, d, handler.AddInstructor, `{"uid": "teacher", "cid": "test", "instructor": "carol"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = callHandler(t, d, handler.PromoteMember, `{"uid": "carol", "cid": "test", "member": "alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	class, err = d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"teacher", "carol", "alice"}, class.Instructors)
+	assert.Equal(t, []string{"bob"}, class.Members)
+
+	// only the creator removes instructors.
+	rec = callHandler(t, d, handler.RemoveInstructor, `{"uid": "carol", "cid": "test", "instructor": "alice"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.RemoveInstructor, `{"uid": "teacher", "cid": "test", "instructor": "teacher"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+	rec = callHandler(t, d, handler.RemoveInstructor, `{"uid": "teacher", "cid": "test", "instructor": "alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	rec = callHandler(t, d, handler.TransferClass, `{"uid": "carol", "cid": "test", "to": "carol"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	rec = callHandler(t, d, handler.TransferClass, `{"uid": "teacher", "cid": "test", "to": "carol"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	class, err = d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, "carol", class.Creator)
+	assert.Equal(t, []string{"teacher", "carol"}, class.Instructors)
+}
+
+func TestRemoveMember(t *testing.T) {
+	d := openAssignmentMock(t)
+	u, err := d.LoadUser(context.Background(), "alice")
+	require.NoError(t, err)
+	u.Classes = []string{"test"}
+	require.NoError(t, d.StoreUser(context.Background(), u))
+
+	rec := callHandler(t, d, handler.RemoveMember, `{"uid": "bob", "cid": "test", "member": "alice"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.RemoveMember, `{"uid": "teacher", "cid": "test", "member": "alice", "reason": "dropped"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"bob"}, class.Members)
+	assert.Equal(t, "dropped", class.Moderation[0].Reason)
+	u, err = d.LoadUser(context.Background(), "alice")
+	require.NoError(t, err)
+	assert.Empty(t, u.Classes)
+
+	// removed members may rejoin.
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "alice", "wid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestBanMember(t *testing.T) {
+	d := openAssignmentMock(t)
+
+	rec := callHandler(t, d, handler.BanMember, `{"uid": "teacher", "cid": "test", "member": "alice", "reason": "spam"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "alice", "wid": "test"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	// the moderation log is only visible to instructors.
+	rec = callHandler(t, d, handler.GetClass, `{"uid": "bob", "cid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	class := db.Class{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &class))
+	assert.Empty(t, class.Moderation)
+	assert.Empty(t, class.Banned)
+
+	rec = callHandler(t, d, handler.UnbanUser, `{"uid": "teacher", "cid": "test", "user": "alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "alice", "wid": "test"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
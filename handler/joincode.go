@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// RotateJoinCode gives a class a new WID and retires the old one,
+// so it can no longer be used to join the class. Members who
+// already joined are unaffected. Any instructor of the class may
+// rotate its WID.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the new WID.
+func RotateJoinCode(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		CID string `json:"cid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	wid, err := c.RotateClassWID(c.Request().Context(), req.CID, req.UID)
+	if err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to rotate join code").Error())
+	}
+	return c.JSON(http.StatusOK, map[string]string{"wid": wid})
+}
+
+// SetJoinCode limits who may join a class with its WID, replacing
+// any previous limits. Any instructor of the class may set them.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "expires": when the WID stops working, never if omitted
+//	    "maxUses": how many users may join with the WID, unlimited if omitted
+//	    "paused": whether joining the class is paused
+//	}
+//
+// Returns status 200 OK on success.
+func SetJoinCode(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string    `json:"uid"`
+		CID     string    `json:"cid"`
+		Expires time.Time `json:"expires"`
+		MaxUses int       `json:"maxUses"`
+		Paused  bool      `json:"paused"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	j := db.JoinCode{
+		Expires: req.Expires,
+		MaxUses: req.MaxUses,
+		Paused:  req.Paused,
+	}
+	if err := c.SetClassJoinCode(c.Request().Context(), req.CID, req.UID, j); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to set join code").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
@@ -0,0 +1,87 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestRotateJoinCode(t *testing.T) {
+	d := openAssignmentMock(t)
+	for _, uid := range []string{"carol", "dave"} {
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: uid}))
+	}
+
+	rec := callHandler(t, d, handler.RotateJoinCode, `{"uid": "alice", "cid": "test"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.RotateJoinCode, `{"uid": "teacher", "cid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := struct {
+		WID string `json:"wid"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	require.NotEmpty(t, resp.WID)
+
+	// the old WID is retired.
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "dave", "wid": "`+resp.WID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Equal(t, resp.WID, class.WID)
+
+	// programs created through the old WID still find the class.
+	cid, err := d.GetUIDFromWID(context.Background(), "test", db.ClassesAliasPath)
+	require.NoError(t, err)
+	assert.Equal(t, "test", cid)
+	assert.Contains(t, class.Members, "dave")
+
+	// but can't be used to add new ones.
+	rec = callHandler(t, d, handler.CreateProgram, `{"uid": "carol", "wid": "test", "program": {"language": "python"}}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	class, err = d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	assert.Empty(t, class.Programs)
+}
+
+func TestSetJoinCode(t *testing.T) {
+	d := openAssignmentMock(t)
+	for _, uid := range []string{"carol", "dave"} {
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: uid}))
+	}
+
+	rec := callHandler(t, d, handler.SetJoinCode, `{"uid": "alice", "cid": "test", "paused": true}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.SetJoinCode, `{"uid": "teacher", "cid": "test", "maxUses": -1}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = callHandler(t, d, handler.SetJoinCode, `{"uid": "teacher", "cid": "test", "paused": true}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = callHandler(t, d, handler.SetJoinCode, `{"uid": "teacher", "cid": "test", "expires": "2000-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	rec = callHandler(t, d, handler.SetJoinCode, `{"uid": "teacher", "cid": "test", "maxUses": 1}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "dave", "wid": "test"}`)
+	assert.Equal(t, http.StatusConflict, rec.Code)
+
+	// members who already joined aren't turned away.
+	rec = callHandler(t, d, handler.JoinClass, `{"uid": "carol", "wid": "test"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
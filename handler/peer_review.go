@@ -0,0 +1,339 @@
+package handler
+
+import (
+	"math/rand"
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// AssignPeerReviews randomly assigns members to review each other's
+// submissions for an assignment. Every submission gets the given
+// number of reviewers, everyone who submitted reviews that many
+// others, and nobody reviews their own. Reviewers can only be
+// assigned once per assignment. Only instructors of the class may
+// assign them.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "aid": REQUIRED
+//	    "reviewers": REQUIRED, number of reviewers per submission
+//	}
+//
+// Returns: Status 200 with the marshalled PeerReviews.
+func AssignPeerReviews(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID       string `json:"uid"`
+		AID       string `json:"aid"`
+		Reviewers int    `json:"reviewers"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" || req.Reviewers == 0 {
+		return c.String(http.StatusBadRequest, "uid, aid and reviewers fields are all required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can assign peer reviews")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	submissions, err := c.LoadSubmissions(c.Request().Context(), a.AID, class.Members)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load submissions").Error())
+	}
+	authors := []string{}
+	for _, uid := range class.Members {
+		if _, ok := submissions[uid]; ok {
+			authors = append(authors, uid)
+		}
+	}
+
+	rng := rand.New(rand.NewSource(time.Now().UnixNano()))
+	reviews, err := db.AssignPeerReviewers(a.AID, authors, req.Reviewers, rng)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if reviews, err = c.CreatePeerReviews(c.Request().Context(), a.AID, req.Reviewers, reviews); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to assign peer reviews").Error())
+	}
+
+	return c.JSON(http.StatusOK, &reviews)
+}
+
+// ListReviewQueue returns the submissions a member has been asked
+// to review for an assignment, along with their review of each so
+// far. Reviewers aren't told whose submission they are reviewing,
+// and only get the code as it was submitted.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with each review and the program it is of.
+func ListReviewQueue(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, _, _, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	reviews, err := c.ListPeerReviews(c.Request().Context(), a.AID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to list peer reviews").Error())
+	}
+
+	type queued struct {
+		db.PeerReview
+		Program db.Program `json:"program"`
+	}
+	queue := []queued{}
+	for _, r := range reviews {
+		if r.Reviewer != req.UID {
+			continue
+		}
+		s, err := c.LoadSubmission(c.Request().Context(), a.AID, r.Author)
+		if err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load submission").Error())
+		}
+		p := s.Snapshot
+		p.UID, p.Owner, p.Collaborators = "", "", nil
+		queue = append(queue, queued{PeerReview: r.ForReviewer(), Program: p})
+	}
+
+	return c.JSON(http.StatusOK, &queue)
+}
+
+// SubmitPeerReview records a reviewer's review of a submission
+// against the assignment's rubric, replacing any review they
+// submitted before.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the reviewer
+//	    "id": REQUIRED, ID of the peer review
+//	    "scores": map of criterion IDs to points
+//	    "comments": map of criterion IDs to comments
+//	    "feedback": overall feedback
+//	}
+//
+// Returns: Status 200 with the marshalled PeerReview.
+func SubmitPeerReview(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID      string             `json:"uid"`
+		ID       string             `json:"id"`
+		Scores   map[string]float64 `json:"scores"`
+		Comments map[string]string  `json:"comments"`
+		Feedback string             `json:"feedback"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid and id fields are both required")
+	}
+
+	r, err := c.LoadPeerReview(c.Request().Context(), req.ID)
+	if err != nil || r.Reviewer != req.UID {
+		return c.String(http.StatusNotFound, "peer review does not exist")
+	}
+	a, class, _, err := loadAssignmentClass(c, r.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	g, err := a.Grade(req.Scores, req.Comments, req.Feedback, req.UID, time.Now().UTC())
+	if err != nil {
+		return statusError(c, err)
+	}
+	r.Review = &g
+	if err := c.UpdatePeerReview(c.Request().Context(), r, "review"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to store peer review").Error())
+	}
+
+	r = r.ForReviewer()
+	return c.JSON(http.StatusOK, &r)
+}
+
+// ListReceivedReviews returns the reviews a member's submission for
+// an assignment has received, without saying who wrote them.
+// Reviews hidden by an instructor are left out.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the marshalled PeerReviews.
+func ListReceivedReviews(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, _, _, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	reviews, err := c.ListPeerReviews(c.Request().Context(), a.AID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to list peer reviews").Error())
+	}
+
+	received := []db.PeerReview{}
+	for _, r := range reviews {
+		if r.Author == req.UID && r.Review != nil && !r.Hidden {
+			received = append(received, r.ForAuthor())
+		}
+	}
+	return c.JSON(http.StatusOK, &received)
+}
+
+// ListPeerReviews returns every peer review of an assignment, with
+// who wrote each and whose submission it is of. Only instructors
+// of the class may list them, and TAs only see the reviews of the
+// members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the marshalled PeerReviews.
+func ListPeerReviews(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can list peer reviews")
+	}
+	members, err := class.MembersVisibleTo(req.UID, "")
+	if err != nil {
+		return statusError(c, err)
+	}
+	visible := make(map[string]bool, len(members))
+	for _, uid := range members {
+		visible[uid] = true
+	}
+
+	reviews, err := c.ListPeerReviews(c.Request().Context(), a.AID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to list peer reviews").Error())
+	}
+	shown := []db.PeerReview{}
+	for _, r := range reviews {
+		if visible[r.Author] {
+			shown = append(shown, r)
+		}
+	}
+	return c.JSON(http.StatusOK, &shown)
+}
+
+// ModeratePeerReview hides a peer review from the author of the
+// submission it is of, or shows it again. Only instructors of the
+// class may moderate reviews, and TAs only those of submissions
+// from the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "id": REQUIRED, ID of the peer review
+//	    "hidden": REQUIRED bool
+//	    "note": why the review was hidden, for other instructors
+//	}
+//
+// Returns: Status 200 with the marshalled PeerReview.
+func ModeratePeerReview(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		ID     string `json:"id"`
+		Hidden *bool  `json:"hidden"`
+		Note   string `json:"note"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" || req.Hidden == nil {
+		return c.String(http.StatusBadRequest, "uid, id and hidden fields are all required")
+	}
+
+	r, err := c.LoadPeerReview(c.Request().Context(), req.ID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "peer review does not exist")
+	}
+	_, class, isInstructor, err := loadAssignmentClass(c, r.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can moderate peer reviews")
+	}
+	if !class.CanSee(req.UID, r.Author) {
+		return c.String(http.StatusForbidden, "TAs can only moderate reviews of submissions from their sections")
+	}
+
+	r.Hidden = *req.Hidden
+	r.ModeratedBy, r.ModerationNote = req.UID, req.Note
+	if err := c.UpdatePeerReview(c.Request().Context(), r, "hidden", "moderatedBy", "moderationNote"); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to store peer review").Error())
+	}
+	return c.JSON(http.StatusOK, &r)
+}
@@ -0,0 +1,92 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestPeerReview(t *testing.T) {
+	ctx := context.Background()
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	a.Rubric = []db.Criterion{{ID: "works", Description: "Works", Points: 5}}
+	require.NoError(t, d.StoreAssignment(ctx, a))
+	for _, uid := range []string{"alice", "bob"} {
+		require.NoError(t, d.StoreSubmission(ctx, db.Submission{
+			AID:      a.AID,
+			UID:      uid,
+			Attempts: 1,
+			Snapshot: db.Program{UID: uid + "-copy", Code: "print('" + uid + "')", Owner: uid},
+		}))
+	}
+
+	rec := callHandler(t, d, handler.AssignPeerReviews, `{"uid": "alice", "aid": "`+a.AID+`", "reviewers": 1}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.AssignPeerReviews, `{"uid": "teacher", "aid": "`+a.AID+`", "reviewers": 2}`)
+	assert.Equal(t, http.StatusConflict, rec.Code, "two submissions can't each get two reviewers")
+	rec = callHandler(t, d, handler.AssignPeerReviews, `{"uid": "teacher", "aid": "`+a.AID+`", "reviewers": 1}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	rec = callHandler(t, d, handler.AssignPeerReviews, `{"uid": "teacher", "aid": "`+a.AID+`", "reviewers": 1}`)
+	assert.Equal(t, http.StatusConflict, rec.Code, "reviewers are only assigned once")
+	reviews, err := d.ListPeerReviews(context.Background(), a.AID)
+	require.NoError(t, err)
+	assert.Len(t, reviews, 2)
+
+	rec = callHandler(t, d, handler.ListReviewQueue, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	queue := []struct {
+		db.PeerReview
+		Program db.Program `json:"program"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &queue))
+	require.Len(t, queue, 1)
+	assert.Empty(t, queue[0].Author)
+	assert.Empty(t, queue[0].Program.Owner)
+	assert.Equal(t, "print('bob')", queue[0].Program.Code)
+	id := queue[0].ID
+
+	t.Run("Submit", func(t *testing.T) {
+		rec := callHandler(t, d, handler.SubmitPeerReview, `{"uid": "bob", "id": "`+id+`", "scores": {"works": 4}}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code, "only the reviewer may submit")
+		rec = callHandler(t, d, handler.SubmitPeerReview, `{"uid": "alice", "id": "`+id+`", "scores": {"works": 6}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = callHandler(t, d, handler.SubmitPeerReview, `{"uid": "alice", "id": "`+id+`", "scores": {"works": 4}, "feedback": "neat"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = callHandler(t, d, handler.ListReceivedReviews, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		received := []db.PeerReview{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &received))
+		require.Len(t, received, 1)
+		assert.Empty(t, received[0].Reviewer)
+		assert.Empty(t, received[0].Review.Grader)
+		assert.Equal(t, "neat", received[0].Review.Feedback)
+	})
+	t.Run("Moderate", func(t *testing.T) {
+		rec := callHandler(t, d, handler.ListPeerReviews, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callHandler(t, d, handler.ListPeerReviews, `{"uid": "teacher", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		all := []db.PeerReview{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &all))
+		assert.Len(t, all, 2)
+
+		rec = callHandler(t, d, handler.ModeratePeerReview, `{"uid": "alice", "id": "`+id+`", "hidden": true}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callHandler(t, d, handler.ModeratePeerReview, `{"uid": "teacher", "id": "`+id+`", "hidden": true, "note": "off topic"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = callHandler(t, d, handler.ListReceivedReviews, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		received := []db.PeerReview{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &received))
+		assert.Empty(t, received)
+	})
+}
@@ -8,7 +8,9 @@
 package handler
 
 import (
+	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -18,20 +20,37 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// defaultSearchLimit and maxSearchLimit bound the number of
+	// results returned by SearchPrograms.
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
 // GetProgram retrieves information about a single program.
+// If the uid of the program's owner is provided, the program
+// is recorded as the owner's most recently opened program.
 //
-// Query parameters: pid
+// Query parameters: pid, uid (optional)
 //
 // Returns status 200 OK with a marshalled Program struct.
 func GetProgram(cc echo.Context) error {
 	c := cc.(*db.DBContext)
-	pid := c.QueryParam("pid")
+	pid, uid := c.QueryParam("pid"), c.QueryParam("uid")
 	p, err := c.LoadProgram(c.Request().Context(), pid)
 	if err != nil {
 		c.Logger().Debugf("Failed to load program with pid `%s`: %v", pid, err)
 		return c.String(http.StatusNotFound, "Failed to load program.")
 	}
 
+	// Failing to record the open shouldn't keep the user
+	// from their program.
+	if uid != "" {
+		if err := c.RecordProgramOpen(c.Request().Context(), uid, pid); err != nil {
+			c.Logger().Warnf("Failed to record pid `%s` as recent for uid `%s`: %v", pid, uid, err)
+		}
+	}
+
 	return c.JSON(http.StatusOK, &p)
 }
 
@@ -69,6 +88,7 @@ func CreateProgram(cc echo.Context) error {
 	if requestBody.Prog.Name != "" {
 		p.Name = requestBody.Prog.Name
 	}
+	p.Owner = requestBody.UID
 
 	wid := requestBody.WID
 	var cid string
@@ -85,6 +105,14 @@ func CreateProgram(cc echo.Context) error {
 		if err != nil {
 			return err
 		}
+		// rotated WIDs still resolve for the programs made through
+		// them, but can't be used to add new ones.
+		if wid != class.WID {
+			return c.String(http.StatusNotFound, "join code "+wid+" has been retired")
+		}
+		if err := class.CheckActive(); err != nil {
+			return statusError(c, err)
+		}
 	}
 
 	// create program
@@ -138,37 +166,17 @@ func DeleteProgram(cc echo.Context) error {
 		return c.String(http.StatusBadRequest, "uid and idx fields are both required")
 	}
 
-	u, err := c.LoadUser(c.Request().Context(), req.UID)
-	if err != nil {
-		return err
-	}
-
-	// get pid to delete then remove the entry
-	idx := 0
-	for i, p := range u.Programs {
-		if p == req.PID {
-			idx = i
-			break
-		}
-	}
-	if idx >= len(u.Programs) {
-		return errors.New("invalid PID")
+	// only the owner may delete a program; collaborators leave it
+	// through /program/collaborator/remove instead.
+	p, err := c.DeleteOwnedProgram(c.Request().Context(), req.PID, req.UID)
+	if status.Code(err) == codes.PermissionDenied {
+		return c.String(http.StatusForbidden, "only the owner of a program can delete it; collaborators can leave it through /program/collaborator/remove")
 	}
-	toDelete := u.Programs[idx]
-	u.Programs = append(u.Programs[:idx], u.Programs[idx+1:]...)
-
-	err = c.StoreUser(c.Request().Context(), u)
 	if err != nil {
-		return err
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to delete program").Error())
 	}
 
 	// remove program from class if is in class
-	p, err := c.LoadProgram(c.Request().Context(), toDelete)
-
-	if err != nil {
-		return err
-	}
-
 	if p.WID != "" {
 		cid, err := c.GetUIDFromWID(c.Request().Context(), p.WID, db.ClassesAliasPath)
 		if err != nil {
@@ -199,14 +207,330 @@ func DeleteProgram(cc echo.Context) error {
 		}
 	}
 
-	err = c.RemoveProgram(c.Request().Context(), req.PID)
+	return c.String(http.StatusOK, "")
+}
 
+// LikeProgram toggles a user's like on a program. A user may
+// like any given program at most once; liking a program twice
+// removes the like.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "pid": REQUIRED
+//	}
+//
+// Returns status 200 OK with whether the program is now liked
+// and its current like and view counts.
+func LikeProgram(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		PID string `json:"pid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" {
+		return c.String(http.StatusBadRequest, "uid and pid fields are both required")
+	}
+
+	liked, err := c.ToggleProgramLike(c.Request().Context(), req.PID, req.UID)
 	if err != nil {
-		if status.Code(err) == codes.NotFound {
-			return c.String(http.StatusNotFound, "user or program does not exist")
+		c.Logger().Debugf("Failed to toggle like on pid `%s` for uid `%s`: %v", req.PID, req.UID, err)
+		return c.String(http.StatusNotFound, "user or program does not exist")
+	}
+
+	stats, err := c.LoadProgramStats(c.Request().Context(), req.PID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load program stats").Error())
+	}
+
+	resp := struct {
+		Liked bool `json:"liked"`
+		db.ProgramStats
+	}{liked, stats}
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// ViewProgram records a single view of a program.
+//
+// Request Body:
+//
+//	{
+//	    "pid": REQUIRED
+//	}
+//
+// Returns status 200 OK on success.
+func ViewProgram(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		PID string `json:"pid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.PID == "" {
+		return c.String(http.StatusBadRequest, "pid is required")
+	}
+
+	if err := c.IncrementProgramViews(c.Request().Context(), req.PID); err != nil {
+		c.Logger().Debugf("Failed to record view of pid `%s`: %v", req.PID, err)
+		return c.String(http.StatusNotFound, "program does not exist")
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// GetProgramStats retrieves the like and view counts of a program.
+//
+// Query parameters: pid
+//
+// Returns status 200 OK with a marshalled ProgramStats struct.
+func GetProgramStats(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	pid := c.QueryParam("pid")
+	if pid == "" {
+		return c.String(http.StatusBadRequest, "`pid` is a required query parameter.")
+	}
+
+	stats, err := c.LoadProgramStats(c.Request().Context(), pid)
+	if err != nil {
+		c.Logger().Debugf("Failed to load stats for pid `%s`: %v", pid, err)
+		return c.String(http.StatusNotFound, "Failed to load program.")
+	}
+	return c.JSON(http.StatusOK, &stats)
+}
+
+// SearchPrograms searches program names and code, returning
+// ranked matches with their program data.
+//
+// Query parameters:
+//   - q string: REQUIRED search terms
+//   - scope string: one of "own" (default), "class" or "public"
+//   - uid string: requester, REQUIRED unless scope is "public"
+//   - cid string: class to search, REQUIRED if scope is "class"
+//   - limit int: maximum number of results, defaults to 20
+//
+// Returns status 200 OK with an array of results ordered by
+// relevance.
+func SearchPrograms(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+
+	query, scope, uid := c.QueryParam("q"), c.QueryParam("scope"), c.QueryParam("uid")
+	if query == "" {
+		return c.String(http.StatusBadRequest, "`q` is a required query parameter.")
+	}
+
+	limit := defaultSearchLimit
+	if l := c.QueryParam("limit"); l != "" {
+		n, err := strconv.Atoi(l)
+		if err != nil || n <= 0 || n > maxSearchLimit {
+			return c.String(http.StatusBadRequest, fmt.Sprintf("`limit` must be between 1 and %d.", maxSearchLimit))
+		}
+		limit = n
+	}
+
+	var (
+		pids   []string
+		public bool
+	)
+	switch scope {
+	case "", "own":
+		if uid == "" {
+			return c.String(http.StatusBadRequest, "`uid` is a required query parameter.")
 		}
-		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to commit transaction to database").Error())
+		user, err := c.LoadUser(c.Request().Context(), uid)
+		if err != nil {
+			return c.String(http.StatusNotFound, "Failed to load user.")
+		}
+		pids = user.Programs
+	case "class":
+		cid := c.QueryParam("cid")
+		if uid == "" || cid == "" {
+			return c.String(http.StatusBadRequest, "`uid` and `cid` are required query parameters.")
+		}
+		class, err := c.LoadClass(c.Request().Context(), cid)
+		if err != nil {
+			return c.String(http.StatusNotFound, "Failed to load class.")
+		}
+		if isIn, _ := classRole(class, uid); !isIn {
+			return c.String(http.StatusBadRequest, "given user not in class")
+		}
+		pids = class.Programs
+	case "public":
+		public = true
+	default:
+		return c.String(http.StatusBadRequest, "`scope` must be one of own, class or public.")
+	}
+
+	matches, err := c.SearchPrograms(c.Request().Context(), query, pids, public)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to search programs").Error())
+	}
+
+	type result struct {
+		db.SearchResult
+		Program db.Program `json:"program"`
 	}
+	results := make([]result, 0, limit)
+	for _, m := range matches {
+		if len(results) == limit {
+			break
+		}
+		p, err := c.LoadProgram(c.Request().Context(), m.PID)
+		if err != nil {
+			c.Logger().Warnf("Search index references missing program with pid `%s`", m.PID)
+			continue
+		}
+		results = append(results, result{m, p})
+	}
+	return c.JSON(http.StatusOK, results)
+}
 
+// PublishProgram makes a program owned by the requester visible
+// to public searches, or hides it again.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "pid": REQUIRED
+//	    "public": bool
+//	}
+//
+// Returns status 200 OK on success.
+func PublishProgram(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		PID    string `json:"pid"`
+		Public bool   `json:"public"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" {
+		return c.String(http.StatusBadRequest, "uid and pid fields are both required")
+	}
+
+	u, err := c.LoadUser(c.Request().Context(), req.UID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "user does not exist")
+	}
+	u.UID = req.UID
+
+	p, err := c.LoadProgram(c.Request().Context(), req.PID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "program does not exist")
+	}
+	p.UID = req.PID
+	if !p.IsOwnedBy(u) {
+		return c.String(http.StatusForbidden, "only the owner can publish a program")
+	}
+	p.Public = req.Public
+	if err := c.StoreProgram(c.Request().Context(), p); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to update program").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// TransferProgram transfers ownership of a program to another
+// user, moving it from the owner's program list to theirs.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the current owner
+//	    "pid": REQUIRED
+//	    "to": REQUIRED, the new owner
+//	}
+//
+// Returns status 200 OK on success.
+func TransferProgram(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		PID string `json:"pid"`
+		To  string `json:"to"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" || req.To == "" {
+		return c.String(http.StatusBadRequest, "uid, pid and to fields are all required")
+	}
+	if req.UID == req.To {
+		return c.String(http.StatusBadRequest, "program is already owned by the given user")
+	}
+
+	if err := c.TransferProgram(c.Request().Context(), req.PID, req.UID, req.To); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to transfer program").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// AddCollaborator shares a program with another user, who may
+// then edit it and will find it in their program list.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the owner
+//	    "pid": REQUIRED
+//	    "collaborator": REQUIRED
+//	}
+//
+// Returns status 200 OK on success.
+func AddCollaborator(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID          string `json:"uid"`
+		PID          string `json:"pid"`
+		Collaborator string `json:"collaborator"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" || req.Collaborator == "" {
+		return c.String(http.StatusBadRequest, "uid, pid and collaborator fields are all required")
+	}
+
+	if err := c.AddProgramCollaborator(c.Request().Context(), req.PID, req.UID, req.Collaborator); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to add collaborator").Error())
+	}
+	return c.String(http.StatusOK, "")
+}
+
+// RemoveCollaborator revokes a collaborator's access to a program.
+// The program's owner may remove any collaborator, and collaborators
+// may remove themselves.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the owner or the collaborator
+//	    "pid": REQUIRED
+//	    "collaborator": REQUIRED
+//	}
+//
+// Returns status 200 OK on success.
+func RemoveCollaborator(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID          string `json:"uid"`
+		PID          string `json:"pid"`
+		Collaborator string `json:"collaborator"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.PID == "" || req.Collaborator == "" {
+		return c.String(http.StatusBadRequest, "uid, pid and collaborator fields are all required")
+	}
+
+	if err := c.RemoveProgramCollaborator(c.Request().Context(), req.PID, req.UID, req.Collaborator); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to remove collaborator").Error())
+	}
 	return c.String(http.StatusOK, "")
 }
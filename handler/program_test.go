@@ -1,11 +1,13 @@
 package handler_test
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
 
@@ -157,3 +159,441 @@ func TestCreateProgram(t *testing.T) {
 		// }
 	})
 }
+
+func TestLikeProgram(t *testing.T) {
+	t.Run("MissingFields", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.LikeProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("ProgramDNE", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test"}))
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test", "pid": "test"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.LikeProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+	t.Run("Toggle", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test"}))
+
+		res := struct {
+			Liked bool  `json:"liked"`
+			Likes int64 `json:"likes"`
+		}{}
+		for _, expected := range []bool{true, false, true} {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "test", "pid": "test"}`))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			require.NoError(t, handler.LikeProgram(&db.DBContext{
+				Context: c,
+				TLADB:   d,
+			}))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+			assert.Equal(t, expected, res.Liked)
+		}
+		assert.Equal(t, int64(1), res.Likes)
+
+		u, err := d.LoadUser(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test"}, u.LikedPrograms)
+	})
+}
+
+func TestProgramStats(t *testing.T) {
+	t.Run("MissingPID", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.GetProgramStats(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("ViewProgramDNE", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"pid": "test"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.ViewProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+	})
+	t.Run("CountsViews", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test"}))
+		for i := 0; i < 3; i++ {
+			req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"pid": "test"}`))
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			require.NoError(t, handler.ViewProgram(&db.DBContext{
+				Context: c,
+				TLADB:   d,
+			}))
+			require.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/?pid=test", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.GetProgramStats(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			stats := db.ProgramStats{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &stats))
+			assert.Equal(t, int64(3), stats.Views)
+			assert.Zero(t, stats.Likes)
+		}
+	})
+}
+
+func TestSearchPrograms(t *testing.T) {
+	t.Run("MissingQuery", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodGet, "/?uid=test", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.SearchPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("BadScope", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodGet, "/?q=turtle&scope=everything", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.SearchPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("Own", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "test",
+			Programs: []string{"spiral", "square"},
+		}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "spiral", Name: "the turtle spiral one"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "square", Name: "square", Code: "import turtle"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "notMine", Name: "turtle spiral"}))
+		req := httptest.NewRequest(http.MethodGet, "/?uid=test&q=turtle+spiral", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.SearchPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			results := []struct {
+				PID     string     `json:"pid"`
+				Program db.Program `json:"program"`
+			}{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+			require.Len(t, results, 2)
+			assert.Equal(t, "spiral", results[0].PID)
+			assert.Equal(t, "the turtle spiral one", results[0].Program.Name)
+			assert.Equal(t, "square", results[1].PID)
+		}
+	})
+	t.Run("ClassNonMember", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreClass(context.Background(), db.Class{
+			CID:     "test",
+			Members: []string{"member"},
+		}))
+		req := httptest.NewRequest(http.MethodGet, "/?uid=outsider&cid=test&scope=class&q=turtle", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.SearchPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("PublishThenSearch", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "test",
+			Programs: []string{"spiral"},
+		}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "spiral", Name: "turtle spiral"}))
+
+		search := func() int {
+			req := httptest.NewRequest(http.MethodGet, "/?scope=public&q=spiral", nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			require.NoError(t, handler.SearchPrograms(&db.DBContext{
+				Context: c,
+				TLADB:   d,
+			}))
+			require.Equal(t, http.StatusOK, rec.Code)
+			results := []db.SearchResult{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+			return len(results)
+		}
+		assert.Zero(t, search())
+
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "spiral", "public": true}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		require.NoError(t, handler.PublishProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		}))
+		require.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, 1, search())
+	})
+	t.Run("PublishNotOwned", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "spiral"}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "spiral", "public": true}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.PublishProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+	t.Run("PublishAsCollaborator", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test", Programs: []string{"spiral"}}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "spiral", Owner: "owner", Collaborators: []string{"test"}}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "test", "pid": "spiral", "public": true}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.PublishProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+}
+
+func TestTransferProgram(t *testing.T) {
+	setup := func(t *testing.T) *db.MockDB {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "owner",
+			Programs: []string{"test"},
+		}))
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "other"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{
+			UID:   "test",
+			Owner: "owner",
+		}))
+		return d
+	}
+
+	t.Run("MissingFields", func(t *testing.T) {
+		d := setup(t)
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "owner", "pid": "test"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.TransferProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("NotOwner", func(t *testing.T) {
+		d := setup(t)
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "other", "pid": "test", "to": "owner"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.TransferProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusForbidden, rec.Code)
+		}
+	})
+	t.Run("RecipientDNE", func(t *testing.T) {
+		d := setup(t)
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "owner", "pid": "test", "to": "nobody"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.TransferProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+			p, err := d.LoadProgram(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, "owner", p.Owner)
+		}
+	})
+	t.Run("Valid", func(t *testing.T) {
+		d := setup(t)
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "owner", "pid": "test", "to": "other"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.TransferProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			p, err := d.LoadProgram(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, "other", p.Owner)
+
+			owner, err := d.LoadUser(context.Background(), "owner")
+			require.NoError(t, err)
+			assert.Empty(t, owner.Programs)
+			other, err := d.LoadUser(context.Background(), "other")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"test"}, other.Programs)
+		}
+	})
+	t.Run("LegacyProgram", func(t *testing.T) {
+		d := setup(t)
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "test"}))
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(`{"uid": "owner", "pid": "test", "to": "other"}`))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.TransferProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			p, err := d.LoadProgram(context.Background(), "test")
+			require.NoError(t, err)
+			assert.Equal(t, "other", p.Owner)
+		}
+	})
+}
+
+func TestCollaborators(t *testing.T) {
+	setup := func(t *testing.T) *db.MockDB {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "owner",
+			Programs: []string{"test"},
+		}))
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "collaborator"}))
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "other"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{
+			UID:   "test",
+			Owner: "owner",
+		}))
+		return d
+	}
+	call := func(t *testing.T, d *db.MockDB, h echo.HandlerFunc, body string) int {
+		req := httptest.NewRequest(http.MethodPut, "/", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		require.NoError(t, h(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		}))
+		return rec.Code
+	}
+
+	t.Run("AddNotOwner", func(t *testing.T) {
+		d := setup(t)
+		assert.Equal(t, http.StatusForbidden, call(t, d, handler.AddCollaborator, `{"uid": "other", "pid": "test", "collaborator": "other"}`))
+	})
+	t.Run("AddSelf", func(t *testing.T) {
+		d := setup(t)
+		assert.Equal(t, http.StatusBadRequest, call(t, d, handler.AddCollaborator, `{"uid": "owner", "pid": "test", "collaborator": "owner"}`))
+	})
+	t.Run("AddThenLeave", func(t *testing.T) {
+		d := setup(t)
+		require.Equal(t, http.StatusOK, call(t, d, handler.AddCollaborator, `{"uid": "owner", "pid": "test", "collaborator": "collaborator"}`))
+
+		p, err := d.LoadProgram(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"collaborator"}, p.Collaborators)
+		u, err := d.LoadUser(context.Background(), "collaborator")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test"}, u.Programs)
+
+		// only the owner or the collaborator may remove them.
+		assert.Equal(t, http.StatusForbidden, call(t, d, handler.RemoveCollaborator, `{"uid": "other", "pid": "test", "collaborator": "collaborator"}`))
+		require.Equal(t, http.StatusOK, call(t, d, handler.RemoveCollaborator, `{"uid": "collaborator", "pid": "test", "collaborator": "collaborator"}`))
+
+		p, err = d.LoadProgram(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Empty(t, p.Collaborators)
+		u, err = d.LoadUser(context.Background(), "collaborator")
+		require.NoError(t, err)
+		assert.Empty(t, u.Programs)
+	})
+	t.Run("CollaboratorCannotDelete", func(t *testing.T) {
+		d := setup(t)
+		require.Equal(t, http.StatusOK, call(t, d, handler.AddCollaborator, `{"uid": "owner", "pid": "test", "collaborator": "collaborator"}`))
+		assert.Equal(t, http.StatusForbidden, call(t, d, handler.DeleteProgram, `{"uid": "collaborator", "pid": "test"}`))
+		_, err := d.LoadProgram(context.Background(), "test")
+		assert.NoError(t, err)
+	})
+	t.Run("OwnerDeletes", func(t *testing.T) {
+		d := setup(t)
+		require.Equal(t, http.StatusOK, call(t, d, handler.AddCollaborator, `{"uid": "owner", "pid": "test", "collaborator": "collaborator"}`))
+		u, err := d.LoadUser(context.Background(), "collaborator")
+		require.NoError(t, err)
+		u.OpenedProgram("test", time.Now())
+		require.NoError(t, d.StoreUser(context.Background(), u))
+
+		require.Equal(t, http.StatusOK, call(t, d, handler.DeleteProgram, `{"uid": "owner", "pid": "test"}`))
+		_, err = d.LoadProgram(context.Background(), "test")
+		assert.Error(t, err)
+		for _, uid := range []string{"owner", "collaborator"} {
+			u, err := d.LoadUser(context.Background(), uid)
+			require.NoError(t, err)
+			assert.Empty(t, u.Programs, uid)
+			assert.Empty(t, u.RecentPrograms, uid)
+			assert.Empty(t, u.MostRecentProgram, uid)
+		}
+	})
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"github.com/uclaacm/teach-la-go-backend/jobs"
+)
+
+// PushProgram returns a handler that gives every member of a class
+// their own copy of a program, such as starter code handed out
+// mid-lesson. Copies are made in the background as a job run by r,
+// whose progress can be followed with GetPushJob. Pushing the same
+// program again with the same key returns the running or finished job rather
+// than copying twice, and retrying a failed push only copies to
+// the members it missed. Only instructors of the class who can
+// edit the program may push it.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "pid": REQUIRED, the program to push
+//	    "key": REQUIRED, an idempotency key chosen by the client
+//	}
+//
+// Returns: Status 202 with the marshalled Job.
+func PushProgram(r *jobs.Registry) echo.HandlerFunc {
+	return func(cc echo.Context) error {
+		c := cc.(*db.DBContext)
+		var req struct {
+			UID string `json:"uid"`
+			CID string `json:"cid"`
+			PID string `json:"pid"`
+			Key string `json:"key"`
+		}
+		if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+		}
+		if req.UID == "" || req.CID == "" || req.PID == "" || req.Key == "" {
+			return c.String(http.StatusBadRequest, "uid, cid, pid and key fields are all required")
+		}
+
+		class, err := c.LoadClass(c.Request().Context(), req.CID)
+		if err != nil {
+			return c.String(http.StatusNotFound, "class does not exist")
+		}
+		if _, isInstructor := classRole(class, req.UID); !isInstructor {
+			return c.String(http.StatusForbidden, "only instructors can push programs")
+		}
+		if err := class.CheckActive(); err != nil {
+			return statusError(c, err)
+		}
+
+		p, err := c.LoadProgram(c.Request().Context(), req.PID)
+		if err != nil {
+			return c.String(http.StatusNotFound, "program does not exist")
+		}
+		user, err := c.LoadUser(c.Request().Context(), req.UID)
+		if err != nil {
+			return c.String(http.StatusNotFound, "user does not exist")
+		}
+		user.UID = req.UID
+		if !p.CanEdit(user) {
+			return c.String(http.StatusForbidden, "only the program's owner or collaborators can push it")
+		}
+
+		// the job outlives the request, so it mustn't touch c.
+		d, logger := c.TLADB, c.Logger()
+		// a key reused for another program is a different push.
+		job, _ := r.Start(class.CID, req.PID+"\x00"+req.Key, func(ctx context.Context, progress jobs.Progress) error {
+			err := db.PushProgram(ctx, d, class, req.PID, p, req.Key, progress)
+			if err != nil {
+				logger.Errorf("Failed to push pid `%s` to class with cid `%s`: %v", req.PID, class.CID, err)
+			}
+			return err
+		})
+		return c.JSON(http.StatusAccepted, &job)
+	}
+}
+
+// GetPushJob returns a handler reporting the progress of a push
+// started with PushProgram. Only instructors of the class may
+// follow it.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "job": REQUIRED, ID of the job
+//	}
+//
+// Returns: Status 200 with the marshalled Job.
+func GetPushJob(r *jobs.Registry) echo.HandlerFunc {
+	return func(cc echo.Context) error {
+		c := cc.(*db.DBContext)
+		var req struct {
+			UID string `json:"uid"`
+			CID string `json:"cid"`
+			Job string `json:"job"`
+		}
+		if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+		}
+		if req.UID == "" || req.CID == "" || req.Job == "" {
+			return c.String(http.StatusBadRequest, "uid, cid and job fields are all required")
+		}
+
+		class, err := c.LoadClass(c.Request().Context(), req.CID)
+		if err != nil {
+			return c.String(http.StatusNotFound, "class does not exist")
+		}
+		if _, isInstructor := classRole(class, req.UID); !isInstructor {
+			return c.String(http.StatusForbidden, "only instructors can follow a push")
+		}
+
+		job, ok := r.Get(class.CID, req.Job)
+		if !ok {
+			return c.String(http.StatusNotFound, "job does not exist")
+		}
+		return c.JSON(http.StatusOK, &job)
+	}
+}
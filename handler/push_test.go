@@ -0,0 +1,79 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+	"github.com/uclaacm/teach-la-go-backend/jobs"
+)
+
+func TestPushProgram(t *testing.T) {
+	d := openAssignmentMock(t)
+	starter, err := d.LoadProgram(context.Background(), "starter")
+	require.NoError(t, err)
+	starter.Owner = "teacher"
+	require.NoError(t, d.StoreProgram(context.Background(), starter))
+	r := jobs.NewRegistry()
+
+	t.Run("MissingKey", func(t *testing.T) {
+		rec := callHandler(t, d, handler.PushProgram(r), `{"uid": "teacher", "cid": "test", "pid": "starter"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("NotInstructor", func(t *testing.T) {
+		rec := callHandler(t, d, handler.PushProgram(r), `{"uid": "alice", "cid": "test", "pid": "starter", "key": "k"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		body := `{"uid": "teacher", "cid": "test", "pid": "starter", "key": "lesson-1"}`
+		rec := callHandler(t, d, handler.PushProgram(r), body)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		job := jobs.Job{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		_, err := r.Wait(context.Background(), job.ID)
+		require.NoError(t, err)
+
+		// retrying returns the same job.
+		rec = callHandler(t, d, handler.PushProgram(r), body)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		again := jobs.Job{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &again))
+		assert.Equal(t, job.ID, again.ID)
+
+		rec = callHandler(t, d, handler.GetPushJob(r), `{"uid": "teacher", "cid": "test", "job": "`+job.ID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		assert.Equal(t, jobs.Done, job.State)
+		assert.Equal(t, 2, job.Done)
+		assert.Equal(t, 2, job.Total)
+
+		for _, uid := range []string{"alice", "bob"} {
+			u, err := d.LoadUser(context.Background(), uid)
+			require.NoError(t, err)
+			assert.Len(t, u.Programs, 1)
+		}
+	})
+	t.Run("KeyReused", func(t *testing.T) {
+		other := starter
+		other.UID = "other"
+		require.NoError(t, d.StoreProgram(context.Background(), other))
+		rec := callHandler(t, d, handler.PushProgram(r), `{"uid": "teacher", "cid": "test", "pid": "other", "key": "lesson-1"}`)
+		require.Equal(t, http.StatusAccepted, rec.Code, rec.Body.String())
+		job := jobs.Job{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &job))
+		_, err := r.Wait(context.Background(), job.ID)
+		require.NoError(t, err)
+
+		u, err := d.LoadUser(context.Background(), "alice")
+		require.NoError(t, err)
+		assert.Len(t, u.Programs, 2)
+	})
+	t.Run("JobDNE", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetPushJob(r), `{"uid": "teacher", "cid": "test", "job": "nope"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
@@ -0,0 +1,175 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// acceptInvitations enrolls u, who must already be stored, in every
+// class with a pending invitation for their UID or email. Classes
+// u may no longer join, such as ones they were banned from, are
+// skipped. The caller stores u.
+func acceptInvitations(c *db.DBContext, u *db.User) error {
+	entries := []string{u.UID}
+	if u.Email != "" {
+		entries = append(entries, u.Email)
+	}
+
+	for _, entry := range entries {
+		classes, err := c.LoadInvitedClasses(c.Request().Context(), entry)
+		if err != nil {
+			return err
+		}
+		for _, class := range classes {
+			_, err := c.EnrollClassMember(c.Request().Context(), class.CID, u.UID)
+			switch status.Code(err) {
+			case codes.OK:
+				addClassToUser(u, class.CID)
+			case codes.PermissionDenied, codes.FailedPrecondition:
+				c.Logger().Debugf("Skipped invitation of uid `%s` to class with cid `%s`: %v", u.UID, class.CID, err)
+			default:
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ImportRoster enrolls the users listed in an uploaded CSV roster
+// in a class. The first column of each row holds either a UID or
+// an email. Listed users without an account are sent a pending
+// invitation instead, and are enrolled when they sign up. Only
+// instructors of the class may import a roster.
+//
+// Request Body (multipart/form-data):
+//   - uid: REQUIRED
+//   - cid: REQUIRED
+//   - roster: REQUIRED CSV file
+//
+// Users are enrolled as if approved by the instructor, so the join
+// code and approval queue don't apply, but banned users are refused,
+// as is everyone once the class is full.
+//
+// Returns: Status 200 with the UIDs of the users enrolled, the
+// entries invited, the UIDs of users already in the class, and the
+// UIDs of users refused.
+func ImportRoster(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	uid, cid := c.FormValue("uid"), c.FormValue("cid")
+	if uid == "" || cid == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+	fh, err := c.FormFile("roster")
+	if err != nil {
+		return c.String(http.StatusBadRequest, "roster file is required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), cid)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, uid); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can import a roster")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	f, err := fh.Open()
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to open roster").Error())
+	}
+	defer f.Close()
+	entries, err := db.ParseRoster(f)
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	resp := struct {
+		Enrolled        []string `json:"enrolled"`
+		Invited         []string `json:"invited"`
+		AlreadyEnrolled []string `json:"alreadyEnrolled"`
+		Refused         []string `json:"refused"`
+	}{
+		Enrolled:        []string{},
+		Invited:         []string{},
+		AlreadyEnrolled: []string{},
+		Refused:         []string{},
+	}
+	for _, entry := range entries {
+		var u db.User
+		if db.IsEmail(entry) {
+			u, err = c.LoadUserByEmail(c.Request().Context(), entry)
+		} else {
+			u, err = c.LoadUser(c.Request().Context(), entry)
+			u.UID = entry
+		}
+		if err != nil {
+			resp.Invited = append(resp.Invited, entry)
+			continue
+		}
+
+		enrolled, err := c.EnrollClassMember(c.Request().Context(), class.CID, u.UID)
+		switch {
+		case status.Code(err) == codes.PermissionDenied, status.Code(err) == codes.FailedPrecondition:
+			resp.Refused = append(resp.Refused, u.UID)
+		case err != nil:
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to enroll user").Error())
+		case enrolled:
+			resp.Enrolled = append(resp.Enrolled, u.UID)
+		default:
+			resp.AlreadyEnrolled = append(resp.AlreadyEnrolled, u.UID)
+		}
+	}
+
+	if len(resp.Invited) != 0 {
+		if err := c.InviteClassUsers(c.Request().Context(), class.CID, resp.Invited); err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to invite users").Error())
+		}
+	}
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// ExportRoster streams the instructors and members of a class as
+// CSV, with their display names, emails and join dates. Only
+// instructors of the class may export the roster, and TAs only get
+// the members of the sections they lead.
+//
+// Query parameters:
+//   - uid string: REQUIRED requester
+//   - cid string: REQUIRED class to export
+//
+// Returns status 200 OK with a text/csv attachment.
+func ExportRoster(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	uid, cid := c.QueryParam("uid"), c.QueryParam("cid")
+	if uid == "" || cid == "" {
+		return c.String(http.StatusBadRequest, "`uid` and `cid` are required query parameters.")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), cid)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, uid); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can export the roster")
+	}
+	if class.Members, err = class.MembersVisibleTo(uid, ""); err != nil {
+		return statusError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "text/csv")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="roster-`+class.CID+`.csv"`)
+	c.Response().WriteHeader(http.StatusOK)
+	if err := db.WriteRoster(c.Request().Context(), c, class, c.Response()); err != nil {
+		// the status has already been sent, so all we can do
+		// is cut the response short.
+		c.Logger().Errorf("Failed to export roster for class with cid `%s`: %v", class.CID, err)
+	}
+	return nil
+}
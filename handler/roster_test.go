@@ -0,0 +1,142 @@
+package handler_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+// importRoster uploads roster to ImportRoster on behalf of uid.
+func importRoster(t *testing.T, d db.TLADB, uid, roster string) *httptest.ResponseRecorder {
+	body := &bytes.Buffer{}
+	w := multipart.NewWriter(body)
+	require.NoError(t, w.WriteField("uid", uid))
+	require.NoError(t, w.WriteField("cid", "test"))
+	f, err := w.CreateFormFile("roster", "roster.csv")
+	require.NoError(t, err)
+	_, err = f.Write([]byte(roster))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	req := httptest.NewRequest(http.MethodPost, "/", body)
+	req.Header.Set(echo.HeaderContentType, w.FormDataContentType())
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, handler.ImportRoster(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	}))
+	return rec
+}
+
+func TestImportRoster(t *testing.T) {
+	t.Run("NotInstructor", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := importRoster(t, d, "alice", "uid\ncarol\n")
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "carol"}))
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "dave", Email: "dave@ucla.edu"}))
+
+		rec := importRoster(t, d, "teacher", "uid\ncarol\nDave@UCLA.edu\nalice\nerin@ucla.edu\nfrank\n")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		resp := struct {
+			Enrolled        []string `json:"enrolled"`
+			Invited         []string `json:"invited"`
+			AlreadyEnrolled []string `json:"alreadyEnrolled"`
+		}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, []string{"carol", "dave"}, resp.Enrolled)
+		assert.Equal(t, []string{"erin@ucla.edu", "frank"}, resp.Invited)
+		assert.Equal(t, []string{"alice"}, resp.AlreadyEnrolled)
+
+		class, err := d.LoadClass(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"alice", "bob", "carol", "dave"}, class.Members)
+		assert.Contains(t, class.JoinDates, "dave")
+		assert.Equal(t, []string{"erin@ucla.edu", "frank"}, class.Invitations)
+		u, err := d.LoadUser(context.Background(), "dave")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test"}, u.Classes)
+
+		// invited users are enrolled when they sign up.
+		req := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(`{"uid": "erin", "email": "erin@ucla.edu"}`))
+		rec = httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		require.NoError(t, handler.CreateUser(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		}))
+		require.Equal(t, http.StatusCreated, rec.Code)
+		u, err = d.LoadUser(context.Background(), "erin")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"test"}, u.Classes)
+		class, err = d.LoadClass(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Contains(t, class.Members, "erin")
+		assert.Equal(t, []string{"frank"}, class.Invitations)
+	})
+	t.Run("Banned", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		class, err := d.LoadClass(context.Background(), "test")
+		require.NoError(t, err)
+		class.Banned = []string{"carol"}
+		require.NoError(t, d.StoreClass(context.Background(), class))
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "carol"}))
+
+		rec := importRoster(t, d, "teacher", "uid\ncarol\n")
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		resp := struct {
+			Enrolled []string `json:"enrolled"`
+			Refused  []string `json:"refused"`
+		}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Empty(t, resp.Enrolled)
+		assert.Equal(t, []string{"carol"}, resp.Refused)
+
+		class, err = d.LoadClass(context.Background(), "test")
+		require.NoError(t, err)
+		assert.NotContains(t, class.Members, "carol")
+	})
+}
+
+func TestExportRoster(t *testing.T) {
+	d := openAssignmentMock(t)
+
+	req := httptest.NewRequest(http.MethodGet, "/?uid=alice&cid=test", nil)
+	rec := httptest.NewRecorder()
+	c := echo.New().NewContext(req, rec)
+	require.NoError(t, handler.ExportRoster(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	}))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req = httptest.NewRequest(http.MethodGet, "/?uid=teacher&cid=test", nil)
+	rec = httptest.NewRecorder()
+	c = echo.New().NewContext(req, rec)
+	require.NoError(t, handler.ExportRoster(&db.DBContext{
+		Context: c,
+		TLADB:   d,
+	}))
+	require.Equal(t, http.StatusOK, rec.Code)
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+	assert.Equal(t, []string{"teacher", "", "", "instructor", ""}, rows[1])
+	assert.Equal(t, "alice", rows[2][0])
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+)
+
+// SetSection creates a section within a class, or replaces an
+// existing one. Members listed are moved out of any other section
+// they were in, and TAs must be instructors of the class. Only the
+// creator of the class may manage sections, since TAs only see the
+// members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the creator of the class
+//	    "cid": REQUIRED
+//	    "section": ID of the section to replace, a new one if omitted
+//	    "name": REQUIRED
+//	    "members": UIDs of the section's members
+//	    "tas": UIDs of the instructors leading the section
+//	}
+//
+// Returns: Status 200 with the marshalled sections of the class.
+func SetSection(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string   `json:"uid"`
+		CID     string   `json:"cid"`
+		Section string   `json:"section"`
+		Name    string   `json:"name"`
+		Members []string `json:"members"`
+		TAs     []string `json:"tas"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.UID == "" || req.CID == "" || req.Name == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and name fields are all required")
+	}
+	if req.Section == "" {
+		req.Section = uuid.New().String()
+	}
+
+	if err := c.SetClassSection(c.Request().Context(), req.CID, req.UID, db.Section{
+		ID:      req.Section,
+		Name:    req.Name,
+		TAs:     req.TAs,
+		Members: req.Members,
+	}); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to set section").Error())
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load class").Error())
+	}
+	return c.JSON(http.StatusOK, &class.Sections)
+}
+
+// DeleteSection removes a section from a class. Its members stay in
+// the class, but assignments listed only for it are hidden from
+// members until they are listed for another section. Only the
+// creator of the class may delete sections.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, the creator of the class
+//	    "cid": REQUIRED
+//	    "section": REQUIRED
+//	}
+//
+// Returns status 200 OK on deletion.
+func DeleteSection(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string `json:"uid"`
+		CID     string `json:"cid"`
+		Section string `json:"section"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.Section == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and section fields are all required")
+	}
+
+	if err := c.DeleteClassSection(c.Request().Context(), req.CID, req.UID, req.Section); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to delete section").Error())
+	}
+	return c.String(http.StatusOK, "section deleted successfully")
+}
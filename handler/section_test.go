@@ -0,0 +1,269 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+// openSectionMock returns a MockDB from openAssignmentMock with a
+// TA "ta" leading section "a", which holds "alice".
+func openSectionMock(t *testing.T) *db.MockDB {
+	d := openAssignmentMock(t)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	class.Instructors = append(class.Instructors, "ta")
+	class.JoinDates = map[string]time.Time{"alice": time.Now(), "bob": time.Now()}
+	require.NoError(t, d.StoreClass(context.Background(), class))
+	require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "ta"}))
+
+	rec := callHandler(t, d, handler.SetSection, `{"uid": "teacher", "cid": "test", "section": "a", "name": "Section A", "members": ["alice"], "tas": ["ta"]}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	return d
+}
+
+func TestSetSection(t *testing.T) {
+	t.Run("MissingName", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.SetSection, `{"uid": "teacher", "cid": "test"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("NotInstructor", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.SetSection, `{"uid": "alice", "cid": "test", "name": "A"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("NotCreator", func(t *testing.T) {
+		d := openSectionMock(t)
+		rec := callHandler(t, d, handler.SetSection, `{"uid": "ta", "cid": "test", "section": "a", "name": "A", "members": ["alice"]}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callHandler(t, d, handler.DeleteSection, `{"uid": "ta", "cid": "test", "section": "a"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("NotMember", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.SetSection, `{"uid": "teacher", "cid": "test", "name": "A", "members": ["dave"]}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		rec := callHandler(t, d, handler.SetSection, `{"uid": "teacher", "cid": "test", "name": "A", "members": ["bob"]}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		sections := []db.Section{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &sections))
+		require.Len(t, sections, 1)
+		assert.NotEmpty(t, sections[0].ID)
+		assert.Equal(t, []string{"bob"}, sections[0].Members)
+
+		rec = callHandler(t, d, handler.DeleteSection, `{"uid": "teacher", "cid": "test", "section": "`+sections[0].ID+`"}`)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		rec = callHandler(t, d, handler.DeleteSection, `{"uid": "teacher", "cid": "test", "section": "`+sections[0].ID+`"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestGetClassSection(t *testing.T) {
+	d := openSectionMock(t)
+	getClass := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/?userData=true", strings.NewReader(body))
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler.GetClass(&db.DBContext{
+			Context: echo.New().NewContext(req, rec),
+			TLADB:   d,
+		}))
+		return rec
+	}
+	var res struct {
+		Members   []string             `json:"members"`
+		Sections  []db.Section         `json:"sections"`
+		JoinDates map[string]time.Time `json:"joinDates"`
+		UserData  map[string]db.User   `json:"userData"`
+	}
+
+	t.Run("TA", func(t *testing.T) {
+		rec := getClass(`{"uid": "ta", "cid": "test"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, []string{"alice"}, res.Members)
+		assert.Contains(t, res.UserData, "alice")
+		assert.NotContains(t, res.UserData, "bob")
+		require.Len(t, res.Sections, 1)
+		assert.Equal(t, "a", res.Sections[0].ID)
+		assert.Contains(t, res.JoinDates, "alice")
+		assert.NotContains(t, res.JoinDates, "bob")
+	})
+	t.Run("Filtered", func(t *testing.T) {
+		rec := getClass(`{"uid": "teacher", "cid": "test", "section": "a"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+		assert.Equal(t, []string{"alice"}, res.Members)
+	})
+	t.Run("SectionDNE", func(t *testing.T) {
+		rec := getClass(`{"uid": "teacher", "cid": "test", "section": "nope"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
+
+func TestListAssignmentsSection(t *testing.T) {
+	d := openSectionMock(t)
+	createTestAssignment(t, d)
+	rec := callHandler(t, d, handler.CreateAssignment, `{"uid": "teacher", "cid": "test", "title": "Section A only", "starterProgram": "starter", "sections": ["a"]}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	a := db.Assignment{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &a))
+	rec = callHandler(t, d, handler.CreateAssignment, `{"uid": "teacher", "cid": "test", "title": "Bad", "starterProgram": "starter", "sections": ["nope"]}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	titles := func(uid, section string) []string {
+		rec := callHandler(t, d, handler.ListAssignments, `{"uid": "`+uid+`", "cid": "test", "section": "`+section+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assignments := []db.Assignment{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &assignments))
+		titles := []string{}
+		for _, a := range assignments {
+			titles = append(titles, a.Title)
+		}
+		return titles
+	}
+	assert.Equal(t, []string{"Spirals", "Section A only"}, titles("alice", ""))
+	assert.Equal(t, []string{"Spirals"}, titles("bob", ""))
+	assert.Equal(t, []string{"Spirals"}, titles("bob", "a"), "members can't pick another section")
+	assert.Equal(t, []string{"Spirals", "Section A only"}, titles("teacher", ""))
+
+	t.Run("OtherSection", func(t *testing.T) {
+		for _, h := range []echo.HandlerFunc{handler.GetAssignment, handler.OpenAssignment, handler.SubmitAssignment} {
+			rec := callHandler(t, d, h, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+			assert.Equal(t, http.StatusNotFound, rec.Code)
+		}
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	})
+}
+
+func TestExportGradebookSection(t *testing.T) {
+	d := openSectionMock(t)
+	createTestAssignment(t, d)
+
+	req := httptest.NewRequest(http.MethodGet, "/?uid=ta&cid=test", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.ExportGradebook(&db.DBContext{
+		Context: echo.New().NewContext(req, rec),
+		TLADB:   d,
+	}))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, "alice", rows[1][0])
+}
+
+func TestExportRosterSection(t *testing.T) {
+	d := openSectionMock(t)
+	req := httptest.NewRequest(http.MethodGet, "/?uid=ta&cid=test", nil)
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.ExportRoster(&db.DBContext{
+		Context: echo.New().NewContext(req, rec),
+		TLADB:   d,
+	}))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	rows, err := csv.NewReader(rec.Body).ReadAll()
+	require.NoError(t, err)
+	members := []string{}
+	for _, row := range rows[1:] {
+		if row[3] == "member" {
+			members = append(members, row[0])
+		}
+	}
+	assert.Equal(t, []string{"alice"}, members)
+}
+
+func TestRemoveMemberSection(t *testing.T) {
+	d := openSectionMock(t)
+	rec := callHandler(t, d, handler.BanMember, `{"uid": "ta", "cid": "test", "member": "bob"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.RemoveMember, `{"uid": "ta", "cid": "test", "member": "alice"}`)
+	assert.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+}
+
+func TestListJoinRequestsSection(t *testing.T) {
+	d := openSectionMock(t)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	class.Pending = []db.JoinRequest{{UID: "dave", RequestedAt: time.Now()}}
+	require.NoError(t, d.StoreClass(context.Background(), class))
+
+	rec := callHandler(t, d, handler.ListJoinRequests, `{"uid": "ta", "cid": "test"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	assert.JSONEq(t, `{"requests": []}`, rec.Body.String())
+}
+
+func TestSubmissionsSection(t *testing.T) {
+	d := openSectionMock(t)
+	a := createTestAssignment(t, d)
+	for _, uid := range []string{"alice", "bob"} {
+		require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: a.AID, UID: uid}))
+	}
+
+	rec := callHandler(t, d, handler.ListSubmissions, `{"uid": "ta", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	resp := struct {
+		Submissions map[string]db.Submission `json:"submissions"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Submissions, "alice")
+	assert.NotContains(t, resp.Submissions, "bob")
+
+	rec = callHandler(t, d, handler.GetSubmission, `{"uid": "ta", "aid": "`+a.AID+`", "student": "bob"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.GradeSubmission, `{"uid": "ta", "aid": "`+a.AID+`", "student": "bob"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	rec = callHandler(t, d, handler.GetSubmission, `{"uid": "ta", "aid": "`+a.AID+`", "student": "alice"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	rec = callHandler(t, d, handler.GetAssignmentCopies, `{"uid": "ta", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	copies := struct {
+		NotStarted []string `json:"notStarted"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &copies))
+	assert.Equal(t, []string{"alice"}, copies.NotStarted)
+}
+
+func TestGetClassProgramsSection(t *testing.T) {
+	d := openSectionMock(t)
+	class, err := d.LoadClass(context.Background(), "test")
+	require.NoError(t, err)
+	for _, uid := range []string{"teacher", "alice", "bob"} {
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: uid + "s", Owner: uid, WID: "test"}))
+		class.Programs = append(class.Programs, uid+"s")
+	}
+	require.NoError(t, d.StoreClass(context.Background(), class))
+
+	req := httptest.NewRequest(http.MethodPost, "/?programs=true", strings.NewReader(`{"uid": "ta", "cid": "test"}`))
+	rec := httptest.NewRecorder()
+	require.NoError(t, handler.GetClass(&db.DBContext{
+		Context: echo.New().NewContext(req, rec),
+		TLADB:   d,
+	}))
+	require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+	res := struct {
+		ProgramData []db.Program `json:"programData"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &res))
+	owners := []string{}
+	for _, p := range res.ProgramData {
+		owners = append(owners, p.Owner)
+	}
+	assert.ElementsMatch(t, []string{"teacher", "alice"}, owners)
+}
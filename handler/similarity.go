@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"github.com/uclaacm/teach-la-go-backend/similarity"
+)
+
+const (
+	// defaultSimilarPairs and maxSimilarPairs are the default and
+	// largest number of pairs returned by CheckSimilarity.
+	defaultSimilarPairs = 50
+	maxSimilarPairs     = 500
+)
+
+// CheckSimilarity compares every submission for an assignment with
+// every other, ignoring the starter program, and returns the pairs
+// of members whose code is suspiciously alike, most similar first,
+// with the lines they share. Renamed variables, changed constants,
+// comments and spacing don't hide copied code. Only instructors of
+// the class may check submissions, and TAs only compare the members
+// of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "aid": REQUIRED
+//	    "minScore": lowest score between 0 and 1 of the pairs returned, 0.5 if omitted
+//	    "limit": number of pairs to return, 50 if omitted
+//	}
+//
+// Returns: Status 200 with the pairs, each naming the two members,
+// their score and the regions of code they share.
+func CheckSimilarity(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID      string  `json:"uid"`
+		AID      string  `json:"aid"`
+		MinScore float64 `json:"minScore"`
+		Limit    int     `json:"limit"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+	if req.MinScore < 0 || req.MinScore > 1 {
+		return c.String(http.StatusBadRequest, "minScore must be between 0 and 1")
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultSimilarPairs
+	}
+	if req.Limit > maxSimilarPairs {
+		req.Limit = maxSimilarPairs
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can check submissions")
+	}
+	members, err := class.MembersVisibleTo(req.UID, "")
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	submissions, err := c.LoadSubmissions(c.Request().Context(), a.AID, members)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load submissions").Error())
+	}
+	docs := []similarity.Document{}
+	for _, uid := range members {
+		if s, ok := submissions[uid]; ok {
+			docs = append(docs, similarity.Document{
+				ID:       uid,
+				Language: s.Snapshot.Language,
+				Code:     s.Snapshot.Code,
+			})
+		}
+	}
+
+	opts := similarity.DefaultOptions
+	if req.MinScore > 0 {
+		opts.MinScore = req.MinScore
+	}
+	if starter, err := c.LoadProgram(c.Request().Context(), a.StarterProgram); err == nil {
+		opts.Base = []similarity.Document{{Language: starter.Language, Code: starter.Code}}
+	}
+
+	pairs := similarity.Compare(docs, opts)
+	if len(pairs) > req.Limit {
+		pairs = pairs[:req.Limit]
+	}
+	resp := struct {
+		Pairs    []similarity.Pair `json:"pairs"`
+		Compared int               `json:"compared"`
+	}{
+		Pairs:    pairs,
+		Compared: len(docs),
+	}
+	return c.JSON(http.StatusOK, &resp)
+}
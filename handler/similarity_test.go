@@ -0,0 +1,53 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+	"github.com/uclaacm/teach-la-go-backend/similarity"
+)
+
+func TestCheckSimilarity(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	code := map[string]string{
+		"alice": "import turtle\nt = turtle.Turtle()\nfor i in range(50):\n    t.forward(i * 5)\n    t.left(91)\n",
+		"bob":   "import turtle\npen = turtle.Turtle()\nfor n in range(20):\n  pen.forward(n*2)\n  pen.left(45)\n",
+	}
+	for uid, c := range code {
+		require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{
+			AID:      a.AID,
+			UID:      uid,
+			Snapshot: db.Program{Language: "python", Code: c},
+		}))
+	}
+
+	t.Run("NotInstructor", func(t *testing.T) {
+		rec := callHandler(t, d, handler.CheckSimilarity, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+	t.Run("BadScore", func(t *testing.T) {
+		rec := callHandler(t, d, handler.CheckSimilarity, `{"uid": "teacher", "aid": "`+a.AID+`", "minScore": 2}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("Valid", func(t *testing.T) {
+		rec := callHandler(t, d, handler.CheckSimilarity, `{"uid": "teacher", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		var resp struct {
+			Pairs    []similarity.Pair `json:"pairs"`
+			Compared int               `json:"compared"`
+		}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, 2, resp.Compared)
+		require.Len(t, resp.Pairs, 1)
+		assert.Equal(t, "alice", resp.Pairs[0].A)
+		assert.Equal(t, "bob", resp.Pairs[0].B)
+		assert.NotEmpty(t, resp.Pairs[0].Regions)
+	})
+}
@@ -0,0 +1,248 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loadSnapshotClass loads the snapshot id and the class cid it was
+// taken of, failing unless uid is an instructor of that class.
+func loadSnapshotClass(c *db.DBContext, cid, id, uid string) (s db.ClassSnapshot, class db.Class, err error) {
+	if class, err = c.LoadClass(c.Request().Context(), cid); err != nil {
+		return s, class, status.Error(codes.NotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, uid); !isInstructor {
+		return s, class, status.Error(codes.PermissionDenied, "only instructors can see snapshots")
+	}
+	if s, err = c.LoadSnapshot(c.Request().Context(), id); err != nil || s.CID != class.CID {
+		return s, class, status.Error(codes.NotFound, "snapshot does not exist")
+	}
+	return s, class, nil
+}
+
+// TakeSnapshot captures the current code of every member's copy of
+// an assignment, or of a program pushed to the class, so that it
+// can be reviewed later. Exactly one of aid and key must be given.
+// Only instructors of the class may take snapshots.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "name": label of the snapshot, its time if omitted
+//	    "aid": assignment whose copies to capture
+//	    "key": key of the push whose copies to capture
+//	    "pid": the program pushed, required with key
+//	}
+//
+// Returns: Status 201 with the marshalled ClassSnapshot.
+func TakeSnapshot(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID  string `json:"uid"`
+		CID  string `json:"cid"`
+		Name string `json:"name"`
+		AID  string `json:"aid"`
+		Key  string `json:"key"`
+		PID  string `json:"pid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+	if (req.AID == "") == (req.Key == "") {
+		return c.String(http.StatusBadRequest, "exactly one of the aid and key fields is required")
+	}
+	if req.Key != "" && req.PID == "" {
+		return c.String(http.StatusBadRequest, "pid field is required with key")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can take snapshots")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	now := time.Now().UTC()
+	s := db.ClassSnapshot{
+		Name:    strings.TrimSpace(req.Name),
+		TakenBy: req.UID,
+		TakenAt: now,
+		AID:     req.AID,
+		PushPID: req.PID,
+		PushKey: req.Key,
+	}
+	if s.Name == "" {
+		s.Name = now.Format(time.RFC1123)
+	}
+
+	copies := db.PushedCopies(class, req.PID, req.Key)
+	if req.AID != "" {
+		a, err := c.LoadAssignment(c.Request().Context(), req.AID)
+		if err != nil || a.CID != class.CID {
+			return c.String(http.StatusNotFound, "assignment does not exist")
+		}
+		copies = a.Copies
+	}
+
+	if s, err = db.TakeSnapshot(c.Request().Context(), c, class, s, copies); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to take snapshot").Error())
+	}
+	return c.JSON(http.StatusCreated, &s)
+}
+
+// ListSnapshots returns the snapshots taken of a class, newest
+// first, without their code. Only instructors of the class may
+// list them.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the marshalled ClassSnapshots.
+func ListSnapshots(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		CID string `json:"cid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	if _, isInstructor := classRole(class, req.UID); !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can list snapshots")
+	}
+
+	snapshots, err := c.ListSnapshots(c.Request().Context(), class.CID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to list snapshots").Error())
+	}
+	return c.JSON(http.StatusOK, &snapshots)
+}
+
+// GetSnapshot returns a snapshot along with the code captured in
+// it, either for every member or for just one. TAs only see the
+// members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED, an instructor of the class
+//	    "cid": REQUIRED
+//	    "id": REQUIRED, ID of the snapshot
+//	    "member": UID of the member whose code to return
+//	}
+//
+// Returns: Status 200 with the marshalled ClassSnapshot.
+func GetSnapshot(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID    string `json:"uid"`
+		CID    string `json:"cid"`
+		ID     string `json:"id"`
+		Member string `json:"member"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid, cid and id fields are all required")
+	}
+
+	s, class, err := loadSnapshotClass(c, req.CID, req.ID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	members := snapshotMembersVisibleTo(s, class, req.UID)
+	if req.Member != "" {
+		if !containsMember(members, req.Member) {
+			return c.String(http.StatusNotFound, "member was not captured in the snapshot")
+		}
+		members = []string{req.Member}
+	}
+
+	if s.Programs, err = c.LoadSnapshotPrograms(c.Request().Context(), s.ID, members); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load snapshot").Error())
+	}
+	s.Members = members
+	return c.JSON(http.StatusOK, &s)
+}
+
+// DownloadSnapshot streams the code captured in a snapshot as a zip
+// archive, with one file per member. TAs only get the files of the
+// members of the sections they lead.
+//
+// Query parameters:
+//   - uid string: REQUIRED requester
+//   - cid string: REQUIRED class the snapshot was taken of
+//   - id string: REQUIRED snapshot to download
+//
+// Returns status 200 OK with an application/zip attachment.
+func DownloadSnapshot(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	uid, cid, id := c.QueryParam("uid"), c.QueryParam("cid"), c.QueryParam("id")
+	if uid == "" || cid == "" || id == "" {
+		return c.String(http.StatusBadRequest, "`uid`, `cid` and `id` are required query parameters.")
+	}
+
+	s, class, err := loadSnapshotClass(c, cid, id, uid)
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	c.Response().Header().Set(echo.HeaderContentType, "application/zip")
+	c.Response().Header().Set(echo.HeaderContentDisposition, `attachment; filename="snapshot-`+s.ID+`.zip"`)
+	c.Response().WriteHeader(http.StatusOK)
+	if err := db.WriteSnapshotZip(c.Request().Context(), c, s, snapshotMembersVisibleTo(s, class, uid), c.Response()); err != nil {
+		// the status has already been sent, so all we can do
+		// is cut the response short.
+		c.Logger().Errorf("Failed to download snapshot with id `%s`: %v", s.ID, err)
+	}
+	return nil
+}
+
+// snapshotMembersVisibleTo returns the members captured in s whose
+// code uid may see, keeping TAs to the sections they lead.
+func snapshotMembersVisibleTo(s db.ClassSnapshot, class db.Class, uid string) []string {
+	// members who have since left the class are still shown.
+	class.Members = s.Members
+	members, _ := class.MembersVisibleTo(uid, "")
+	return members
+}
+
+// containsMember reports whether uid is in members.
+func containsMember(members []string, uid string) bool {
+	for _, m := range members {
+		if m == uid {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package handler_test
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestSnapshot(t *testing.T) {
+	d := openSectionMock(t)
+	a := createTestAssignment(t, d)
+	for _, uid := range []string{"alice", "bob"} {
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "`+uid+`", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	}
+
+	t.Run("MissingSource", func(t *testing.T) {
+		rec := callHandler(t, d, handler.TakeSnapshot, `{"uid": "teacher", "cid": "test"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+		rec = callHandler(t, d, handler.TakeSnapshot, `{"uid": "teacher", "cid": "test", "key": "lesson-1"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+	t.Run("NotInstructor", func(t *testing.T) {
+		rec := callHandler(t, d, handler.TakeSnapshot, `{"uid": "alice", "cid": "test", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+	})
+
+	rec := callHandler(t, d, handler.TakeSnapshot, `{"uid": "teacher", "cid": "test", "name": "Lesson 1", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusCreated, rec.Code, rec.Body.String())
+	s := db.ClassSnapshot{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+	assert.Equal(t, []string{"alice", "bob"}, s.Members)
+
+	t.Run("List", func(t *testing.T) {
+		rec := callHandler(t, d, handler.ListSnapshots, `{"uid": "teacher", "cid": "test"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		snapshots := []db.ClassSnapshot{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &snapshots))
+		require.Len(t, snapshots, 1)
+		assert.Empty(t, snapshots[0].Programs)
+	})
+	t.Run("Get", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetSnapshot, `{"uid": "teacher", "cid": "test", "id": "`+s.ID+`", "member": "bob"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		got := db.ClassSnapshot{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		require.Len(t, got.Programs, 1)
+		assert.Equal(t, "import turtle", got.Programs["bob"].Code)
+	})
+	t.Run("TA", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetSnapshot, `{"uid": "ta", "cid": "test", "id": "`+s.ID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		got := db.ClassSnapshot{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+		assert.Equal(t, []string{"alice"}, got.Members)
+
+		rec = callHandler(t, d, handler.GetSnapshot, `{"uid": "ta", "cid": "test", "id": "`+s.ID+`", "member": "bob"}`)
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+	t.Run("Download", func(t *testing.T) {
+		req := httptest.NewRequest(http.MethodGet, "/?uid=teacher&cid=test&id="+s.ID, nil)
+		rec := httptest.NewRecorder()
+		require.NoError(t, handler.DownloadSnapshot(&db.DBContext{
+			Context: echo.New().NewContext(req, rec),
+			TLADB:   d,
+		}))
+		require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+		assert.Equal(t, "application/zip", rec.Header().Get(echo.HeaderContentType))
+		r, err := zip.NewReader(bytes.NewReader(rec.Body.Bytes()), int64(rec.Body.Len()))
+		require.NoError(t, err)
+		assert.Len(t, r.File, 2)
+	})
+}
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SubmitAssignment turns in the requesting member's copy of an
+// assignment for their section, freezing a snapshot of it. Members may resubmit,
+// replacing their previous submission, until the assignment's
+// lock date. Archived classes take no new submissions.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with the marshalled Submission.
+func SubmitAssignment(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor && !a.IsFor(class.SectionsOf(req.UID)) {
+		return c.String(http.StatusNotFound, "assignment does not exist")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+	pid, ok := a.Copies[req.UID]
+	if !ok {
+		return c.String(http.StatusConflict, "assignment must be opened before it is submitted")
+	}
+	p, err := c.LoadProgram(c.Request().Context(), pid)
+	if err != nil {
+		return c.String(http.StatusNotFound, "program does not exist")
+	}
+	p.UID = pid
+
+	prev, err := c.LoadSubmission(c.Request().Context(), a.AID, req.UID)
+	if err != nil && status.Code(err) != codes.NotFound {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load previous submission").Error())
+	}
+	s, err := a.Submit(prev, p, req.UID, time.Now().UTC())
+	if err != nil {
+		return statusError(c, err)
+	}
+	if err := c.StoreSubmission(c.Request().Context(), s); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to store submission").Error())
+	}
+
+	return c.JSON(http.StatusOK, &s)
+}
+
+// GetSubmission returns a member's submission for an assignment.
+// Members may only see their own submission, and only see its grade
+// once grades are released; instructors may see any member's, and
+// TAs those of the members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "student": UID of the member whose submission to return, uid if omitted
+//	}
+//
+// Returns: Status 200 with the marshalled Submission.
+func GetSubmission(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string `json:"uid"`
+		AID     string `json:"aid"`
+		Student string `json:"student"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+	if req.Student == "" {
+		req.Student = req.UID
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if req.Student != req.UID && !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can view other members' submissions")
+	}
+	if !class.CanSee(req.UID, req.Student) {
+		return c.String(http.StatusForbidden, "TAs can only view submissions from their sections")
+	}
+
+	s, err := c.LoadSubmission(c.Request().Context(), a.AID, req.Student)
+	if err != nil {
+		return c.String(http.StatusNotFound, "submission does not exist")
+	}
+	if !isInstructor && !a.GradesReleased {
+		s.Grade = nil
+	}
+	return c.JSON(http.StatusOK, &s)
+}
+
+// ListSubmissions returns every member's submission for an
+// assignment. Only instructors of the class may list submissions,
+// and TAs only see the members of the sections they lead.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	}
+//
+// Returns: Status 200 with a map of member UIDs to their
+// submissions and the UIDs of members who have not submitted.
+func ListSubmissions(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		AID string `json:"aid"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" {
+		return c.String(http.StatusBadRequest, "uid and aid fields are both required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can list submissions")
+	}
+	members, err := class.MembersVisibleTo(req.UID, "")
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	resp := struct {
+		Submissions map[string]db.Submission `json:"submissions"`
+		Missing     []string                 `json:"missing"`
+	}{
+		Submissions: make(map[string]db.Submission),
+		Missing:     []string{},
+	}
+	for _, uid := range members {
+		s, err := c.LoadSubmission(c.Request().Context(), a.AID, uid)
+		if status.Code(err) == codes.NotFound {
+			resp.Missing = append(resp.Missing, uid)
+			continue
+		}
+		if err != nil {
+			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to load submission").Error())
+		}
+		resp.Submissions[uid] = s
+	}
+
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// GrantExtension gives a member of the class a personal due date
+// for an assignment. Only instructors of the class may grant
+// extensions, and TAs only to the members of their sections.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "aid": REQUIRED
+//	    "student": REQUIRED, UID of the member granted the extension
+//	    "dueDate": REQUIRED, the member's new due date
+//	}
+//
+// Returns: Status 200 with the marshalled Assignment.
+func GrantExtension(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID     string    `json:"uid"`
+		AID     string    `json:"aid"`
+		Student string    `json:"student"`
+		DueDate time.Time `json:"dueDate"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.AID == "" || req.Student == "" || req.DueDate.IsZero() {
+		return c.String(http.StatusBadRequest, "uid, aid, student and dueDate fields are all required")
+	}
+
+	a, class, isInstructor, err := loadAssignmentClass(c, req.AID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can grant extensions")
+	}
+	if isIn, _ := classRole(class, req.Student); !isIn {
+		return c.String(http.StatusBadRequest, "given student not in class")
+	}
+	if !class.CanSee(req.UID, req.Student) {
+		return c.String(http.StatusForbidden, "TAs can only grant extensions to their sections")
+	}
+
+	if a.Extensions == nil {
+		a.Extensions = make(map[string]time.Time)
+	}
+	a.Extensions[req.Student] = req.DueDate
+	if err := c.GrantExtension(c.Request().Context(), a.AID, req.Student, req.DueDate); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to grant extension").Error())
+	}
+
+	return c.JSON(http.StatusOK, &a)
+}
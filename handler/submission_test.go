@@ -0,0 +1,116 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestSubmitAssignment(t *testing.T) {
+	t.Run("NotOpened", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		rec := callHandler(t, d, handler.SubmitAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+	t.Run("Locked", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		require.Equal(t, http.StatusCreated, callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`).Code)
+		a, err := d.LoadAssignment(context.Background(), a.AID)
+		require.NoError(t, err)
+		a.DueDate = time.Now().Add(-2 * time.Hour)
+		a.LockDate = time.Now().Add(-time.Hour)
+		require.NoError(t, d.StoreAssignment(context.Background(), a))
+
+		rec := callHandler(t, d, handler.SubmitAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		assert.Equal(t, http.StatusConflict, rec.Code)
+	})
+	t.Run("Resubmit", func(t *testing.T) {
+		d := openAssignmentMock(t)
+		a := createTestAssignment(t, d)
+		rec := callHandler(t, d, handler.OpenAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusCreated, rec.Code)
+		p := db.Program{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &p))
+
+		rec = callHandler(t, d, handler.SubmitAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		// later edits to the copy don't affect the first submission.
+		p.Code = "print('changed')"
+		require.NoError(t, d.StoreProgram(context.Background(), p))
+		s, err := d.LoadSubmission(context.Background(), a.AID, "alice")
+		require.NoError(t, err)
+		assert.Equal(t, "import turtle", s.Snapshot.Code)
+		assert.False(t, s.Late)
+
+		rec = callHandler(t, d, handler.SubmitAssignment, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+		assert.Equal(t, "print('changed')", s.Snapshot.Code)
+		assert.Equal(t, 2, s.Attempts)
+	})
+}
+
+func TestGetSubmission(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: a.AID, UID: "alice", Attempts: 1}))
+
+	rec := callHandler(t, d, handler.GetSubmission, `{"uid": "bob", "aid": "`+a.AID+`", "student": "alice"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.GetSubmission, `{"uid": "bob", "aid": "`+a.AID+`"}`)
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+
+	rec = callHandler(t, d, handler.GetSubmission, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "alice"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	s := db.Submission{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &s))
+	assert.Equal(t, "alice", s.UID)
+}
+
+func TestListSubmissions(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+	require.NoError(t, d.StoreSubmission(context.Background(), db.Submission{AID: a.AID, UID: "alice", Attempts: 1}))
+
+	rec := callHandler(t, d, handler.ListSubmissions, `{"uid": "alice", "aid": "`+a.AID+`"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.ListSubmissions, `{"uid": "teacher", "aid": "`+a.AID+`"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	resp := struct {
+		Submissions map[string]db.Submission `json:"submissions"`
+		Missing     []string                 `json:"missing"`
+	}{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+	assert.Contains(t, resp.Submissions, "alice")
+	assert.Equal(t, []string{"bob"}, resp.Missing)
+}
+
+func TestGrantExtension(t *testing.T) {
+	d := openAssignmentMock(t)
+	a := createTestAssignment(t, d)
+
+	rec := callHandler(t, d, handler.GrantExtension, `{"uid": "alice", "aid": "`+a.AID+`", "student": "alice", "dueDate": "2031-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	rec = callHandler(t, d, handler.GrantExtension, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "outsider", "dueDate": "2031-01-01T00:00:00Z"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	rec = callHandler(t, d, handler.GrantExtension, `{"uid": "teacher", "aid": "`+a.AID+`", "student": "alice", "dueDate": "2031-01-01T00:00:00Z"}`)
+	require.Equal(t, http.StatusOK, rec.Code)
+	a, err := d.LoadAssignment(context.Background(), a.AID)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2031, 1, 1, 0, 0, 0, 0, time.UTC), a.DueFor("alice").UTC())
+	assert.Equal(t, a.DueDate, a.DueFor("bob"))
+}
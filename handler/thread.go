@@ -0,0 +1,351 @@
+package handler
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/httpext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// loadThreadClass loads the thread id and the class it was started
+// in, along with whether uid is an instructor of that class. Fails
+// if uid is not in the class.
+func loadThreadClass(c *db.DBContext, id, uid string) (t db.Thread, class db.Class, isInstructor bool, err error) {
+	if t, err = c.LoadThread(c.Request().Context(), id); err != nil {
+		return t, class, false, status.Error(codes.NotFound, "thread does not exist")
+	}
+	if class, err = c.LoadClass(c.Request().Context(), t.CID); err != nil {
+		return t, class, false, status.Error(codes.NotFound, "class does not exist")
+	}
+
+	isIn, isInstructor := classRole(class, uid)
+	if !isIn {
+		return t, class, false, status.Error(codes.InvalidArgument, "given user not in class")
+	}
+	return t, class, isInstructor, nil
+}
+
+// loadSnippet returns the snippet of the program requested by uid
+// for attaching to a post, or nil if none was requested. Unless
+// the program is public, uid must own it or collaborate on it.
+func loadSnippet(c *db.DBContext, uid string, req *db.Snippet) (*db.Snippet, error) {
+	if req == nil {
+		return nil, nil
+	}
+	p, err := c.LoadProgram(c.Request().Context(), req.PID)
+	if err != nil {
+		return nil, status.Error(codes.NotFound, "program does not exist")
+	}
+	p.UID = req.PID
+	if !p.Public {
+		user, err := c.LoadUser(c.Request().Context(), uid)
+		if err != nil {
+			return nil, status.Error(codes.NotFound, "user does not exist")
+		}
+		user.UID = uid
+		if !p.CanEdit(user) {
+			return nil, status.Error(codes.PermissionDenied, "given user cannot open the program")
+		}
+	}
+
+	s, err := db.NewSnippet(req.PID, p, req.StartLine, req.EndLine)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// CreateThread starts a discussion thread in a class. Anyone in
+// the class may start one, optionally without showing their name
+// to other members. Instructors can always see who posted.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "title": REQUIRED
+//	    "body": REQUIRED
+//	    "anonymous": bool
+//	    "snippet": {"pid", "startLine", "endLine"} of a program to quote
+//	}
+//
+// Returns: Status 201 with the marshalled Thread.
+func CreateThread(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID       string      `json:"uid"`
+		CID       string      `json:"cid"`
+		Title     string      `json:"title"`
+		Body      string      `json:"body"`
+		Anonymous bool        `json:"anonymous"`
+		Snippet   *db.Snippet `json:"snippet"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	req.Title, req.Body = strings.TrimSpace(req.Title), strings.TrimSpace(req.Body)
+	if req.UID == "" || req.CID == "" || req.Title == "" || req.Body == "" {
+		return c.String(http.StatusBadRequest, "uid, cid, title and body fields are all required")
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	isIn, isInstructor := classRole(class, req.UID)
+	if !isIn {
+		return c.String(http.StatusBadRequest, "given user not in class")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+	snippet, err := loadSnippet(c, req.UID, req.Snippet)
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	t, err := c.CreateThread(c.Request().Context(), db.Thread{
+		CID:       class.CID,
+		Author:    req.UID,
+		Anonymous: req.Anonymous,
+		Title:     req.Title,
+		Body:      req.Body,
+		Snippet:   snippet,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create thread").Error())
+	}
+
+	t = t.ForUser(req.UID, isInstructor)
+	return c.JSON(http.StatusCreated, &t)
+}
+
+// ListThreads returns a page of a class's discussion threads,
+// newest first.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "cid": REQUIRED
+//	    "limit": number of threads per page, 20 if omitted
+//	    "after": ID of the last thread of the previous page
+//	}
+//
+// Returns: Status 200 with the threads and the cursor of the next
+// page, which is empty on the last page.
+func ListThreads(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID   string `json:"uid"`
+		CID   string `json:"cid"`
+		Limit int    `json:"limit"`
+		After string `json:"after"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.CID == "" {
+		return c.String(http.StatusBadRequest, "uid and cid fields are both required")
+	}
+	if req.Limit <= 0 {
+		req.Limit = defaultFeedSize
+	}
+	if req.Limit > maxFeedSize {
+		req.Limit = maxFeedSize
+	}
+
+	class, err := c.LoadClass(c.Request().Context(), req.CID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "class does not exist")
+	}
+	isIn, isInstructor := classRole(class, req.UID)
+	if !isIn {
+		return c.String(http.StatusBadRequest, "given user not in class")
+	}
+
+	// ask for one more than a page to know if there is another.
+	threads, err := c.ListThreads(c.Request().Context(), class.CID, req.After, req.Limit+1)
+	if err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to list threads").Error())
+	}
+	resp := struct {
+		Threads []db.Thread `json:"threads"`
+		Next    string      `json:"next"`
+	}{}
+	if len(threads) > req.Limit {
+		threads = threads[:req.Limit]
+		resp.Next = threads[len(threads)-1].ID
+	}
+	for i := range threads {
+		threads[i] = threads[i].ForUser(req.UID, isInstructor)
+	}
+	resp.Threads = threads
+
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// GetThread returns a discussion thread along with its replies,
+// oldest first.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED
+//	}
+//
+// Returns: Status 200 with the thread and its replies.
+func GetThread(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID string `json:"uid"`
+		ID  string `json:"id"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" {
+		return c.String(http.StatusBadRequest, "uid and id fields are both required")
+	}
+
+	t, _, isInstructor, err := loadThreadClass(c, req.ID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	replies, err := c.ListReplies(c.Request().Context(), t.ID)
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to list replies").Error())
+	}
+	for i := range replies {
+		replies[i] = replies[i].ForUser(req.UID, isInstructor)
+	}
+
+	resp := struct {
+		Thread  db.Thread  `json:"thread"`
+		Replies []db.Reply `json:"replies"`
+	}{t.ForUser(req.UID, isInstructor), replies}
+	return c.JSON(http.StatusOK, &resp)
+}
+
+// CreateReply replies to a discussion thread. Anyone in the class
+// may reply, optionally without showing their name to other
+// members.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED, ID of the thread
+//	    "body": REQUIRED
+//	    "anonymous": bool
+//	    "snippet": {"pid", "startLine", "endLine"} of a program to quote
+//	}
+//
+// Returns: Status 201 with the marshalled Reply.
+func CreateReply(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID       string      `json:"uid"`
+		ID        string      `json:"id"`
+		Body      string      `json:"body"`
+		Anonymous bool        `json:"anonymous"`
+		Snippet   *db.Snippet `json:"snippet"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	req.Body = strings.TrimSpace(req.Body)
+	if req.UID == "" || req.ID == "" || req.Body == "" {
+		return c.String(http.StatusBadRequest, "uid, id and body fields are all required")
+	}
+
+	t, class, isInstructor, err := loadThreadClass(c, req.ID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+	snippet, err := loadSnippet(c, req.UID, req.Snippet)
+	if err != nil {
+		return statusError(c, err)
+	}
+
+	r, err := c.CreateReply(c.Request().Context(), db.Reply{
+		ThreadID:  t.ID,
+		Author:    req.UID,
+		Anonymous: req.Anonymous,
+		Body:      req.Body,
+		Snippet:   snippet,
+		CreatedAt: time.Now().UTC(),
+	})
+	if err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create reply").Error())
+	}
+
+	r = r.ForUser(req.UID, isInstructor)
+	return c.JSON(http.StatusCreated, &r)
+}
+
+// EndorseReply endorses a reply as a good answer to its thread, or
+// withdraws the endorsement. A thread is marked answered while any
+// of its replies is endorsed. Only instructors of the class may
+// endorse replies.
+//
+// Request Body:
+//
+//	{
+//	    "uid": REQUIRED
+//	    "id": REQUIRED, ID of the reply
+//	    "endorsed": REQUIRED bool
+//	}
+//
+// Returns: Status 200 with the marshalled Reply.
+func EndorseReply(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+	var req struct {
+		UID      string `json:"uid"`
+		ID       string `json:"id"`
+		Endorsed *bool  `json:"endorsed"`
+	}
+	if err := httpext.RequestBodyTo(c.Request(), &req); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to read request body").Error())
+	}
+	if req.UID == "" || req.ID == "" || req.Endorsed == nil {
+		return c.String(http.StatusBadRequest, "uid, id and endorsed fields are all required")
+	}
+
+	r, err := c.LoadReply(c.Request().Context(), req.ID)
+	if err != nil {
+		return c.String(http.StatusNotFound, "reply does not exist")
+	}
+	_, class, isInstructor, err := loadThreadClass(c, r.ThreadID, req.UID)
+	if err != nil {
+		return statusError(c, err)
+	}
+	if !isInstructor {
+		return c.String(http.StatusForbidden, "only instructors can endorse replies")
+	}
+	if err := class.CheckActive(); err != nil {
+		return statusError(c, err)
+	}
+
+	endorser := ""
+	if *req.Endorsed {
+		endorser = req.UID
+	}
+	if r, err = c.EndorseReply(c.Request().Context(), r.ID, endorser); err != nil {
+		return c.String(storageErrorStatus(err), errors.Wrap(err, "failed to endorse reply").Error())
+	}
+
+	return c.JSON(http.StatusOK, &r)
+}
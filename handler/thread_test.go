@@ -0,0 +1,90 @@
+package handler_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uclaacm/teach-la-go-backend/db"
+	"github.com/uclaacm/teach-la-go-backend/handler"
+)
+
+func TestThreads(t *testing.T) {
+	d := openAssignmentMock(t)
+	require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "mine", Owner: "alice", Code: "a = 1\nb = 2\nprint(a + b)"}))
+
+	t.Run("Snippet", func(t *testing.T) {
+		rec := callHandler(t, d, handler.CreateThread, `{"uid": "bob", "cid": "test", "title": "Help", "body": "?", "snippet": {"pid": "mine", "startLine": 1, "endLine": 2}}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code, "bob can't quote alice's program")
+		rec = callHandler(t, d, handler.CreateThread, `{"uid": "alice", "cid": "test", "title": "Help", "body": "?", "snippet": {"pid": "mine", "startLine": 2, "endLine": 9}}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+
+	rec := callHandler(t, d, handler.CreateThread, `{"uid": "alice", "cid": "test", "title": "Why 3?", "body": "It prints 3", "anonymous": true, "snippet": {"pid": "mine", "startLine": 2, "endLine": 3}}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	th := db.Thread{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &th))
+	assert.Equal(t, "alice", th.Author)
+	require.NotNil(t, th.Snippet)
+	assert.Equal(t, "b = 2\nprint(a + b)", th.Snippet.Code)
+
+	t.Run("Anonymous", func(t *testing.T) {
+		rec := callHandler(t, d, handler.ListThreads, `{"uid": "bob", "cid": "test"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		resp := struct {
+			Threads []db.Thread `json:"threads"`
+		}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		require.Len(t, resp.Threads, 1)
+		assert.Empty(t, resp.Threads[0].Author)
+		require.NotNil(t, resp.Threads[0].Snippet)
+		assert.Empty(t, resp.Threads[0].Snippet.PID, "the program's owner would give alice away")
+		assert.Equal(t, th.Snippet.Code, resp.Threads[0].Snippet.Code)
+
+		rec = callHandler(t, d, handler.ListThreads, `{"uid": "teacher", "cid": "test"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.Equal(t, "alice", resp.Threads[0].Author)
+		assert.Equal(t, "mine", resp.Threads[0].Snippet.PID)
+	})
+
+	rec = callHandler(t, d, handler.CreateReply, `{"uid": "bob", "id": "`+th.ID+`", "body": "1 + 2 is 3"}`)
+	require.Equal(t, http.StatusCreated, rec.Code)
+	r := db.Reply{}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &r))
+
+	t.Run("Endorse", func(t *testing.T) {
+		rec := callHandler(t, d, handler.EndorseReply, `{"uid": "alice", "id": "`+r.ID+`", "endorsed": true}`)
+		assert.Equal(t, http.StatusForbidden, rec.Code)
+		rec = callHandler(t, d, handler.EndorseReply, `{"uid": "teacher", "id": "`+r.ID+`", "endorsed": true}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		rec = callHandler(t, d, handler.GetThread, `{"uid": "alice", "id": "`+th.ID+`"}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		resp := struct {
+			Thread  db.Thread  `json:"thread"`
+			Replies []db.Reply `json:"replies"`
+		}{}
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+		assert.True(t, resp.Thread.Answered)
+		require.Len(t, resp.Replies, 1)
+		assert.Equal(t, "teacher", resp.Replies[0].EndorsedBy)
+
+		rec = callHandler(t, d, handler.EndorseReply, `{"uid": "teacher", "id": "`+r.ID+`", "endorsed": false}`)
+		require.Equal(t, http.StatusOK, rec.Code)
+		th, err := d.LoadThread(context.Background(), th.ID)
+		require.NoError(t, err)
+		assert.False(t, th.Answered)
+	})
+	t.Run("Outsider", func(t *testing.T) {
+		rec := callHandler(t, d, handler.GetThread, `{"uid": "mallory", "id": "`+th.ID+`"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = callHandler(t, d, handler.ListThreads, `{"uid": "mallory", "cid": "test"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		rec = callHandler(t, d, handler.CreateThread, `{"uid": "mallory", "cid": "test", "title": "Hi", "body": "?"}`)
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
@@ -3,6 +3,7 @@ package handler
 import (
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/pkg/errors"
@@ -18,6 +19,10 @@ import (
 // Query Parameters:
 //  - uid string: UID of user to GET
 //	- programs string: Whether to acquire programs.
+//  - folder string: Only acquire programs in the folder with this ID.
+//  - tag string: Only acquire programs with this tag.
+//  - archived string: "true" to list only archived classes, "all"
+//    to list every class. Archived classes are hidden by default.
 //
 // Returns: Status 200 with marshalled User and programs.
 func GetUser(cc echo.Context) error {
@@ -46,10 +51,25 @@ func GetUser(cc echo.Context) error {
 		user.DeveloperAcc = true
 	}
 
+	// Filter archived classes out of the user's class list, or
+	// keep only those, unless all classes are requested.
+	if archived := c.QueryParam("archived"); archived != "all" && len(user.Classes) != 0 {
+		classes := make([]string, 0, len(user.Classes))
+		for _, cid := range user.Classes {
+			class, err := c.LoadClass(c.Request().Context(), cid)
+			isArchived := err == nil && class.Archived
+			if isArchived == (archived == "true") {
+				classes = append(classes, cid)
+			}
+		}
+		user.Classes = classes
+	}
+
 	resp.UserData = user
 
 	// Get programs, if requested.
 	if programsRequested != "" {
+		folder, tag := c.QueryParams()["folder"], c.QueryParam("tag")
 		for _, p := range resp.UserData.Programs {
 			// If error in retrieving a given program, ignore it.
 			currentProg, err := c.LoadProgram(c.Request().Context(), p)
@@ -58,14 +78,33 @@ func GetUser(cc echo.Context) error {
 				continue
 			}
 
+			// An empty folder parameter selects top-level programs.
+			if len(folder) != 0 && currentProg.Folder != folder[0] {
+				continue
+			}
+			if tag != "" && !hasTag(currentProg, tag) {
+				continue
+			}
+
 			resp.Programs[p] = currentProg
 		}
 	}
 	return c.JSON(http.StatusOK, &resp)
 }
 
-// DeleteUser deletes an user along with all their programs
-// from the database.
+// hasTag reports whether p is tagged with tag.
+func hasTag(p db.Program, tag string) bool {
+	for _, t := range p.Tags {
+		if t == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// DeleteUser deletes an user along with all the programs they
+// own from the database. They are taken off the collaborators of
+// programs they were only sharing.
 //
 // Request Body:
 // {
@@ -99,9 +138,25 @@ func DeleteUser(cc echo.Context) error {
 
 	resp.UserData = user
 
-	// Delete all programs
-	for _, prog := range user.Programs {
-		if err := c.RemoveProgram(c.Request().Context(), prog); err != nil && status.Code(err) != codes.NotFound {
+	// Delete the programs the user owns, and leave the ones they
+	// only collaborate on to their owners.
+	user.UID = uid
+	for _, pid := range user.Programs {
+		p, err := c.LoadProgram(c.Request().Context(), pid)
+		if status.Code(err) == codes.NotFound {
+			continue
+		}
+		if err != nil {
+			return c.String(http.StatusInternalServerError, "failed to delete user.")
+		}
+		p.UID = pid
+
+		if p.IsOwnedBy(user) {
+			_, err = c.DeleteOwnedProgram(c.Request().Context(), pid, uid)
+		} else {
+			err = c.RemoveProgramCollaborator(c.Request().Context(), pid, uid, uid)
+		}
+		if err != nil && status.Code(err) != codes.NotFound {
 			return c.String(http.StatusInternalServerError, "failed to delete user.")
 		}
 	}
@@ -115,17 +170,20 @@ func DeleteUser(cc echo.Context) error {
 
 // CreateUser creates a new user object corresponding to either
 // the provided UID or a random new one if none is provided
-// with the default data.
+// with the default data. The user is enrolled in any classes
+// that invited their UID or email.
 //
 // Request Body:
 // {
 //     "uid": string <optional>
+//     "email": string <optional>
 // }
 //
 // Returns: Status 200 with a marshalled User struct on success.
 func CreateUser(cc echo.Context) error {
 	var body struct {
-		UID string `json:"uid"`
+		UID   string `json:"uid"`
+		Email string `json:"email"`
 	}
 
 	if err := httpext.RequestBodyTo(cc.Request(), &body); err != nil {
@@ -135,6 +193,7 @@ func CreateUser(cc echo.Context) error {
 	// create structures to be used as default data
 	newUser, newProgs := db.DefaultData()
 	newUser.UID = body.UID
+	newUser.Email = db.NormalizeEmail(body.Email)
 
 	c := cc.(*db.DBContext)
 	user, err := c.CreateUser(c.Request().Context(), newUser)
@@ -146,6 +205,7 @@ func CreateUser(cc echo.Context) error {
 
 	for _, prog := range newProgs {
 		// create program in database
+		prog.Owner = user.UID
 		p, err := c.CreateProgram(c.Request().Context(), prog)
 		if err != nil {
 			return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create user").Error())
@@ -156,10 +216,85 @@ func CreateUser(cc echo.Context) error {
 	}
 
 	// set most recent program
-	user.MostRecentProgram = user.Programs[0]
+	user.OpenedProgram(user.Programs[0], time.Now().UTC())
+
+	if err := acceptInvitations(c, &user); err != nil {
+		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to accept class invitations").Error())
+	}
 	if err := c.StoreUser(c.Request().Context(), user); err != nil {
 		return c.String(http.StatusInternalServerError, errors.Wrap(err, "failed to create user").Error())
 	}
 
 	return c.JSON(http.StatusCreated, &user)
 }
+
+// GetLikedPrograms acquires the programs liked by the user with
+// the given uid.
+//
+// Query Parameters:
+//   - uid string: UID of user whose likes to GET
+//
+// Returns: Status 200 with a map of liked PIDs to their programs.
+// Programs which could not be loaded, such as deleted ones, are
+// omitted.
+func GetLikedPrograms(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+
+	uid := c.QueryParam("uid")
+	if uid == "" {
+		return c.String(http.StatusBadRequest, "`uid` is a required query parameter.")
+	}
+	user, err := c.LoadUser(c.Request().Context(), uid)
+	if err != nil {
+		c.Logger().Debugf("Failed to load user with uid `%s`: %v", uid, err)
+		return c.String(http.StatusNotFound, "Failed to load user.")
+	}
+
+	programs := make(map[string]db.Program)
+	for _, pid := range user.LikedPrograms {
+		p, err := c.LoadProgram(c.Request().Context(), pid)
+		if err != nil {
+			c.Logger().Warnf("Failed to load liked program with pid `%s` for user with uid `%s`", pid, uid)
+			continue
+		}
+		programs[pid] = p
+	}
+	return c.JSON(http.StatusOK, programs)
+}
+
+// GetRecentPrograms acquires the programs most recently opened
+// or saved by the user with the given uid.
+//
+// Query Parameters:
+//   - uid string: UID of user whose recent programs to GET
+//
+// Returns: Status 200 with an array of recent programs, most
+// recent first. Programs which could not be loaded are omitted.
+func GetRecentPrograms(cc echo.Context) error {
+	c := cc.(*db.DBContext)
+
+	uid := c.QueryParam("uid")
+	if uid == "" {
+		return c.String(http.StatusBadRequest, "`uid` is a required query parameter.")
+	}
+	user, err := c.LoadUser(c.Request().Context(), uid)
+	if err != nil {
+		c.Logger().Debugf("Failed to load user with uid `%s`: %v", uid, err)
+		return c.String(http.StatusNotFound, "Failed to load user.")
+	}
+
+	type recent struct {
+		db.RecentProgram
+		Program db.Program `json:"program"`
+	}
+	resp := make([]recent, 0, len(user.RecentPrograms))
+	for _, r := range user.RecentPrograms {
+		p, err := c.LoadProgram(c.Request().Context(), r.PID)
+		if err != nil {
+			c.Logger().Warnf("Failed to load recent program with pid `%s` for user with uid `%s`", r.PID, uid)
+			continue
+		}
+		resp = append(resp, recent{r, p})
+	}
+	return c.JSON(http.StatusOK, resp)
+}
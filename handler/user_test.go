@@ -132,6 +132,42 @@ func TestGetUser(t *testing.T) {
 			assert.Empty(t, resp.Programs)
 		}
 	})
+	t.Run("FilterPrograms", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "testuser",
+			Programs: []string{"top", "filed", "tagged"},
+			Folders:  map[string]db.Folder{"a": {ID: "a", Name: "a"}},
+		}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "top"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "filed", Folder: "a"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "tagged", Folder: "a", Tags: []string{"turtle"}}))
+
+		for query, expected := range map[string][]string{
+			"&folder=a":            {"filed", "tagged"},
+			"&folder=":             {"top"},
+			"&tag=turtle":          {"tagged"},
+			"&folder=a&tag=turtle": {"tagged"},
+		} {
+			req := httptest.NewRequest(http.MethodGet, "/?uid=testuser&programs=true"+query, nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+
+			resp := struct {
+				Programs map[string]db.Program `json:"programs"`
+			}{}
+			require.NoError(t, handler.GetUser(&db.DBContext{
+				Context: c,
+				TLADB:   d,
+			}))
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+			assert.Len(t, resp.Programs, len(expected), query)
+			for _, pid := range expected {
+				assert.Contains(t, resp.Programs, pid, query)
+			}
+		}
+	})
 }
 
 func TestDeleteUser(t *testing.T) {
@@ -218,6 +254,28 @@ func TestDeleteUser(t *testing.T) {
 			}
 		}
 	})
+	t.Run("Collaborator", func(t *testing.T) {
+		d := db.OpenMock()
+
+		// testuser only collaborates on owner's program.
+		prog := db.Program{UID: "testprog", Owner: "owner", Collaborators: []string{"testuser"}}
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "owner", Programs: []string{prog.UID}}))
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "testuser", Programs: []string{prog.UID}}))
+		require.NoError(t, d.StoreProgram(context.Background(), prog))
+
+		req := httptest.NewRequest(http.MethodGet, "/?uid=testuser", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		require.NoError(t, handler.DeleteUser(&db.DBContext{Context: c, TLADB: d}))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		p, err := d.LoadProgram(context.Background(), prog.UID)
+		require.NoError(t, err)
+		assert.Empty(t, p.Collaborators)
+		owner, err := d.LoadUser(context.Background(), "owner")
+		require.NoError(t, err)
+		assert.Equal(t, []string{prog.UID}, owner.Programs)
+	})
 }
 
 func TestCreateUser(t *testing.T) {
@@ -290,3 +348,118 @@ func TestCreateUser(t *testing.T) {
 	})
 
 }
+
+func TestGetLikedPrograms(t *testing.T) {
+	t.Run("MissingUID", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.GetLikedPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("SkipsDeleted", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:           "test",
+			LikedPrograms: []string{"liked", "deleted"},
+		}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "liked"}))
+		req := httptest.NewRequest(http.MethodGet, "/?uid=test", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.GetLikedPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			programs := make(map[string]db.Program)
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &programs))
+			assert.Contains(t, programs, "liked")
+			assert.NotContains(t, programs, "deleted")
+		}
+	})
+}
+
+func TestGetRecentPrograms(t *testing.T) {
+	t.Run("MissingUID", func(t *testing.T) {
+		d := db.OpenMock()
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+
+		if assert.NoError(t, handler.GetRecentPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			assert.Equal(t, http.StatusBadRequest, rec.Code)
+		}
+	})
+	t.Run("OpenedThroughGetProgram", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{
+			UID:      "test",
+			Programs: []string{"first", "second"},
+		}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "first", Name: "first"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "second", Name: "second"}))
+
+		for _, pid := range []string{"first", "second"} {
+			req := httptest.NewRequest(http.MethodGet, "/?uid=test&pid="+pid, nil)
+			rec := httptest.NewRecorder()
+			c := echo.New().NewContext(req, rec)
+			require.NoError(t, handler.GetProgram(&db.DBContext{
+				Context: c,
+				TLADB:   d,
+			}))
+			require.Equal(t, http.StatusOK, rec.Code)
+		}
+
+		req := httptest.NewRequest(http.MethodGet, "/?uid=test", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		if assert.NoError(t, handler.GetRecentPrograms(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		})) {
+			require.Equal(t, http.StatusOK, rec.Code)
+			recent := []struct {
+				PID     string     `json:"pid"`
+				Program db.Program `json:"program"`
+			}{}
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &recent))
+			require.Len(t, recent, 2)
+			assert.Equal(t, "second", recent[0].PID)
+			assert.Equal(t, "second", recent[0].Program.Name)
+			assert.Equal(t, "first", recent[1].PID)
+		}
+
+		u, err := d.LoadUser(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Equal(t, "second", u.MostRecentProgram)
+	})
+	t.Run("NotOwnerNotRecorded", func(t *testing.T) {
+		d := db.OpenMock()
+		require.NoError(t, d.StoreUser(context.Background(), db.User{UID: "test"}))
+		require.NoError(t, d.StoreProgram(context.Background(), db.Program{UID: "other"}))
+
+		req := httptest.NewRequest(http.MethodGet, "/?uid=test&pid=other", nil)
+		rec := httptest.NewRecorder()
+		c := echo.New().NewContext(req, rec)
+		require.NoError(t, handler.GetProgram(&db.DBContext{
+			Context: c,
+			TLADB:   d,
+		}))
+		require.Equal(t, http.StatusOK, rec.Code)
+
+		u, err := d.LoadUser(context.Background(), "test")
+		require.NoError(t, err)
+		assert.Empty(t, u.RecentPrograms)
+	})
+}
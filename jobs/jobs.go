@@ -0,0 +1,169 @@
+// Package jobs runs long requests in the background and tracks
+// their progress, so that clients can poll for the outcome instead
+// of holding a connection open.
+package jobs
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pkg/errors"
+)
+
+// ErrNotFound is returned when waiting on a job the Registry
+// doesn't know of.
+var ErrNotFound = errors.New("jobs: no such job")
+
+const (
+	// Running, Done and Failed are the states of a Job.
+	Running = "running"
+	Done    = "done"
+	Failed  = "failed"
+
+	// Retention is how long a finished job is remembered, and so
+	// how long its key keeps a retry from running it again.
+	Retention = 24 * time.Hour
+)
+
+// Job is a snapshot of a background job.
+type Job struct {
+	ID         string    `json:"id"`
+	Key        string    `json:"key"`
+	State      string    `json:"state"`
+	Done       int       `json:"done"`
+	Total      int       `json:"total"`
+	Error      string    `json:"error"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+
+	// Scope limits who may look the job up, such as to the class
+	// it was started for.
+	Scope string `json:"-"`
+}
+
+// Progress is called by a job to report that done of total items
+// have been processed.
+type Progress func(done, total int)
+
+// Func is the work done by a job.
+type Func func(ctx context.Context, progress Progress) error
+
+// entry is a job as kept by a Registry.
+type entry struct {
+	Job
+	finished chan struct{}
+}
+
+// Registry runs jobs and keeps track of them in memory. It is safe
+// for concurrent use. Jobs don't survive a restart, so their work
+// must be safe to redo.
+type Registry struct {
+	sync.Mutex
+	jobs map[string]*entry
+
+	// keys maps a scope and idempotency key to the ID of the job
+	// started with them.
+	keys map[string]string
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{
+		jobs: make(map[string]*entry),
+		keys: make(map[string]string),
+	}
+}
+
+// Start runs f in the background as a job within scope. If a job
+// was already started in the same scope with the same idempotency
+// key and hasn't failed, that job is returned instead and f isn't
+// run. started reports whether f was run.
+func (r *Registry) Start(scope, key string, f Func) (job Job, started bool) {
+	r.Lock()
+	defer r.Unlock()
+	r.prune(time.Now().UTC())
+
+	if id, ok := r.keys[scope+"/"+key]; ok && r.jobs[id].State != Failed {
+		return r.jobs[id].Job, false
+	}
+
+	e := &entry{
+		Job: Job{
+			ID:        uuid.New().String(),
+			Key:       key,
+			Scope:     scope,
+			State:     Running,
+			StartedAt: time.Now().UTC(),
+		},
+		finished: make(chan struct{}),
+	}
+	r.jobs[e.ID] = e
+	r.keys[scope+"/"+key] = e.ID
+
+	go r.run(e, f)
+	return e.Job, true
+}
+
+// run runs f as the job e, recording its progress and outcome.
+func (r *Registry) run(e *entry, f Func) {
+	defer close(e.finished)
+
+	err := f(context.Background(), func(done, total int) {
+		r.Lock()
+		defer r.Unlock()
+		e.Done, e.Total = done, total
+	})
+
+	r.Lock()
+	defer r.Unlock()
+	e.State = Done
+	if err != nil {
+		e.State, e.Error = Failed, err.Error()
+	}
+	e.FinishedAt = time.Now().UTC()
+}
+
+// prune forgets jobs which finished more than Retention before now.
+func (r *Registry) prune(now time.Time) {
+	for id, e := range r.jobs {
+		if e.State != Running && now.Sub(e.FinishedAt) > Retention {
+			delete(r.jobs, id)
+			if r.keys[e.Scope+"/"+e.Key] == id {
+				delete(r.keys, e.Scope+"/"+e.Key)
+			}
+		}
+	}
+}
+
+// Get returns the job with the given ID, if it was started within
+// scope.
+func (r *Registry) Get(scope, id string) (Job, bool) {
+	r.Lock()
+	defer r.Unlock()
+	e, ok := r.jobs[id]
+	if !ok || e.Scope != scope {
+		return Job{}, false
+	}
+	return e.Job, true
+}
+
+// Wait blocks until the job with the given ID finishes or ctx is
+// done, returning the job as it last was.
+func (r *Registry) Wait(ctx context.Context, id string) (Job, error) {
+	r.Lock()
+	e, ok := r.jobs[id]
+	r.Unlock()
+	if !ok {
+		return Job{}, ErrNotFound
+	}
+
+	select {
+	case <-e.finished:
+	case <-ctx.Done():
+	}
+	r.Lock()
+	defer r.Unlock()
+	return e.Job, ctx.Err()
+}
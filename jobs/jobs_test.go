@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRegistry(t *testing.T) {
+	r := NewRegistry()
+	release := make(chan struct{})
+	runs := 0
+	f := func(ctx context.Context, progress Progress) error {
+		runs++
+		progress(1, 2)
+		<-release
+		progress(2, 2)
+		return nil
+	}
+
+	job, started := r.Start("class", "key", f)
+	require.True(t, started)
+	assert.Equal(t, Running, job.State)
+
+	again, started := r.Start("class", "key", f)
+	assert.False(t, started, "the same key shouldn't start a second job")
+	assert.Equal(t, job.ID, again.ID)
+
+	_, ok := r.Get("other", job.ID)
+	assert.False(t, ok, "jobs can't be looked up from another scope")
+
+	close(release)
+	job, err := r.Wait(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Done, job.State)
+	assert.Equal(t, 2, job.Done)
+	assert.Equal(t, 1, runs)
+
+	_, err = r.Wait(context.Background(), "nope")
+	assert.Equal(t, ErrNotFound, err)
+}
+
+func TestRegistryRetry(t *testing.T) {
+	r := NewRegistry()
+	failing := func(context.Context, Progress) error { return errors.New("boom") }
+
+	job, _ := r.Start("class", "key", failing)
+	job, err := r.Wait(context.Background(), job.ID)
+	require.NoError(t, err)
+	assert.Equal(t, Failed, job.State)
+	assert.Equal(t, "boom", job.Error)
+
+	retry, started := r.Start("class", "key", func(context.Context, Progress) error { return nil })
+	assert.True(t, started, "a failed job may be retried")
+	assert.NotEqual(t, job.ID, retry.ID)
+
+	// finished jobs are forgotten once they are old enough.
+	_, err = r.Wait(context.Background(), retry.ID)
+	require.NoError(t, err)
+	r.Lock()
+	r.prune(time.Now().UTC().Add(Retention + time.Minute))
+	r.Unlock()
+	_, ok := r.Get("class", retry.ID)
+	assert.False(t, ok)
+}
@@ -13,11 +13,32 @@ import (
 	"github.com/labstack/echo/v4/middleware"
 	"github.com/labstack/gommon/log"
 	"github.com/pkg/errors"
+	"github.com/uclaacm/teach-la-go-backend/autograder"
 	"github.com/uclaacm/teach-la-go-backend/db"
 	"github.com/uclaacm/teach-la-go-backend/handler"
+	"github.com/uclaacm/teach-la-go-backend/jobs"
 	"github.com/urfave/cli/v2"
 )
 
+// openDB opens a connection to the database using the credentials
+// given on the command line, checking for them in the following
+// partial order:
+// - JSON
+// - .env
+// - TLACFG
+func openDB(c *cli.Context, logger echo.Logger) (*db.DB, error) {
+	jsonPath, dotenvPath := c.String("json"), c.String("dotenv")
+	switch {
+	case jsonPath != "":
+		return db.OpenFromJSON(context.Background(), jsonPath)
+	case dotenvPath != "":
+		if err := godotenv.Load(dotenvPath); err != nil {
+			logger.Error(errors.Wrap(err, "failed to open .env file"))
+		}
+	}
+	return db.Open(context.Background(), os.Getenv(db.DefaultEnvVar))
+}
+
 func serve(c *cli.Context) error {
 	e := echo.New()
 	e.HideBanner = true
@@ -36,32 +57,24 @@ func serve(c *cli.Context) error {
 		AllowMethods: []string{http.MethodGet, http.MethodPost, http.MethodPut, http.MethodDelete},
 	}))
 
-	// Check for working credentials in the following partial order:
-	// - JSON
-	// - .env
-	// - TLACFG
-	jsonPath, dotenvPath := c.String("json"), c.String("dotenv")
-	var (
-		d   *db.DB
-		err error
-	)
-	switch {
-	case jsonPath != "":
-		d, err = db.OpenFromJSON(context.Background(), jsonPath)
-	case dotenvPath != "":
-		if err := godotenv.Load(dotenvPath); err != nil {
-			e.Logger.Error(errors.Wrap(err, "failed to open .env file"))
-		}
-		d, err = db.Open(context.Background(), os.Getenv(db.DefaultEnvVar))
-	default:
-		d, err = db.Open(context.Background(), os.Getenv(db.DefaultEnvVar))
-	}
+	d, err := openDB(c, e.Logger)
 	if err != nil {
 		e.Logger.Fatal(errors.Wrap(err, "failed to open connection to firestore"))
 		return err
 	}
 	defer d.Close()
 
+	// Build the program search index in the background so that
+	// startup isn't blocked on reading every program.
+	go func() {
+		if err := d.IndexPrograms(context.Background()); err != nil {
+			e.Logger.Error(errors.Wrap(err, "failed to build program search index"))
+		}
+	}()
+
+	// Pushes of programs to whole classes run in the background.
+	pushes := jobs.NewRegistry()
+
 	// Register our database handler to every Echo context.
 	e.Use(func(nxt echo.HandlerFunc) echo.HandlerFunc {
 		return func(c echo.Context) error {
@@ -76,12 +89,28 @@ func serve(c *cli.Context) error {
 	e.GET("/user/get", handler.GetUser)
 	e.PUT("/user/update", d.UpdateUser)
 	e.POST("/user/create", handler.CreateUser)
+	e.GET("/user/likes", handler.GetLikedPrograms)
+	e.GET("/user/recent", handler.GetRecentPrograms)
+	e.POST("/user/folder/create", handler.CreateFolder)
+	e.PUT("/user/folder/update", handler.UpdateFolder)
+	e.DELETE("/user/folder/delete", handler.DeleteFolder)
 
 	// program management
 	e.GET("/program/get", handler.GetProgram)
 	e.PUT("/program/update", d.UpdateProgram)
 	e.POST("/program/create", handler.CreateProgram)
 	e.DELETE("/program/delete", handler.DeleteProgram)
+	e.POST("/program/like", handler.LikeProgram)
+	e.POST("/program/view", handler.ViewProgram)
+	e.GET("/program/stats", handler.GetProgramStats)
+	e.GET("/program/search", handler.SearchPrograms)
+	e.PUT("/program/publish", handler.PublishProgram)
+	e.PUT("/program/move", handler.MoveProgram)
+	e.PUT("/program/tag", handler.TagProgram)
+	e.PUT("/program/untag", handler.UntagProgram)
+	e.PUT("/program/transfer", handler.TransferProgram)
+	e.PUT("/program/collaborator/add", handler.AddCollaborator)
+	e.PUT("/program/collaborator/remove", handler.RemoveCollaborator)
 
 	// class management
 	e.POST("/class/get", handler.GetClass)
@@ -89,6 +118,81 @@ func serve(c *cli.Context) error {
 	e.PUT("/class/join", handler.JoinClass)
 	e.PUT("/class/leave", d.LeaveClass)
 	e.POST("/class/members", handler.GetClassMembers)
+	e.POST("/class/assignments", handler.ListAssignments)
+	e.POST("/class/roster/import", handler.ImportRoster)
+	e.GET("/class/roster", handler.ExportRoster)
+	e.PUT("/class/instructor/add", handler.AddInstructor)
+	e.PUT("/class/instructor/promote", handler.PromoteMember)
+	e.PUT("/class/instructor/remove", handler.RemoveInstructor)
+	e.PUT("/class/transfer", handler.TransferClass)
+	e.PUT("/class/member/remove", handler.RemoveMember)
+	e.PUT("/class/member/ban", handler.BanMember)
+	e.PUT("/class/member/unban", handler.UnbanUser)
+	e.PUT("/class/wid/rotate", handler.RotateJoinCode)
+	e.PUT("/class/wid", handler.SetJoinCode)
+	e.PUT("/class/admission", handler.SetAdmission)
+	e.POST("/class/requests", handler.ListJoinRequests)
+	e.PUT("/class/requests/approve", handler.ApproveJoin)
+	e.PUT("/class/requests/reject", handler.RejectJoin)
+	e.PUT("/class/archive", handler.ArchiveClass)
+	e.POST("/class/clone", handler.CloneClass)
+	e.PUT("/class/section", handler.SetSection)
+	e.DELETE("/class/section/delete", handler.DeleteSection)
+	e.POST("/class/dashboard", handler.GetDashboard)
+	e.POST("/class/push", handler.PushProgram(pushes))
+	e.POST("/class/push/status", handler.GetPushJob(pushes))
+	e.POST("/class/snapshot/create", handler.TakeSnapshot)
+	e.POST("/class/snapshots", handler.ListSnapshots)
+	e.POST("/class/snapshot/get", handler.GetSnapshot)
+	e.GET("/class/snapshot/download", handler.DownloadSnapshot)
+
+	// announcements
+	e.POST("/class/announcement/create", handler.CreateAnnouncement)
+	e.PUT("/class/announcement/update", handler.UpdateAnnouncement)
+	e.DELETE("/class/announcement/delete", handler.DeleteAnnouncement)
+	e.POST("/class/announcements", handler.ListAnnouncements)
+	e.PUT("/class/announcements/read", handler.ReadAnnouncements)
+
+	// discussion threads
+	e.POST("/class/thread/create", handler.CreateThread)
+	e.POST("/class/threads", handler.ListThreads)
+	e.POST("/class/thread/get", handler.GetThread)
+	e.POST("/class/thread/reply", handler.CreateReply)
+	e.PUT("/class/thread/endorse", handler.EndorseReply)
+
+	// assignment management
+	e.POST("/assignment/create", handler.CreateAssignment)
+	e.PUT("/assignment/update", handler.UpdateAssignment)
+	e.DELETE("/assignment/delete", handler.DeleteAssignment)
+	e.POST("/assignment/get", handler.GetAssignment)
+	e.POST("/assignment/open", handler.OpenAssignment)
+	e.POST("/assignment/copies", handler.GetAssignmentCopies)
+	e.PUT("/assignment/extension", handler.GrantExtension)
+
+	// submission management
+	e.POST("/assignment/submit", handler.SubmitAssignment)
+	e.POST("/assignment/submission", handler.GetSubmission)
+	e.POST("/assignment/submissions", handler.ListSubmissions)
+	e.POST("/assignment/similarity", handler.CheckSimilarity)
+
+	// peer review
+	e.PUT("/assignment/peerreview/assign", handler.AssignPeerReviews)
+	e.POST("/assignment/peerreview/queue", handler.ListReviewQueue)
+	e.PUT("/assignment/peerreview/submit", handler.SubmitPeerReview)
+	e.POST("/assignment/peerreview/received", handler.ListReceivedReviews)
+	e.POST("/assignment/peerreviews", handler.ListPeerReviews)
+	e.PUT("/assignment/peerreview/moderate", handler.ModeratePeerReview)
+
+	// grading
+	e.PUT("/assignment/rubric", handler.SetRubric)
+	e.PUT("/assignment/grade", handler.GradeSubmission)
+	e.PUT("/assignment/release", handler.ReleaseGrades)
+	e.POST("/assignment/grade", handler.GetGrade)
+	e.GET("/class/gradebook", handler.ExportGradebook)
+
+	// autograding
+	e.PUT("/assignment/tests", handler.SetTestCases)
+	e.POST("/assignment/autograde", handler.AutogradeSubmission(autograder.NewLocalRunner()))
 
 	// collaborative coding management
 	e.POST("/collab/create", d.CreateCollab)
@@ -114,6 +218,33 @@ func serve(c *cli.Context) error {
 	return nil
 }
 
+// exportGradebook writes the gradebook of a class, or of one of its
+// sections, as CSV to the output file, or to stdout if none is given.
+func exportGradebook(c *cli.Context) error {
+	d, err := openDB(c, log.New("tlabe"))
+	if err != nil {
+		return errors.Wrap(err, "failed to open connection to firestore")
+	}
+	defer d.Close()
+
+	class, err := d.LoadClass(c.Context, c.String("cid"))
+	if err != nil {
+		return errors.Wrap(err, "failed to load class")
+	}
+	if class.Members, err = class.MembersVisibleTo(class.Creator, c.String("section")); err != nil {
+		return errors.Wrap(err, "failed to filter section")
+	}
+
+	out := os.Stdout
+	if path := c.String("output"); path != "" {
+		if out, err = os.Create(path); err != nil {
+			return errors.Wrap(err, "failed to create output file")
+		}
+		defer out.Close()
+	}
+	return db.WriteGradebook(c.Context, d, class, out)
+}
+
 func main() {
 	cli.VersionFlag = &cli.BoolFlag{
 		Name:    "version",
@@ -160,6 +291,31 @@ func main() {
 			},
 		},
 		Action: serve,
+		Commands: []*cli.Command{
+			{
+				Name:  "gradebook",
+				Usage: "Export a class gradebook as CSV",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:     "cid",
+						Aliases:  []string{"c"},
+						Required: true,
+						Usage:    "Specify the class to export",
+					},
+					&cli.StringFlag{
+						Name:    "section",
+						Aliases: []string{"s"},
+						Usage:   "Specify a section to limit the export to",
+					},
+					&cli.StringFlag{
+						Name:    "output",
+						Aliases: []string{"o"},
+						Usage:   "Specify a path to write the CSV to instead of stdout",
+					},
+				},
+				Action: exportGradebook,
+			},
+		},
 	}
 
 	if err := app.Run(os.Args); err != nil {
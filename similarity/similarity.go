@@ -0,0 +1,274 @@
+// Package similarity finds programs which share more code than
+// chance would explain, such as copied homework.
+//
+// Programs are tokenized with identifiers, literals, comments and
+// whitespace normalized away, fingerprinted by winnowing the hashes
+// of their k-grams of tokens, and compared by the fingerprints they
+// share, as described in "Winnowing: Local Algorithms for Document
+// Fingerprinting" by Schleimer, Wilkerson and Aiken.
+package similarity
+
+import (
+	"hash/fnv"
+	"sort"
+)
+
+// Options tune a comparison.
+type Options struct {
+	// KGram is the number of tokens hashed into each fingerprint,
+	// and so the shortest run of tokens that can be matched.
+	KGram int
+
+	// Window is the number of consecutive k-grams from which one
+	// fingerprint is kept. Any match at least KGram+Window-1 tokens
+	// long is guaranteed to be found.
+	Window int
+
+	// MinScore is the lowest score of the pairs returned.
+	MinScore float64
+
+	// MaxDocuments is the most documents a fingerprint may appear
+	// in before it is taken to be common code and ignored, unless
+	// they are no more than MaxShare of the documents compared, so
+	// that large groups sharing code are still found in large
+	// classes. Zero means there is no limit.
+	MaxDocuments int
+	MaxShare     float64
+
+	// Base holds code every document was given to start from, such
+	// as an assignment's starter program. Code matching it is
+	// ignored.
+	Base []Document
+}
+
+// DefaultOptions suit programs of the length written in class.
+var DefaultOptions = Options{
+	KGram:        12,
+	Window:       8,
+	MinScore:     0.5,
+	MaxDocuments: 10,
+	MaxShare:     0.5,
+}
+
+// Document is a program to compare.
+type Document struct {
+	ID       string
+	Language string
+	Code     string
+}
+
+// Span is a range of lines, numbered from one and inclusive.
+type Span struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Region is code found in both documents of a Pair.
+type Region struct {
+	A Span `json:"a"`
+	B Span `json:"b"`
+}
+
+// Pair is two documents which share code.
+type Pair struct {
+	A string `json:"a"`
+	B string `json:"b"`
+
+	// Score is the fraction of the fingerprints of the shorter
+	// document which are also found in the longer, and Shared the
+	// number of fingerprints they have in common.
+	Score  float64 `json:"score"`
+	Shared int     `json:"shared"`
+
+	Regions []Region `json:"regions"`
+}
+
+// fingerprinted is a document along with its tokens and the
+// positions of the k-grams kept as its fingerprints, by hash.
+type fingerprinted struct {
+	Document
+	tokens []token
+	prints map[uint64][]int
+}
+
+// Compare returns the pairs of docs which share code, most similar
+// first.
+func Compare(docs []Document, opts Options) []Pair {
+	base := make(map[uint64]bool)
+	for _, d := range opts.Base {
+		for h := range fingerprint(d, opts).prints {
+			base[h] = true
+		}
+	}
+
+	// index which documents each fingerprint appears in, leaving
+	// out the base code.
+	fps := make([]fingerprinted, len(docs))
+	holders := make(map[uint64][]int)
+	for i, d := range docs {
+		fps[i] = fingerprint(d, opts)
+		for h := range fps[i].prints {
+			if base[h] {
+				delete(fps[i].prints, h)
+				continue
+			}
+			holders[h] = append(holders[h], i)
+		}
+	}
+
+	// count the fingerprints each pair of documents shares.
+	common := float64(opts.MaxDocuments)
+	if share := opts.MaxShare * float64(len(docs)); share > common {
+		common = share
+	}
+	shared := make(map[[2]int][]uint64)
+	for h, in := range holders {
+		if opts.MaxDocuments > 0 && float64(len(in)) > common {
+			for _, i := range in {
+				delete(fps[i].prints, h)
+			}
+			continue
+		}
+		for x := range in {
+			for _, j := range in[x+1:] {
+				key := [2]int{in[x], j}
+				shared[key] = append(shared[key], h)
+			}
+		}
+	}
+
+	pairs := []Pair{}
+	for key, hashes := range shared {
+		a, b := fps[key[0]], fps[key[1]]
+		smaller := len(a.prints)
+		if len(b.prints) < smaller {
+			smaller = len(b.prints)
+		}
+		score := float64(len(hashes)) / float64(smaller)
+		if score < opts.MinScore {
+			continue
+		}
+		pairs = append(pairs, Pair{
+			A:       a.ID,
+			B:       b.ID,
+			Score:   score,
+			Shared:  len(hashes),
+			Regions: regions(a, b, hashes, opts.KGram),
+		})
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		if pairs[i].Shared != pairs[j].Shared {
+			return pairs[i].Shared > pairs[j].Shared
+		}
+		if pairs[i].A != pairs[j].A {
+			return pairs[i].A < pairs[j].A
+		}
+		return pairs[i].B < pairs[j].B
+	})
+	return pairs
+}
+
+// fingerprint tokenizes d and winnows the hashes of its k-grams,
+// keeping the rightmost smallest hash of each window. Documents
+// shorter than a k-gram have no fingerprints.
+func fingerprint(d Document, opts Options) fingerprinted {
+	fp := fingerprinted{
+		Document: d,
+		tokens:   tokenize(d.Language, d.Code),
+		prints:   make(map[uint64][]int),
+	}
+
+	hashes := []uint64{}
+	for i := 0; i+opts.KGram <= len(fp.tokens); i++ {
+		h := fnv.New64a()
+		for _, t := range fp.tokens[i : i+opts.KGram] {
+			h.Write([]byte(t.text))
+			h.Write([]byte{0})
+		}
+		hashes = append(hashes, h.Sum64())
+	}
+
+	last := -1
+	for start := 0; start == 0 || start+opts.Window <= len(hashes); start++ {
+		end := start + opts.Window
+		if end > len(hashes) {
+			end = len(hashes)
+		}
+		if start >= end {
+			break
+		}
+		chosen := start
+		for i := start; i < end; i++ {
+			if hashes[i] <= hashes[chosen] {
+				chosen = i
+			}
+		}
+		if chosen != last {
+			fp.prints[hashes[chosen]] = append(fp.prints[hashes[chosen]], chosen)
+			last = chosen
+		}
+	}
+	return fp
+}
+
+// maxPairings bounds how many pairs of positions of one repeated
+// fingerprint are matched up when finding regions.
+const maxPairings = 64
+
+// regions returns the code a and b share through the given
+// fingerprints, merging overlapping matches and reporting each as
+// the lines it spans in both documents.
+func regions(a, b fingerprinted, hashes []uint64, k int) []Region {
+	type match struct{ a, b int }
+	matches := []match{}
+	for _, h := range hashes {
+		n := 0
+		for _, pa := range a.prints[h] {
+			for _, pb := range b.prints[h] {
+				if n++; n <= maxPairings {
+					matches = append(matches, match{pa, pb})
+				}
+			}
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].a != matches[j].a {
+			return matches[i].a < matches[j].a
+		}
+		return matches[i].b < matches[j].b
+	})
+
+	regions := []Region{}
+	add := func(aStart, aEnd, bStart, bEnd int) {
+		r := Region{
+			A: Span{Start: a.tokens[aStart].line, End: a.tokens[aEnd-1].line},
+			B: Span{Start: b.tokens[bStart].line, End: b.tokens[bEnd-1].line},
+		}
+		if n := len(regions); n == 0 || regions[n-1] != r {
+			regions = append(regions, r)
+		}
+	}
+
+	for i := 0; i < len(matches); {
+		aStart, bStart := matches[i].a, matches[i].b
+		aEnd, bEnd := aStart+k, bStart+k
+		for i++; i < len(matches); i++ {
+			m := matches[i]
+			if m.a > aEnd || m.b < bStart || m.b > bEnd {
+				break
+			}
+			if m.a+k > aEnd {
+				aEnd = m.a + k
+			}
+			if m.b+k > bEnd {
+				bEnd = m.b + k
+			}
+		}
+		add(aStart, aEnd, bStart, bEnd)
+	}
+	return regions
+}
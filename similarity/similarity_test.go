@@ -0,0 +1,115 @@
+package similarity
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const spiral = `import turtle
+
+t = turtle.Turtle()
+# draw a spiral
+for i in range(50):
+    t.forward(i * 5)
+    t.left(91)
+    if i % 10 == 0:
+        t.color("red")
+    else:
+        t.color("blue")
+`
+
+// spiralCopy is spiral with its identifiers renamed, its constants
+// and comments changed and its spacing shuffled.
+const spiralCopy = `import turtle
+pen   =   turtle.Turtle()
+
+for step in range( 40 ):
+    pen.forward(step*3)    # go!
+    pen.left(89)
+    if step % 5 == 0:
+        pen.color('green')
+    else:
+        pen.color('black')
+`
+
+const squares = `import turtle
+
+t = turtle.Turtle()
+def square(size):
+    while size > 0:
+        t.forward(size)
+        t.right(90)
+        size = size - 10
+
+square(100)
+print("done")
+`
+
+func TestTokenize(t *testing.T) {
+	texts := func(tokens []token) (s []string) {
+		for _, t := range tokens {
+			s = append(s, t.text)
+		}
+		return s
+	}
+
+	assert.Equal(t, []string{"for", "V", "in", "V", "(", "N", ")", ":", "V", "(", "S", ")"},
+		texts(tokenize("python", "for x in range(3):  # loop\n  print('''a\n'b''')")))
+	assert.Equal(t, []string{"const", "V", "=", "S", ";"},
+		texts(tokenize("react", "/* greeting */ const msg = `hi ${name}`; // done")))
+	assert.Equal(t, []string{"<", "p", ">", "hi", "<", "/", "p", ">"},
+		texts(tokenize("html", "<!-- note --><P>Hi</p>")))
+
+	tokens := tokenize("python", "a = '''x\ny'''\nb")
+	require.Len(t, tokens, 4)
+	assert.Equal(t, 3, tokens[3].line)
+}
+
+func TestCompare(t *testing.T) {
+	docs := []Document{
+		{ID: "alice", Language: "python", Code: spiral},
+		{ID: "bob", Language: "python", Code: spiralCopy},
+		{ID: "carol", Language: "python", Code: squares},
+	}
+
+	pairs := Compare(docs, DefaultOptions)
+	require.Len(t, pairs, 1)
+	assert.Equal(t, "alice", pairs[0].A)
+	assert.Equal(t, "bob", pairs[0].B)
+	assert.InDelta(t, 1.0, pairs[0].Score, 0.01)
+	require.NotEmpty(t, pairs[0].Regions)
+	assert.Equal(t, Span{Start: 3, End: 11}, pairs[0].Regions[0].A)
+	assert.Equal(t, Span{Start: 2, End: 10}, pairs[0].Regions[0].B)
+
+	t.Run("Base", func(t *testing.T) {
+		opts := DefaultOptions
+		opts.Base = []Document{{Language: "python", Code: spiral}}
+		assert.Empty(t, Compare(docs, opts), "code given to everyone isn't suspicious")
+	})
+	t.Run("Common", func(t *testing.T) {
+		opts := DefaultOptions
+		opts.MaxDocuments = 1
+		assert.Empty(t, Compare(docs, opts))
+	})
+	t.Run("Ring", func(t *testing.T) {
+		ring := []Document{}
+		for i := 0; i < 12; i++ {
+			ring = append(ring, Document{ID: string(rune('a' + i)), Language: "python", Code: spiral})
+		}
+		for i := 0; i < 14; i++ {
+			ring = append(ring, Document{ID: string(rune('A' + i)), Language: "python", Code: "print(1)"})
+		}
+		pairs := Compare(ring, DefaultOptions)
+		assert.Len(t, pairs, 12*11/2, "groups larger than MaxDocuments are found in large classes")
+
+		assert.Empty(t, Compare(ring[:12], DefaultOptions), "code shared by most of a class is common")
+	})
+	t.Run("Short", func(t *testing.T) {
+		assert.Empty(t, Compare([]Document{
+			{ID: "a", Language: "python", Code: "print(1)"},
+			{ID: "b", Language: "python", Code: "print(2)"},
+		}, DefaultOptions))
+	})
+}
@@ -0,0 +1,151 @@
+package similarity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// token is a normalized token of a program, along with the line it
+// starts on.
+type token struct {
+	text string
+	line int
+}
+
+const (
+	// identToken, numberToken and stringToken replace every
+	// identifier, number and string literal, so that renaming a
+	// variable or changing a constant doesn't hide copied code.
+	identToken  = "V"
+	numberToken = "N"
+	stringToken = "S"
+)
+
+var (
+	pythonKeywords = wordSet(`False None True and as assert async await
+		break class continue def del elif else except finally for from
+		global if import in is lambda nonlocal not or pass raise return
+		try while with yield`)
+
+	// javaScriptKeywords are shared by processing, which is written
+	// in p5.js, and react.
+	javaScriptKeywords = wordSet(`async await break case catch class
+		const continue debugger default delete do else export extends
+		false finally for function if import in instanceof let new null
+		of return super switch this throw true try typeof undefined var
+		void while with yield`)
+)
+
+func wordSet(words string) map[string]bool {
+	set := make(map[string]bool)
+	for _, w := range strings.Fields(words) {
+		set[w] = true
+	}
+	return set
+}
+
+// tokenize splits code in the given language into normalized
+// tokens. Whitespace and comments are dropped, identifiers other
+// than keywords, numbers and string literals are each replaced by a
+// placeholder, and every other character is its own token. HTML
+// has no identifiers, so its words are kept, lowercased.
+func tokenize(language, code string) []token {
+	src := []rune(code)
+	tokens := []token{}
+	line := 1
+	emit := func(text string, at int) {
+		tokens = append(tokens, token{text: text, line: at})
+	}
+	// skip advances i past n runes, counting newlines.
+	skip := func(i, n int) int {
+		for end := i + n; i < end && i < len(src); i++ {
+			if src[i] == '\n' {
+				line++
+			}
+		}
+		return i
+	}
+	hasPrefix := func(i int, prefix string) bool {
+		return strings.HasPrefix(string(src[i:min(len(src), i+len(prefix))]), prefix)
+	}
+	// skipPast advances i to just after the next end, or to the end
+	// of the code if there is none.
+	skipPast := func(i int, end string) int {
+		for i < len(src) && !hasPrefix(i, end) {
+			i = skip(i, 1)
+		}
+		return skip(i, len([]rune(end)))
+	}
+
+	for i := 0; i < len(src); {
+		r := src[i]
+		switch {
+		case unicode.IsSpace(r):
+			i = skip(i, 1)
+
+		// comments
+		case language == "python" && r == '#':
+			i = skipPast(i, "\n")
+		case language == "html" && hasPrefix(i, "<!--"):
+			i = skipPast(i+4, "-->")
+		case language != "python" && language != "html" && hasPrefix(i, "//"):
+			i = skipPast(i, "\n")
+		case language != "python" && language != "html" && hasPrefix(i, "/*"):
+			i = skipPast(i+2, "*/")
+
+		// string literals
+		case r == '"' || r == '\'' || (r == '`' && language != "python"):
+			emit(stringToken, line)
+			quote := string(r)
+			if language == "python" && hasPrefix(i, quote+quote+quote) {
+				quote = quote + quote + quote
+			}
+			i = skip(i, len(quote))
+			for i < len(src) && !hasPrefix(i, quote) {
+				if src[i] == '\\' {
+					i = skip(i, 1)
+				}
+				i = skip(i, 1)
+			}
+			i = skip(i, len(quote))
+
+		case unicode.IsDigit(r):
+			emit(numberToken, line)
+			for i < len(src) && (isWordRune(src[i]) || src[i] == '.') {
+				i++
+			}
+
+		case isWordRune(r):
+			start := i
+			for i < len(src) && isWordRune(src[i]) {
+				i++
+			}
+			word := string(src[start:i])
+			switch {
+			case language == "html":
+				emit(strings.ToLower(word), line)
+			case language == "python" && pythonKeywords[word],
+				language != "python" && javaScriptKeywords[word]:
+				emit(word, line)
+			default:
+				emit(identToken, line)
+			}
+
+		default:
+			emit(string(r), line)
+			i++
+		}
+	}
+	return tokens
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || r == '$' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}